@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"strings"
@@ -8,9 +9,14 @@ import (
 
 	"blytz.cloud/backend/config"
 	"blytz.cloud/backend/internal/auth"
+	"blytz.cloud/backend/internal/billing"
 	"blytz.cloud/backend/internal/handlers"
 	"blytz.cloud/backend/internal/middleware"
+	"blytz.cloud/backend/internal/notify"
+	"blytz.cloud/backend/internal/payments"
 	"blytz.cloud/backend/internal/repository"
+	"blytz.cloud/backend/internal/scheduler"
+	"blytz.cloud/backend/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -27,6 +33,50 @@ func main() {
 	auth.SetJWTSecret(cfg.JWT.Secret)
 	auth.SetCookieName(cfg.JWT.CookieName)
 	handlers.SetForceSecureCookies(cfg.JWT.ForceSecure)
+	handlers.SetSlotHorizonDays(cfg.Schedule.SlotHorizonDays)
+	handlers.SetSlotHoldDuration(time.Duration(cfg.Schedule.SlotHoldMinutes) * time.Minute)
+	// Logged notifications only show their one-time tokens in development.
+	notify.SetNotifier(notify.LogNotifier{ShowSecrets: cfg.Server.Env == "development"})
+	services.SetWaitlistOfferDuration(time.Duration(cfg.Schedule.WaitlistOfferMinutes) * time.Minute)
+	services.SetDepositPaymentTimeout(time.Duration(cfg.Payments.TimeoutMinutes) * time.Minute)
+	services.SetTrialGracePeriod(time.Duration(cfg.Billing.TrialGraceDays) * 24 * time.Hour)
+
+	var fakePayments *payments.FakeProvider
+	switch cfg.Payments.Provider {
+	case "":
+	case "fake":
+		if cfg.Server.Env == "production" {
+			log.Fatal("PAYMENT_PROVIDER=fake cannot be used in production")
+		}
+		if cfg.Payments.WebhookSecret == "" {
+			log.Fatal("PAYMENT_WEBHOOK_SECRET must be explicitly configured")
+		}
+		fakePayments = payments.NewFakeProvider(cfg.Payments.WebhookSecret)
+		services.SetPaymentProvider(fakePayments)
+	default:
+		log.Fatalf("Unknown PAYMENT_PROVIDER %q", cfg.Payments.Provider)
+	}
+
+	var fakeBilling *billing.FakeProvider
+	switch cfg.Billing.Provider {
+	case "":
+	case "hmac":
+		if cfg.Billing.WebhookSecret == "" {
+			log.Fatal("BILLING_WEBHOOK_SECRET must be explicitly configured")
+		}
+		services.SetBillingProvider(billing.NewHMACProvider(cfg.Billing.WebhookSecret))
+	case "fake":
+		if cfg.Server.Env == "production" {
+			log.Fatal("BILLING_PROVIDER=fake cannot be used in production")
+		}
+		if cfg.Billing.WebhookSecret == "" {
+			log.Fatal("BILLING_WEBHOOK_SECRET must be explicitly configured")
+		}
+		fakeBilling = billing.NewFakeProvider(cfg.Billing.WebhookSecret)
+		services.SetBillingProvider(fakeBilling)
+	default:
+		log.Fatalf("Unknown BILLING_PROVIDER %q", cfg.Billing.Provider)
+	}
 
 	// Set Gin mode
 	if cfg.Server.Env == "production" {
@@ -43,6 +93,15 @@ func main() {
 		if err := repo.AutoMigrate(); err != nil {
 			log.Fatalf("Failed to run migrations: %v", err)
 		}
+		if err := repo.MigrateSlotCapacity(); err != nil {
+			log.Fatalf("Failed to migrate slot capacity: %v", err)
+		}
+		if err := repo.MigrateDepositDue(); err != nil {
+			log.Fatalf("Failed to migrate booking deposits: %v", err)
+		}
+		if err := repo.MigrateSubscriptions(services.TrialPeriod); err != nil {
+			log.Fatalf("Failed to migrate subscriptions: %v", err)
+		}
 	}
 
 	if cfg.Startup.BackfillMoney {
@@ -59,6 +118,41 @@ func main() {
 
 	// Initialize handlers
 	handler := handlers.NewHandler(repo)
+	handler.FakePayments = fakePayments
+	handler.FakeBilling = fakeBilling
+
+	if cfg.Schedule.JobsEnabled {
+		ctx := context.Background()
+		go scheduler.Every(ctx, "slot-generation", time.Duration(cfg.Schedule.SlotGenerationIntervalMin)*time.Minute, func(now time.Time) error {
+			return handler.AvailabilityService.RegenerateAll(now, cfg.Schedule.SlotHorizonDays)
+		})
+		go scheduler.Every(ctx, "slot-hold-sweeper", time.Minute, func(now time.Time) error {
+			_, err := handler.SlotHoldService.ReleaseExpired(now)
+			return err
+		})
+		go scheduler.Every(ctx, "waitlist-offer-sweeper", time.Minute, func(now time.Time) error {
+			_, err := handler.WaitlistService.ExpireOffers(now)
+			return err
+		})
+		go scheduler.Every(ctx, "deposit-payment-sweeper", time.Minute, func(now time.Time) error {
+			_, err := handler.BookingService.ExpireUnpaidDeposits(now)
+			return err
+		})
+		go scheduler.Every(ctx, "no-show-sweeper", 15*time.Minute, func(now time.Time) error {
+			_, err := handler.BookingService.MarkNoShows(now, time.Duration(cfg.Schedule.NoShowGraceMinutes)*time.Minute)
+			return err
+		})
+		go scheduler.Every(ctx, "trial-expiry-sweeper", 15*time.Minute, func(now time.Time) error {
+			_, err := handler.SubscriptionService.ExpireTrials(now)
+			return err
+		})
+		go scheduler.Every(ctx, "idempotency-key-sweeper", time.Hour, func(now time.Time) error {
+			_, err := handler.IdempotencyService.DeleteExpired(now)
+			return err
+		})
+	}
+
+	idempotent := middleware.Idempotency(handler.IdempotencyService, 24*time.Hour)
 
 	// Setup Gin router
 	r := gin.Default()
@@ -85,8 +179,8 @@ func main() {
 			}
 		}
 
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, Idempotency-Key, X-Hold-Token, X-Manage-Token")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
 
 		if c.Request.Method == http.MethodOptions {
 			c.AbortWithStatus(204)
@@ -107,6 +201,7 @@ func main() {
 		authRoutes.Use(middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), middleware.RateLimitByIP(30, time.Minute), middleware.RateLimitByIPAndEmail(10, time.Minute))
 		authRoutes.POST("/register", handler.Register)
 		authRoutes.POST("/login", handler.Login)
+		authRoutes.POST("/invitations/accept", handler.AcceptInvitation)
 		v1.POST("/auth/logout", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), auth.AuthMiddleware(handler.AuthService), handler.Logout)
 
 		// Protected routes
@@ -118,29 +213,114 @@ func main() {
 
 		// Services
 		v1.GET("/businesses/:businessId/services", handler.GetServicesByBusiness)
+		v1.GET("/businesses/:businessId/services/:serviceId/add-ons", handler.ListAddOns)
+		v1.GET("/businesses/:businessId/services/:serviceId/price-variants", handler.ListPriceVariants)
 
 		// Slots
 		v1.GET("/businesses/:businessId/slots", handler.GetSlotsByBusiness)
 
+		// Slot holds
+		v1.POST("/slot-holds", middleware.RateLimitByIP(20, time.Minute), handler.CreateSlotHold)
+		v1.DELETE("/slot-holds", middleware.RateLimitByIP(20, time.Minute), handler.ReleaseSlotHold)
+
 		// Bookings
-		v1.POST("/bookings", handler.CreateBooking)
+		v1.POST("/bookings", idempotent, handler.CreateBooking)
+
+		// Payment provider webhooks are authenticated by their signature.
+		v1.POST("/payments/webhook", handler.PaymentWebhook)
+		if fakePayments != nil {
+			v1.POST("/payments/fake/:intentId/complete", handler.CompleteFakePayment)
+		}
+
+		// Billing provider webhooks drive workshop subscriptions.
+		v1.POST("/billing/webhook", handler.BillingWebhook)
+		if fakeBilling != nil {
+			v1.POST("/billing/fake/events", handler.EmitFakeBillingEvent)
+		}
+
+		// Waitlist
+		v1.POST("/waitlist", middleware.RateLimitByIP(20, time.Minute), idempotent, handler.CreateWaitlistEntry)
+
+		// Customer self-service via the manage token returned at booking time,
+		// sent in the X-Manage-Token header
+		manage := v1.Group("/bookings/manage")
+		manage.Use(middleware.RateLimitByIP(30, time.Minute))
+		{
+			manage.GET("", handler.GetManagedBooking)
+			manage.POST("/cancel", handler.CancelManagedBooking)
+			manage.POST("/reschedule", handler.RescheduleManagedBooking)
+		}
 
-		operator := v1.Group("/businesses/:businessId")
-		operator.Use(auth.AuthMiddleware(handler.AuthService), middleware.RequireBusinessMembership(handler.AuthService))
+		workshop := v1.Group("/businesses/:businessId")
+		workshop.Use(auth.AuthMiddleware(handler.AuthService), middleware.RequireBusinessMembership(handler.AuthService))
+
+		// Bookings stay manageable for as long as the workshop takes public
+		// bookings, including a trial's grace period.
+		bookings := workshop.Group("/bookings")
+		bookings.Use(middleware.RequireBookingSubscription(handler.SubscriptionService))
 		{
-			operator.GET("/bookings", handler.ListBookings)
+			bookings.GET("", handler.ListBookings)
+			bookings.PATCH("/:bookingId/status", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.UpdateBookingStatus)
+			bookings.POST("/:bookingId/reschedule", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.RescheduleBooking)
+			bookings.GET("/:bookingId/history", handler.GetBookingHistory)
+			bookings.GET("/:bookingId/payments", handler.ListBookingPayments)
+			bookings.POST("/:bookingId/payments", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), idempotent, handler.RecordPayment)
+			bookings.POST("/:bookingId/refunds", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), idempotent, handler.RecordRefund)
+		}
+
+		operator := workshop.Group("")
+		operator.Use(middleware.RequireActiveSubscription(handler.SubscriptionService))
+		{
+			operator.GET("/subscription", handler.GetSubscription)
+			operator.GET("/usage", handler.GetUsage)
+
+			// Staff invitations (owners only)
+			invitations := operator.Group("/invitations")
+			invitations.Use(middleware.RequireBusinessOwner(handler.AuthService))
+			invitations.GET("", handler.ListInvitations)
+			invitations.POST("", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), middleware.RateLimitByIP(20, time.Minute), idempotent, handler.CreateInvitation)
+			invitations.DELETE("/:invitationId", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.RevokeInvitation)
+			operator.POST("/services", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.CreateService)
+			operator.PUT("/services/:serviceId", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.UpdateService)
+			operator.POST("/services/:serviceId/archive", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.ArchiveService)
+			operator.POST("/services/:serviceId/add-ons", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.CreateAddOn)
+			operator.PUT("/services/:serviceId/add-ons/:addOnId", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.UpdateAddOn)
+			operator.POST("/services/:serviceId/add-ons/:addOnId/archive", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.ArchiveAddOn)
+			operator.PUT("/services/:serviceId/price-variants/:vehicleClass", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.UpsertPriceVariant)
+			operator.DELETE("/services/:serviceId/price-variants/:vehicleClass", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.DeletePriceVariant)
+			operator.GET("/tax-rates", handler.ListTaxRates)
+			operator.POST("/tax-rates", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.CreateTaxRate)
+			operator.PUT("/tax-rates/:taxRateId", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.UpdateTaxRate)
+			operator.DELETE("/tax-rates/:taxRateId", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.DeleteTaxRate)
+			operator.GET("/promo-codes", handler.ListPromoCodes)
+			operator.POST("/promo-codes", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.CreatePromoCode)
+			operator.POST("/promo-codes/:promoCodeId/archive", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.ArchivePromoCode)
+			operator.GET("/waitlist", handler.ListWaitlist)
+			operator.GET("/booking-policy", handler.GetBookingPolicy)
+			operator.PUT("/booking-policy", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.UpdateBookingPolicy)
+			operator.GET("/availability-rules", handler.ListAvailabilityRules)
+			operator.POST("/availability-rules", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.CreateAvailabilityRule)
+			operator.PUT("/availability-rules/:ruleId", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.UpdateAvailabilityRule)
+			operator.DELETE("/availability-rules/:ruleId", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.DeleteAvailabilityRule)
+			operator.POST("/slots/regenerate", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.RegenerateSlots)
 			operator.GET("/customers", handler.ListCustomers)
-			operator.POST("/customers", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.CreateCustomer)
+			operator.POST("/customers", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), idempotent, handler.CreateCustomer)
 			operator.GET("/vehicles", handler.ListVehicles)
-			operator.POST("/vehicles", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.CreateVehicle)
+			operator.POST("/vehicles", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), idempotent, handler.CreateVehicle)
 			operator.GET("/jobs", handler.ListJobs)
-			operator.POST("/jobs", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.CreateJob)
+			operator.POST("/jobs", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), idempotent, handler.CreateJob)
+			operator.GET("/invoices", handler.ListInvoices)
+			operator.POST("/invoices", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), idempotent, handler.CreateInvoice)
+			operator.GET("/invoices/:invoiceId/html", handler.RenderInvoice)
+			operator.POST("/invoices/:invoiceId/issue", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.IssueInvoice)
+			operator.POST("/invoices/:invoiceId/pay", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.MarkInvoicePaid)
+			operator.POST("/invoices/:invoiceId/void", middleware.RequireAllowedOrigin(cfg.CORS.AllowedOrigins), handler.VoidInvoice)
 		}
 	}
 
 	// Start server
 	log.Printf("Allowed CORS origins: %s", strings.Join(cfg.CORS.AllowedOrigins, ", "))
-	log.Printf("Startup flags: auto_migrate=%t seed_data=%t backfill_money=%t", cfg.Startup.AutoMigrate, cfg.Startup.SeedData, cfg.Startup.BackfillMoney)
+	log.Printf("Startup flags: auto_migrate=%t seed_data=%t backfill_money=%t scheduled_jobs=%t payment_provider=%q billing_provider=%q", cfg.Startup.AutoMigrate, cfg.Startup.SeedData, cfg.Startup.BackfillMoney, cfg.Schedule.JobsEnabled, cfg.Payments.Provider, cfg.Billing.Provider)
 	log.Printf("Starting server on port %s...", cfg.Server.Port)
 	if err := r.Run(":" + cfg.Server.Port); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
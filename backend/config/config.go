@@ -12,6 +12,9 @@ type Config struct {
 	CORS     CORSConfig
 	Startup  StartupConfig
 	JWT      JWTConfig
+	Schedule ScheduleConfig
+	Payments PaymentsConfig
+	Billing  BillingConfig
 }
 
 type ServerConfig struct {
@@ -38,6 +41,31 @@ type StartupConfig struct {
 	BackfillMoney bool
 }
 
+type ScheduleConfig struct {
+	JobsEnabled               bool
+	SlotHorizonDays           int
+	SlotGenerationIntervalMin int
+	SlotHoldMinutes           int
+	NoShowGraceMinutes        int
+	WaitlistOfferMinutes      int
+}
+
+// PaymentsConfig selects the provider that collects deposits online. An empty
+// Provider leaves online payments off.
+type PaymentsConfig struct {
+	Provider       string
+	WebhookSecret  string
+	TimeoutMinutes int
+}
+
+// BillingConfig controls the workshop's own subscription to the product. An
+// empty Provider leaves subscriptions without webhook updates.
+type BillingConfig struct {
+	Provider       string
+	WebhookSecret  string
+	TrialGraceDays int
+}
+
 type JWTConfig struct {
 	Secret         string
 	CookieName     string
@@ -73,6 +101,24 @@ func LoadConfig() *Config {
 			ForceSecure:    getEnvAsBool("JWT_COOKIE_SECURE", getEnv("ENV", "development") == "production"),
 			TrustedProxies: getEnvAsSlice("TRUSTED_PROXIES", "127.0.0.1"),
 		},
+		Schedule: ScheduleConfig{
+			JobsEnabled:               getEnvAsBool("SCHEDULED_JOBS_ENABLED", true),
+			SlotHorizonDays:           getEnvAsInt("SLOT_HORIZON_DAYS", 28),
+			SlotGenerationIntervalMin: getEnvAsInt("SLOT_GENERATION_INTERVAL_MINUTES", 60),
+			SlotHoldMinutes:           getEnvAsInt("SLOT_HOLD_MINUTES", 10),
+			NoShowGraceMinutes:        getEnvAsInt("NO_SHOW_GRACE_MINUTES", 60),
+			WaitlistOfferMinutes:      getEnvAsInt("WAITLIST_OFFER_MINUTES", 30),
+		},
+		Payments: PaymentsConfig{
+			Provider:       getEnv("PAYMENT_PROVIDER", ""),
+			WebhookSecret:  getEnv("PAYMENT_WEBHOOK_SECRET", ""),
+			TimeoutMinutes: getEnvAsInt("PAYMENT_TIMEOUT_MINUTES", 15),
+		},
+		Billing: BillingConfig{
+			Provider:       getEnv("BILLING_PROVIDER", ""),
+			WebhookSecret:  getEnv("BILLING_WEBHOOK_SECRET", ""),
+			TrialGraceDays: getEnvAsInt("TRIAL_GRACE_DAYS", 3),
+		},
 	}
 }
 
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const inviteTokenSubject = "staff-invite"
+
+// InviteClaims identifies the invitation a staff invite link was issued for.
+type InviteClaims struct {
+	InvitationID string `json:"invitation_id"`
+	jwt.RegisteredClaims
+}
+
+// GenerateInviteToken signs the token sent with a staff invitation. It stops
+// working at expiresAt; whether it was already used or revoked is tracked on
+// the invitation itself.
+func GenerateInviteToken(invitationID string, expiresAt time.Time) (string, error) {
+	if len(jwtSecret) == 0 {
+		return "", errors.New("jwt secret is not configured")
+	}
+	claims := InviteClaims{
+		InvitationID: invitationID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   inviteTokenSubject,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+// ValidateInviteToken returns the invitation ID an invite token was issued
+// for. Session and manage tokens are rejected even though they share the
+// signing secret.
+func ValidateInviteToken(tokenString string) (string, error) {
+	if len(jwtSecret) == 0 {
+		return "", errors.New("jwt secret is not configured")
+	}
+	token, err := jwt.ParseWithClaims(tokenString, &InviteClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("invalid signing method")
+		}
+		return jwtSecret, nil
+	}, jwt.WithSubject(inviteTokenSubject), jwt.WithExpirationRequired())
+	if err != nil {
+		return "", err
+	}
+
+	claims, ok := token.Claims.(*InviteClaims)
+	if !ok || !token.Valid || claims.InvitationID == "" {
+		return "", errors.New("invalid token")
+	}
+	return claims.InvitationID, nil
+}
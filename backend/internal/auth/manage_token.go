@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const manageTokenSubject = "booking-manage"
+
+// ManageTokenHeader carries a customer's manage token. It is kept out of the
+// URL so it does not end up in access logs.
+const ManageTokenHeader = "X-Manage-Token"
+
+// ManageClaims identifies the single booking a customer's manage link grants
+// access to.
+type ManageClaims struct {
+	BookingID string `json:"booking_id"`
+	jwt.RegisteredClaims
+}
+
+// GenerateManageToken signs a self-service token for one booking that stops
+// working at expiresAt.
+func GenerateManageToken(bookingID string, expiresAt time.Time) (string, error) {
+	if len(jwtSecret) == 0 {
+		return "", errors.New("jwt secret is not configured")
+	}
+	claims := ManageClaims{
+		BookingID: bookingID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   manageTokenSubject,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+// ValidateManageToken returns the booking ID a manage token was issued for.
+// Session tokens are rejected even though they share the signing secret.
+func ValidateManageToken(tokenString string) (string, error) {
+	if len(jwtSecret) == 0 {
+		return "", errors.New("jwt secret is not configured")
+	}
+	token, err := jwt.ParseWithClaims(tokenString, &ManageClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("invalid signing method")
+		}
+		return jwtSecret, nil
+	}, jwt.WithSubject(manageTokenSubject), jwt.WithExpirationRequired())
+	if err != nil {
+		return "", err
+	}
+
+	claims, ok := token.Claims.(*ManageClaims)
+	if !ok || !token.Valid || claims.BookingID == "" {
+		return "", errors.New("invalid token")
+	}
+	return claims.BookingID, nil
+}
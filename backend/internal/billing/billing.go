@@ -0,0 +1,46 @@
+// Package billing receives the workshop's own subscription state from a
+// billing provider. The provider owns the subscription; it announces every
+// change with a signed webhook, which is the only way a subscription's plan
+// or status changes after signup.
+package billing
+
+import (
+	"errors"
+	"time"
+)
+
+// SignatureHeader carries the webhook signature.
+const SignatureHeader = "X-Billing-Signature"
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+type EventType string
+
+const (
+	EventSubscriptionCreated       EventType = "subscription.created"
+	EventSubscriptionUpdated       EventType = "subscription.updated"
+	EventSubscriptionPaymentFailed EventType = "subscription.payment_failed"
+	EventSubscriptionCanceled      EventType = "subscription.canceled"
+)
+
+// Event is a verified webhook notification about a business's subscription.
+// Plan, Status and CurrentPeriodEnd describe the subscription after the
+// change; OccurredAt orders events that arrive out of sequence.
+type Event struct {
+	ID               string    `json:"id"`
+	Type             EventType `json:"type"`
+	BusinessID       string    `json:"business_id"`
+	Plan             string    `json:"plan,omitempty"`
+	Status           string    `json:"status,omitempty"`
+	CurrentPeriodEnd time.Time `json:"current_period_end"`
+	OccurredAt       time.Time `json:"occurred_at"`
+}
+
+type Provider interface {
+	// Name identifies the provider on recorded events.
+	Name() string
+	// VerifyWebhook checks the signature on a webhook body and decodes it.
+	// It returns ErrInvalidSignature when the body was not sent by the
+	// provider.
+	VerifyWebhook(payload []byte, signature string) (Event, error)
+}
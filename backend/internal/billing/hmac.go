@@ -0,0 +1,46 @@
+package billing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// HMACProvider accepts webhooks from any billing provider that signs the body
+// with a hex HMAC-SHA256 under a shared secret and sends events in the Event
+// format. It is the provider to use in production.
+type HMACProvider struct {
+	secret []byte
+}
+
+func NewHMACProvider(webhookSecret string) *HMACProvider {
+	return &HMACProvider{secret: []byte(webhookSecret)}
+}
+
+func (p *HMACProvider) Name() string {
+	return "hmac"
+}
+
+func (p *HMACProvider) VerifyWebhook(payload []byte, signature string) (Event, error) {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, p.mac(payload)) {
+		return Event{}, ErrInvalidSignature
+	}
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return Event{}, err
+	}
+	return event, nil
+}
+
+// sign returns the signature the provider sends with payload.
+func (p *HMACProvider) sign(payload []byte) string {
+	return hex.EncodeToString(p.mac(payload))
+}
+
+func (p *HMACProvider) mac(payload []byte) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
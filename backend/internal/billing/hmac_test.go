@@ -0,0 +1,27 @@
+package billing
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHMACProviderVerifiesSignedWebhooks(t *testing.T) {
+	provider := NewHMACProvider("billing-secret")
+	payload := []byte(`{"id":"evt_1","type":"subscription.updated","business_id":"b1","plan":"PRO","status":"ACTIVE"}`)
+
+	event, err := provider.VerifyWebhook(payload, provider.sign(payload))
+	if err != nil {
+		t.Fatalf("verify webhook: %v", err)
+	}
+	if event.ID != "evt_1" || event.Type != EventSubscriptionUpdated || event.Plan != "PRO" {
+		t.Fatalf("expected the event to be decoded, got %+v", event)
+	}
+
+	forged := NewHMACProvider("other-secret").sign(payload)
+	if _, err := provider.VerifyWebhook(payload, forged); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected a signature under another secret to be rejected, got %v", err)
+	}
+	if _, err := provider.VerifyWebhook(payload, "not-hex"); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected a malformed signature to be rejected, got %v", err)
+	}
+}
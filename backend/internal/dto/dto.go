@@ -8,6 +8,15 @@ type RegisterRequest struct {
 	Password string `json:"password" binding:"required,min=6"`
 }
 
+// AcceptInvitationRequest redeems a staff invite. Name is only needed when no
+// account exists for the invited email; otherwise Password must be that
+// account's password.
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Name     string `json:"name"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
@@ -27,10 +36,12 @@ type MembershipBusinessResponse struct {
 }
 
 type MembershipResponse struct {
-	ID         string                     `json:"id"`
-	BusinessID string                     `json:"business_id"`
-	Role       string                     `json:"role"`
-	Business   MembershipBusinessResponse `json:"business"`
+	ID                 string                     `json:"id"`
+	BusinessID         string                     `json:"business_id"`
+	Role               string                     `json:"role"`
+	Business           MembershipBusinessResponse `json:"business"`
+	SubscriptionStatus string                     `json:"subscription_status,omitempty"`
+	TrialDaysRemaining *int                       `json:"trial_days_remaining,omitempty"`
 }
 
 type CurrentUserResponse struct {
@@ -77,38 +88,113 @@ type UpdateBusinessRequest struct {
 // Service DTOs
 
 type ServiceResponse struct {
-	ID                 string `json:"id"`
-	BusinessID         string `json:"business_id"`
-	Name               string `json:"name"`
-	Description        string `json:"description"`
-	DurationMin        int    `json:"duration_min"`
-	TotalPriceMinor    int64  `json:"total_price_minor"`
-	DepositAmountMinor int64  `json:"deposit_amount_minor"`
-	CurrencyCode       string `json:"currency_code"`
-	CreatedAt          string `json:"created_at"`
-	UpdatedAt          string `json:"updated_at"`
+	ID                 string  `json:"id"`
+	BusinessID         string  `json:"business_id"`
+	Name               string  `json:"name"`
+	Description        string  `json:"description"`
+	DurationMin        int     `json:"duration_min"`
+	TotalPriceMinor    int64   `json:"total_price_minor"`
+	DepositAmountMinor int64   `json:"deposit_amount_minor"`
+	CurrencyCode       string  `json:"currency_code"`
+	ArchivedAt         *string `json:"archived_at,omitempty"`
+	CreatedAt          string  `json:"created_at"`
+	UpdatedAt          string  `json:"updated_at"`
 }
 
+// CreateServiceRequest is also used for full updates. The business comes from
+// the route.
 type CreateServiceRequest struct {
-	BusinessID         string `json:"business_id" binding:"required,uuid"`
 	Name               string `json:"name" binding:"required"`
 	Description        string `json:"description"`
 	DurationMin        int    `json:"duration_min" binding:"required,min=1"`
 	TotalPriceMinor    int64  `json:"total_price_minor" binding:"required,gt=0"`
-	DepositAmountMinor int64  `json:"deposit_amount_minor" binding:"required,gte=0"`
+	DepositAmountMinor int64  `json:"deposit_amount_minor" binding:"gte=0"`
 	CurrencyCode       string `json:"currency_code" binding:"required,len=3"`
 }
 
+type AddOnResponse struct {
+	ID               string `json:"id"`
+	ServiceID        string `json:"service_id"`
+	Name             string `json:"name"`
+	PriceMinor       int64  `json:"price_minor"`
+	ExtraDurationMin int    `json:"extra_duration_min"`
+}
+
+type AddOnRequest struct {
+	Name             string `json:"name" binding:"required"`
+	PriceMinor       int64  `json:"price_minor" binding:"gte=0"`
+	ExtraDurationMin int    `json:"extra_duration_min" binding:"gte=0"`
+}
+
+type PriceVariantResponse struct {
+	ServiceID          string `json:"service_id"`
+	VehicleClass       string `json:"vehicle_class"`
+	TotalPriceMinor    int64  `json:"total_price_minor"`
+	DepositAmountMinor int64  `json:"deposit_amount_minor"`
+}
+
+type PriceVariantRequest struct {
+	TotalPriceMinor    int64 `json:"total_price_minor" binding:"required,gt=0"`
+	DepositAmountMinor int64 `json:"deposit_amount_minor" binding:"gte=0"`
+}
+
+// Tax rate DTOs
+
+type TaxRateResponse struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	RateBasisPoints int    `json:"rate_basis_points"`
+	Inclusive       bool   `json:"inclusive"`
+}
+
+type TaxRateRequest struct {
+	Name            string `json:"name" binding:"required"`
+	RateBasisPoints int    `json:"rate_basis_points" binding:"required,min=1,max=10000"`
+	Inclusive       bool   `json:"inclusive"`
+}
+
+// Promo code DTOs
+
+type PromoCodeResponse struct {
+	ID               string   `json:"id"`
+	Code             string   `json:"code"`
+	DiscountType     string   `json:"discount_type"`
+	PercentOff       int      `json:"percent_off"`
+	AmountOffMinor   int64    `json:"amount_off_minor"`
+	ValidFrom        string   `json:"valid_from,omitempty"`
+	ValidUntil       string   `json:"valid_until,omitempty"`
+	MaxRedemptions   int      `json:"max_redemptions"`
+	PerCustomerLimit int      `json:"per_customer_limit"`
+	RedemptionCount  int      `json:"redemption_count"`
+	ServiceIDs       []string `json:"service_ids"`
+	ArchivedAt       string   `json:"archived_at,omitempty"`
+	CreatedAt        string   `json:"created_at"`
+}
+
+type CreatePromoCodeRequest struct {
+	Code             string   `json:"code" binding:"required"`
+	DiscountType     string   `json:"discount_type" binding:"required,oneof=PERCENT FIXED"`
+	PercentOff       int      `json:"percent_off" binding:"gte=0,lte=100"`
+	AmountOffMinor   int64    `json:"amount_off_minor" binding:"gte=0"`
+	ValidFrom        string   `json:"valid_from"`
+	ValidUntil       string   `json:"valid_until"`
+	MaxRedemptions   int      `json:"max_redemptions" binding:"gte=0"`
+	PerCustomerLimit int      `json:"per_customer_limit" binding:"gte=0"`
+	ServiceIDs       []string `json:"service_ids" binding:"omitempty,dive,uuid"`
+}
+
 // Slot DTOs
 
 type SlotResponse struct {
-	ID         string `json:"id"`
-	BusinessID string `json:"business_id"`
-	StartTime  string `json:"start_time"`
-	EndTime    string `json:"end_time"`
-	IsBooked   bool   `json:"is_booked"`
-	CreatedAt  string `json:"created_at"`
-	UpdatedAt  string `json:"updated_at"`
+	ID                string `json:"id"`
+	BusinessID        string `json:"business_id"`
+	StartTime         string `json:"start_time"`
+	EndTime           string `json:"end_time"`
+	IsBooked          bool   `json:"is_booked"`
+	Capacity          int    `json:"capacity"`
+	RemainingCapacity int    `json:"remaining_capacity"`
+	CreatedAt         string `json:"created_at"`
+	UpdatedAt         string `json:"updated_at"`
 }
 
 type CreateSlotRequest struct {
@@ -117,6 +203,76 @@ type CreateSlotRequest struct {
 	EndTime    string `json:"end_time" binding:"required,gtfield=StartTime"`
 }
 
+type CreateSlotHoldRequest struct {
+	BusinessID string `json:"business_id" binding:"required,uuid"`
+	ServiceID  string `json:"service_id" binding:"required,uuid"`
+	SlotID     string `json:"slot_id" binding:"required,uuid"`
+}
+
+type SlotHoldResponse struct {
+	HoldToken  string `json:"hold_token"`
+	BusinessID string `json:"business_id"`
+	ServiceID  string `json:"service_id"`
+	SlotID     string `json:"slot_id"`
+	ExpiresAt  string `json:"expires_at"`
+}
+
+// Waitlist DTOs
+type CreateWaitlistEntryRequest struct {
+	BusinessID    string          `json:"business_id" binding:"required,uuid"`
+	ServiceID     string          `json:"service_id" binding:"required,uuid"`
+	PreferredFrom string          `json:"preferred_from" binding:"required"`
+	PreferredTo   string          `json:"preferred_to" binding:"required"`
+	Customer      CustomerDetails `json:"customer" binding:"required"`
+}
+
+type WaitlistEntryResponse struct {
+	ID             string          `json:"id"`
+	BusinessID     string          `json:"business_id"`
+	ServiceID      string          `json:"service_id"`
+	PreferredFrom  string          `json:"preferred_from"`
+	PreferredTo    string          `json:"preferred_to"`
+	Customer       CustomerDetails `json:"customer"`
+	Status         string          `json:"status"`
+	OfferExpiresAt *string         `json:"offer_expires_at,omitempty"`
+	CreatedAt      string          `json:"created_at"`
+}
+
+// Availability DTOs
+
+type AvailabilityRuleResponse struct {
+	ID              string `json:"id"`
+	BusinessID      string `json:"business_id"`
+	Weekday         int    `json:"weekday"`
+	StartTime       string `json:"start_time"`
+	EndTime         string `json:"end_time"`
+	SlotDurationMin int    `json:"slot_duration_min"`
+	Capacity        int    `json:"capacity"`
+	BreakStart      string `json:"break_start,omitempty"`
+	BreakEnd        string `json:"break_end,omitempty"`
+	Timezone        string `json:"timezone"`
+	CreatedAt       string `json:"created_at"`
+	UpdatedAt       string `json:"updated_at"`
+}
+
+type AvailabilityRuleRequest struct {
+	Weekday         *int   `json:"weekday" binding:"required,min=0,max=6"`
+	StartTime       string `json:"start_time" binding:"required"`
+	EndTime         string `json:"end_time" binding:"required"`
+	SlotDurationMin int    `json:"slot_duration_min" binding:"required,min=5"`
+	Capacity        int    `json:"capacity" binding:"omitempty,min=1"`
+	BreakStart      string `json:"break_start"`
+	BreakEnd        string `json:"break_end"`
+	Timezone        string `json:"timezone"`
+}
+
+type SlotGenerationResponse struct {
+	Created int    `json:"created"`
+	Removed int    `json:"removed"`
+	From    string `json:"from"`
+	Until   string `json:"until"`
+}
+
 // Booking DTOs
 
 type CustomerDetails struct {
@@ -126,43 +282,137 @@ type CustomerDetails struct {
 }
 
 type BookingResponse struct {
-	ID               string          `json:"id"`
-	BusinessID       string          `json:"business_id"`
-	ServiceID        string          `json:"service_id"`
-	SlotID           string          `json:"slot_id"`
-	ServiceName      string          `json:"service_name"`
-	SlotTime         string          `json:"slot_time"`
-	Customer         CustomerDetails `json:"customer"`
-	Status           string          `json:"status"`
-	DepositPaidMinor int64           `json:"deposit_paid_minor"`
-	TotalPriceMinor  int64           `json:"total_price_minor"`
-	CurrencyCode     string          `json:"currency_code"`
-	CreatedAt        string          `json:"created_at"`
-	UpdatedAt        string          `json:"updated_at"`
+	ID               string                  `json:"id"`
+	BusinessID       string                  `json:"business_id"`
+	ServiceID        string                  `json:"service_id"`
+	SlotID           string                  `json:"slot_id"`
+	ServiceName      string                  `json:"service_name"`
+	SlotTime         string                  `json:"slot_time"`
+	DurationMin      int                     `json:"duration_min"`
+	VehicleID        string                  `json:"vehicle_id,omitempty"`
+	VehicleClass     string                  `json:"vehicle_class,omitempty"`
+	Customer         CustomerDetails         `json:"customer"`
+	Status           string                  `json:"status"`
+	DepositDueMinor  int64                   `json:"deposit_due_minor"`
+	DepositPaidMinor int64                   `json:"deposit_paid_minor"`
+	TotalPriceMinor  int64                   `json:"total_price_minor"`
+	DiscountMinor    int64                   `json:"discount_minor"`
+	TaxMinor         int64                   `json:"tax_minor"`
+	PromoCode        string                  `json:"promo_code,omitempty"`
+	CurrencyCode     string                  `json:"currency_code"`
+	RefundableMinor  int64                   `json:"refundable_minor"`
+	ForfeitedMinor   int64                   `json:"forfeited_minor"`
+	LineItems        []LineItemResponse      `json:"line_items"`
+	Breakdown        *PriceBreakdownResponse `json:"breakdown,omitempty"`
+	ManageToken      string                  `json:"manage_token,omitempty"`
+	DepositPayment   *DepositPaymentResponse `json:"deposit_payment,omitempty"`
+	CreatedAt        string                  `json:"created_at"`
+	UpdatedAt        string                  `json:"updated_at"`
+}
+
+// DepositPaymentResponse is what the customer needs to pay the deposit online
+// with the payment provider.
+type DepositPaymentResponse struct {
+	Provider     string `json:"provider"`
+	IntentID     string `json:"intent_id"`
+	ClientSecret string `json:"client_secret"`
+	AmountMinor  int64  `json:"amount_minor"`
+	CurrencyCode string `json:"currency_code"`
+	ExpiresAt    string `json:"expires_at"`
+}
+
+// CompleteFakePaymentRequest settles a fake provider intent in development.
+type CompleteFakePaymentRequest struct {
+	Succeeded bool `json:"succeeded"`
+}
+
+// EmitFakeBillingEventRequest sends a fake billing provider event in
+// development.
+type EmitFakeBillingEventRequest struct {
+	BusinessID       string `json:"business_id" binding:"required,uuid"`
+	Type             string `json:"type" binding:"required,oneof=subscription.created subscription.updated subscription.payment_failed subscription.canceled"`
+	Plan             string `json:"plan"`
+	Status           string `json:"status"`
+	CurrentPeriodEnd string `json:"current_period_end"`
+}
+
+type LineItemResponse struct {
+	Kind            string  `json:"kind"`
+	ReferenceID     *string `json:"reference_id,omitempty"`
+	Name            string  `json:"name"`
+	Quantity        int     `json:"quantity"`
+	UnitAmountMinor int64   `json:"unit_amount_minor"`
+	AmountMinor     int64   `json:"amount_minor"`
+	CurrencyCode    string  `json:"currency_code"`
+	TaxInclusive    bool    `json:"tax_inclusive,omitempty"`
+	DurationMin     int     `json:"duration_min"`
+}
+
+// PriceBreakdownResponse is derived from a booking's line items.
+type PriceBreakdownResponse struct {
+	SubtotalMinor int64 `json:"subtotal_minor"`
+	DiscountMinor int64 `json:"discount_minor"`
+	TaxMinor      int64 `json:"tax_minor"`
+	TotalMinor    int64 `json:"total_minor"`
+}
+
+type BookingPolicyResponse struct {
+	BusinessID               string `json:"business_id"`
+	SelfServiceCutoffHours   int    `json:"self_service_cutoff_hours"`
+	FreeCancelNoticeHours    int    `json:"free_cancel_notice_hours"`
+	LateCancelDepositKeepPct int    `json:"late_cancel_deposit_keep_pct"`
+	NoShowDepositKeepPct     int    `json:"no_show_deposit_keep_pct"`
+}
+
+// UpdateBookingPolicyRequest leaves omitted fields at their current values.
+type UpdateBookingPolicyRequest struct {
+	SelfServiceCutoffHours   *int `json:"self_service_cutoff_hours" binding:"omitempty,min=0"`
+	FreeCancelNoticeHours    *int `json:"free_cancel_notice_hours" binding:"omitempty,min=0"`
+	LateCancelDepositKeepPct *int `json:"late_cancel_deposit_keep_pct" binding:"omitempty,min=0,max=100"`
+	NoShowDepositKeepPct     *int `json:"no_show_deposit_keep_pct" binding:"omitempty,min=0,max=100"`
 }
 
 type CreateBookingRequest struct {
-	BusinessID string          `json:"business_id" binding:"required,uuid"`
-	ServiceID  string          `json:"service_id" binding:"required,uuid"`
-	SlotID     string          `json:"slot_id" binding:"required,uuid"`
-	Customer   CustomerDetails `json:"customer" binding:"required"`
+	BusinessID   string          `json:"business_id" binding:"required,uuid"`
+	ServiceID    string          `json:"service_id" binding:"required,uuid"`
+	SlotID       string          `json:"slot_id" binding:"required,uuid"`
+	HoldToken    string          `json:"hold_token,omitempty"`
+	AddOnIDs     []string        `json:"add_on_ids" binding:"omitempty,dive,uuid"`
+	VehicleClass string          `json:"vehicle_class" binding:"omitempty,oneof=SEDAN HATCHBACK SUV TRUCK VAN"`
+	VehicleID    string          `json:"vehicle_id" binding:"omitempty,uuid"`
+	PromoCode    string          `json:"promo_code"`
+	Customer     CustomerDetails `json:"customer" binding:"required"`
 }
 
 type UpdateBookingStatusRequest struct {
-	Status string `json:"status" binding:"required,oneof=PENDING CONFIRMED COMPLETED CANCELLED"`
+	Status string `json:"status" binding:"required,oneof=PENDING CONFIRMED COMPLETED CANCELLED NO_SHOW"`
+}
+
+type RescheduleBookingRequest struct {
+	SlotID string `json:"slot_id" binding:"required,uuid"`
+}
+
+type BookingHistoryResponse struct {
+	ID               string `json:"id"`
+	BookingID        string `json:"booking_id"`
+	Action           string `json:"action"`
+	PreviousSlotID   string `json:"previous_slot_id,omitempty"`
+	PreviousSlotTime string `json:"previous_slot_time,omitempty"`
+	CreatedAt        string `json:"created_at"`
 }
 
 // Customer DTOs
 
 type CustomerResponse struct {
-	ID         string `json:"id"`
-	BusinessID string `json:"business_id"`
-	Name       string `json:"name"`
-	Email      string `json:"email"`
-	Phone      string `json:"phone"`
-	Notes      string `json:"notes"`
-	CreatedAt  string `json:"created_at"`
-	UpdatedAt  string `json:"updated_at"`
+	ID          string `json:"id"`
+	BusinessID  string `json:"business_id"`
+	Name        string `json:"name"`
+	Email       string `json:"email"`
+	Phone       string `json:"phone"`
+	Notes       string `json:"notes"`
+	NoShowCount int    `json:"no_show_count"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
 }
 
 type CreateCustomerRequest struct {
@@ -183,6 +433,7 @@ type VehicleResponse struct {
 	Model        string           `json:"model"`
 	Color        string           `json:"color"`
 	LicensePlate string           `json:"license_plate"`
+	Class        string           `json:"class"`
 	Customer     CustomerResponse `json:"customer"`
 	CreatedAt    string           `json:"created_at"`
 	UpdatedAt    string           `json:"updated_at"`
@@ -195,34 +446,203 @@ type CreateVehicleRequest struct {
 	Model        string `json:"model" binding:"required"`
 	Color        string `json:"color"`
 	LicensePlate string `json:"license_plate"`
+	Class        string `json:"class" binding:"omitempty,oneof=SEDAN HATCHBACK SUV TRUCK VAN"`
 }
 
 // Job DTOs
 
 type JobResponse struct {
-	ID          string           `json:"id"`
-	BusinessID  string           `json:"business_id"`
-	CustomerID  string           `json:"customer_id"`
-	VehicleID   string           `json:"vehicle_id"`
-	BookingID   string           `json:"booking_id,omitempty"`
-	Title       string           `json:"title"`
-	Status      string           `json:"status"`
-	ScheduledAt string           `json:"scheduled_at"`
-	Notes       string           `json:"notes"`
-	Customer    CustomerResponse `json:"customer"`
-	Vehicle     VehicleResponse  `json:"vehicle"`
-	CreatedAt   string           `json:"created_at"`
-	UpdatedAt   string           `json:"updated_at"`
+	ID          string                `json:"id"`
+	BusinessID  string                `json:"business_id"`
+	CustomerID  string                `json:"customer_id"`
+	VehicleID   string                `json:"vehicle_id"`
+	BookingID   string                `json:"booking_id,omitempty"`
+	Title       string                `json:"title"`
+	Status      string                `json:"status"`
+	ScheduledAt string                `json:"scheduled_at"`
+	Notes       string                `json:"notes"`
+	Customer    CustomerResponse      `json:"customer"`
+	Vehicle     VehicleResponse       `json:"vehicle"`
+	LineItems   []JobLineItemResponse `json:"line_items"`
+	CreatedAt   string                `json:"created_at"`
+	UpdatedAt   string                `json:"updated_at"`
+}
+
+type JobLineItemResponse struct {
+	Name            string `json:"name"`
+	Quantity        int    `json:"quantity"`
+	UnitAmountMinor int64  `json:"unit_amount_minor"`
+	AmountMinor     int64  `json:"amount_minor"`
 }
 
 type CreateJobRequest struct {
-	CustomerID  string `json:"customer_id" binding:"required,uuid"`
-	VehicleID   string `json:"vehicle_id" binding:"required,uuid"`
-	BookingID   string `json:"booking_id,omitempty" binding:"omitempty,uuid"`
-	Title       string `json:"title" binding:"required"`
-	Status      string `json:"status" binding:"omitempty,oneof=SCHEDULED IN_PROGRESS READY DELIVERED"`
-	ScheduledAt string `json:"scheduled_at" binding:"required"`
-	Notes       string `json:"notes"`
+	CustomerID  string               `json:"customer_id" binding:"required,uuid"`
+	VehicleID   string               `json:"vehicle_id" binding:"required,uuid"`
+	BookingID   string               `json:"booking_id,omitempty" binding:"omitempty,uuid"`
+	Title       string               `json:"title" binding:"required"`
+	Status      string               `json:"status" binding:"omitempty,oneof=SCHEDULED IN_PROGRESS READY DELIVERED"`
+	ScheduledAt string               `json:"scheduled_at" binding:"required"`
+	Notes       string               `json:"notes"`
+	LineItems   []JobLineItemRequest `json:"line_items" binding:"omitempty,dive"`
+}
+
+// JobLineItemRequest is extra work or parts billed on top of the booking.
+type JobLineItemRequest struct {
+	Name            string `json:"name" binding:"required"`
+	Quantity        int    `json:"quantity" binding:"omitempty,min=1"`
+	UnitAmountMinor int64  `json:"unit_amount_minor" binding:"gte=0"`
+}
+
+// Invoice DTOs
+
+type InvoiceResponse struct {
+	ID                 string                `json:"id"`
+	BusinessID         string                `json:"business_id"`
+	Number             string                `json:"number,omitempty"`
+	BookingID          string                `json:"booking_id"`
+	JobID              string                `json:"job_id,omitempty"`
+	Status             string                `json:"status"`
+	Reference          string                `json:"reference,omitempty"`
+	CustomerName       string                `json:"customer_name"`
+	CustomerEmail      string                `json:"customer_email"`
+	CurrencyCode       string                `json:"currency_code"`
+	SubtotalMinor      int64                 `json:"subtotal_minor"`
+	DiscountMinor      int64                 `json:"discount_minor"`
+	TaxMinor           int64                 `json:"tax_minor"`
+	TotalMinor         int64                 `json:"total_minor"`
+	DepositCreditMinor int64                 `json:"deposit_credit_minor"`
+	AmountDueMinor     int64                 `json:"amount_due_minor"`
+	Lines              []InvoiceLineResponse `json:"lines"`
+	IssuedAt           string                `json:"issued_at,omitempty"`
+	PaidAt             string                `json:"paid_at,omitempty"`
+	VoidedAt           string                `json:"voided_at,omitempty"`
+	CreatedAt          string                `json:"created_at"`
+}
+
+type InvoiceLineResponse struct {
+	Kind            string `json:"kind"`
+	Description     string `json:"description"`
+	Quantity        int    `json:"quantity"`
+	UnitAmountMinor int64  `json:"unit_amount_minor"`
+	AmountMinor     int64  `json:"amount_minor"`
+	TaxInclusive    bool   `json:"tax_inclusive,omitempty"`
+}
+
+// CreateInvoiceRequest names exactly one of a booking or a job to invoice.
+type CreateInvoiceRequest struct {
+	BookingID string `json:"booking_id" binding:"omitempty,uuid"`
+	JobID     string `json:"job_id" binding:"omitempty,uuid"`
+}
+
+// Invitation DTOs
+
+type CreateInvitationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=OWNER STAFF"`
+}
+
+type InvitationResponse struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	ExpiresAt string `json:"expires_at"`
+	CreatedAt string `json:"created_at"`
+}
+
+// Subscription DTOs
+
+type SubscriptionResponse struct {
+	Plan               string `json:"plan"`
+	Status             string `json:"status"`
+	CurrentPeriodEnd   string `json:"current_period_end"`
+	CanceledAt         string `json:"canceled_at,omitempty"`
+	TrialDaysRemaining *int   `json:"trial_days_remaining,omitempty"`
+	Active             bool   `json:"active"`
+}
+
+// UsageMetric is how much of one plan limit is used. A null limit is
+// unlimited.
+type UsageMetric struct {
+	Used  int64 `json:"used"`
+	Limit *int  `json:"limit"`
+}
+
+type UsageResponse struct {
+	Plan            string      `json:"plan"`
+	PeriodStart     string      `json:"period_start"`
+	StaffSeats      UsageMetric `json:"staff_seats"`
+	MonthlyBookings UsageMetric `json:"monthly_bookings"`
+	Services        UsageMetric `json:"services"`
+}
+
+// SubscriptionStateResponse is the billing state reported when a change is
+// refused because of the subscription.
+type SubscriptionStateResponse struct {
+	Plan             string `json:"plan"`
+	Status           string `json:"status"`
+	CurrentPeriodEnd string `json:"current_period_end"`
+}
+
+// SubscriptionInactiveResponse is returned with 402 when a change is blocked
+// because the workshop's subscription has lapsed. Subscription is omitted
+// when the workshop has none.
+type SubscriptionInactiveResponse struct {
+	Error        string                     `json:"error"`
+	Code         string                     `json:"code"`
+	Subscription *SubscriptionStateResponse `json:"subscription,omitempty"`
+}
+
+// PlanLimitResponse is returned with 402 when a change would go over the
+// workshop's plan.
+type PlanLimitResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+	Limit string `json:"limit"`
+	Max   int    `json:"max"`
+}
+
+// Payment DTOs
+
+// RecordPaymentRequest records a payment or refund. RecordedAt is RFC3339 and
+// defaults to now; InvoiceID optionally ties the entry to one of the
+// booking's invoices.
+type RecordPaymentRequest struct {
+	AmountMinor int64  `json:"amount_minor" binding:"required,min=1"`
+	Method      string `json:"method" binding:"required,oneof=CASH CARD TRANSFER"`
+	InvoiceID   string `json:"invoice_id" binding:"omitempty,uuid"`
+	RecordedAt  string `json:"recorded_at"`
+	Note        string `json:"note" binding:"max=500"`
+}
+
+type PaymentRecordResponse struct {
+	ID           string `json:"id"`
+	BookingID    string `json:"booking_id"`
+	InvoiceID    string `json:"invoice_id,omitempty"`
+	Kind         string `json:"kind"`
+	Method       string `json:"method"`
+	AmountMinor  int64  `json:"amount_minor"`
+	CurrencyCode string `json:"currency_code"`
+	Note         string `json:"note,omitempty"`
+	RecordedAt   string `json:"recorded_at"`
+	RecordedByID string `json:"recorded_by_id,omitempty"`
+}
+
+type BookingBalanceResponse struct {
+	CurrencyCode  string `json:"currency_code"`
+	TotalMinor    int64  `json:"total_minor"`
+	PaidMinor     int64  `json:"paid_minor"`
+	RefundedMinor int64  `json:"refunded_minor"`
+	BalanceMinor  int64  `json:"balance_minor"`
+}
+
+type BookingPaymentsResponse struct {
+	Payments []PaymentRecordResponse `json:"payments"`
+	Balance  BookingBalanceResponse  `json:"balance"`
+}
+
+type RecordPaymentResponse struct {
+	Payment PaymentRecordResponse  `json:"payment"`
+	Balance BookingBalanceResponse `json:"balance"`
 }
 
 // Error Response DTO
@@ -1,12 +1,19 @@
 package handlers
 
 import (
+	"bytes"
+	"fmt"
+	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"blytz.cloud/backend/internal/auth"
+	"blytz.cloud/backend/internal/billing"
 	"blytz.cloud/backend/internal/dto"
+	"blytz.cloud/backend/internal/invoice"
 	"blytz.cloud/backend/internal/models"
+	"blytz.cloud/backend/internal/payments"
 	"blytz.cloud/backend/internal/repository"
 	"blytz.cloud/backend/internal/services"
 
@@ -15,15 +22,34 @@ import (
 )
 
 type Handler struct {
-	Repo            *repository.Repository
-	AuthService     *services.AuthService
-	BusinessService *services.BusinessService
-	ServiceService  *services.ServiceService
-	SlotService     *services.SlotService
-	BookingService  *services.BookingService
-	CustomerService *services.CustomerService
-	VehicleService  *services.VehicleService
-	JobService      *services.JobService
+	Repo                 *repository.Repository
+	AuthService          *services.AuthService
+	BusinessService      *services.BusinessService
+	ServiceService       *services.ServiceService
+	SlotService          *services.SlotService
+	BookingService       *services.BookingService
+	CustomerService      *services.CustomerService
+	VehicleService       *services.VehicleService
+	JobService           *services.JobService
+	AvailabilityService  *services.AvailabilityService
+	SlotHoldService      *services.SlotHoldService
+	IdempotencyService   *services.IdempotencyService
+	BookingPolicyService *services.BookingPolicyService
+	WaitlistService      *services.WaitlistService
+	AddOnService         *services.AddOnService
+	PriceVariantService  *services.PriceVariantService
+	PromoCodeService     *services.PromoCodeService
+	TaxRateService       *services.TaxRateService
+	InvoiceService       *services.InvoiceService
+	PaymentService       *services.PaymentService
+	SubscriptionService  *services.SubscriptionService
+	InvitationService    *services.InvitationService
+	// FakePayments is set when the in-process payment provider is in use, so
+	// deposits can be paid without a real provider.
+	FakePayments *payments.FakeProvider
+	// FakeBilling is set when the in-process billing provider is in use, so
+	// subscription changes can be simulated without a real provider.
+	FakeBilling *billing.FakeProvider
 }
 
 var forceSecureCookies bool
@@ -32,21 +58,54 @@ func SetForceSecureCookies(force bool) {
 	forceSecureCookies = force
 }
 
+var slotHorizonDays = 28
+
+func SetSlotHorizonDays(days int) {
+	if days > 0 {
+		slotHorizonDays = days
+	}
+}
+
+var slotHoldDuration = 10 * time.Minute
+
+func SetSlotHoldDuration(duration time.Duration) {
+	if duration > 0 {
+		slotHoldDuration = duration
+	}
+}
+
+// holdTokenHeader carries the token of a slot hold being released, keeping it
+// out of the URL and so out of access logs.
+const holdTokenHeader = "X-Hold-Token"
+
 func getCurrentUserID(c *gin.Context) (uuid.UUID, error) {
 	return uuid.Parse(c.GetString("user_id"))
 }
 
 func NewHandler(repo *repository.Repository) *Handler {
 	return &Handler{
-		Repo:            repo,
-		AuthService:     services.NewAuthService(repo.DB),
-		BusinessService: services.NewBusinessService(repo.DB),
-		ServiceService:  services.NewServiceService(repo.DB),
-		SlotService:     services.NewSlotService(repo.DB),
-		BookingService:  services.NewBookingService(repo.DB),
-		CustomerService: services.NewCustomerService(repo.DB),
-		VehicleService:  services.NewVehicleService(repo.DB),
-		JobService:      services.NewJobService(repo.DB),
+		Repo:                 repo,
+		AuthService:          services.NewAuthService(repo.DB),
+		BusinessService:      services.NewBusinessService(repo.DB),
+		ServiceService:       services.NewServiceService(repo.DB),
+		SlotService:          services.NewSlotService(repo.DB),
+		BookingService:       services.NewBookingService(repo.DB),
+		CustomerService:      services.NewCustomerService(repo.DB),
+		VehicleService:       services.NewVehicleService(repo.DB),
+		JobService:           services.NewJobService(repo.DB),
+		AvailabilityService:  services.NewAvailabilityService(repo.DB),
+		SlotHoldService:      services.NewSlotHoldService(repo.DB),
+		IdempotencyService:   services.NewIdempotencyService(repo.DB),
+		BookingPolicyService: services.NewBookingPolicyService(repo.DB),
+		WaitlistService:      services.NewWaitlistService(repo.DB),
+		AddOnService:         services.NewAddOnService(repo.DB),
+		PriceVariantService:  services.NewPriceVariantService(repo.DB),
+		PromoCodeService:     services.NewPromoCodeService(repo.DB),
+		TaxRateService:       services.NewTaxRateService(repo.DB),
+		InvoiceService:       services.NewInvoiceService(repo.DB),
+		PaymentService:       services.NewPaymentService(repo.DB),
+		SubscriptionService:  services.NewSubscriptionService(repo.DB),
+		InvitationService:    services.NewInvitationService(repo.DB),
 	}
 }
 
@@ -60,14 +119,15 @@ func currentBusinessID(c *gin.Context) (uuid.UUID, error) {
 
 func customerResponse(customer models.Customer) dto.CustomerResponse {
 	return dto.CustomerResponse{
-		ID:         customer.ID.String(),
-		BusinessID: customer.BusinessID.String(),
-		Name:       customer.Name,
-		Email:      customer.Email,
-		Phone:      customer.Phone,
-		Notes:      customer.Notes,
-		CreatedAt:  customer.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:  customer.UpdatedAt.Format(time.RFC3339),
+		ID:          customer.ID.String(),
+		BusinessID:  customer.BusinessID.String(),
+		Name:        customer.Name,
+		Email:       customer.Email,
+		Phone:       customer.Phone,
+		Notes:       customer.Notes,
+		NoShowCount: customer.NoShowCount,
+		CreatedAt:   customer.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   customer.UpdatedAt.Format(time.RFC3339),
 	}
 }
 
@@ -81,6 +141,7 @@ func vehicleResponse(vehicle models.Vehicle) dto.VehicleResponse {
 		Model:        vehicle.Model,
 		Color:        vehicle.Color,
 		LicensePlate: vehicle.LicensePlate,
+		Class:        string(vehicle.Class),
 		Customer:     customerResponse(vehicle.Customer),
 		CreatedAt:    vehicle.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:    vehicle.UpdatedAt.Format(time.RFC3339),
@@ -92,6 +153,15 @@ func jobResponse(job models.Job) dto.JobResponse {
 	if job.BookingID != nil {
 		bookingID = job.BookingID.String()
 	}
+	lineItems := make([]dto.JobLineItemResponse, len(job.LineItems))
+	for i, item := range job.LineItems {
+		lineItems[i] = dto.JobLineItemResponse{
+			Name:            item.Name,
+			Quantity:        item.Quantity,
+			UnitAmountMinor: item.UnitAmountMinor,
+			AmountMinor:     item.AmountMinor,
+		}
+	}
 	return dto.JobResponse{
 		ID:          job.ID.String(),
 		BusinessID:  job.BusinessID.String(),
@@ -104,11 +174,378 @@ func jobResponse(job models.Job) dto.JobResponse {
 		Notes:       job.Notes,
 		Customer:    customerResponse(job.Customer),
 		Vehicle:     vehicleResponse(job.Vehicle),
+		LineItems:   lineItems,
 		CreatedAt:   job.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:   job.UpdatedAt.Format(time.RFC3339),
 	}
 }
 
+func subscriptionResponse(sub models.Subscription, now time.Time) dto.SubscriptionResponse {
+	response := dto.SubscriptionResponse{
+		Plan:             string(sub.Plan),
+		Status:           string(sub.Status),
+		CurrentPeriodEnd: sub.CurrentPeriodEnd.Format(time.RFC3339),
+		Active:           services.SubscriptionActive(&sub, now),
+	}
+	response.TrialDaysRemaining = services.TrialDaysRemaining(&sub, now)
+	if sub.CanceledAt != nil {
+		response.CanceledAt = sub.CanceledAt.Format(time.RFC3339)
+	}
+	return response
+}
+
+func invitationResponse(invitation models.Invitation) dto.InvitationResponse {
+	return dto.InvitationResponse{
+		ID:        invitation.ID.String(),
+		Email:     invitation.Email,
+		Role:      string(invitation.Role),
+		ExpiresAt: invitation.ExpiresAt.Format(time.RFC3339),
+		CreatedAt: invitation.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func usageMetric(used int64, limit int) dto.UsageMetric {
+	metric := dto.UsageMetric{Used: used}
+	if limit > 0 {
+		metric.Limit = &limit
+	}
+	return metric
+}
+
+func paymentRecordResponse(payment models.PaymentRecord) dto.PaymentRecordResponse {
+	response := dto.PaymentRecordResponse{
+		ID:           payment.ID.String(),
+		BookingID:    payment.BookingID.String(),
+		Kind:         string(payment.Kind),
+		Method:       string(payment.Method),
+		AmountMinor:  payment.AmountMinor,
+		CurrencyCode: payment.CurrencyCode,
+		Note:         payment.Note,
+		RecordedAt:   payment.RecordedAt.Format(time.RFC3339),
+	}
+	if payment.InvoiceID != nil {
+		response.InvoiceID = payment.InvoiceID.String()
+	}
+	if payment.RecordedByID != nil {
+		response.RecordedByID = payment.RecordedByID.String()
+	}
+	return response
+}
+
+func bookingBalanceResponse(balance services.BookingBalance) dto.BookingBalanceResponse {
+	return dto.BookingBalanceResponse{
+		CurrencyCode:  balance.CurrencyCode,
+		TotalMinor:    balance.TotalMinor,
+		PaidMinor:     balance.PaidMinor,
+		RefundedMinor: balance.RefundedMinor,
+		BalanceMinor:  balance.BalanceMinor,
+	}
+}
+
+func invoiceResponse(invoice models.Invoice) dto.InvoiceResponse {
+	response := dto.InvoiceResponse{
+		ID:                 invoice.ID.String(),
+		BusinessID:         invoice.BusinessID.String(),
+		BookingID:          invoice.BookingID.String(),
+		Status:             string(invoice.Status),
+		Reference:          invoice.Reference,
+		CustomerName:       invoice.CustomerName,
+		CustomerEmail:      invoice.CustomerEmail,
+		CurrencyCode:       invoice.CurrencyCode,
+		SubtotalMinor:      invoice.SubtotalMinor,
+		DiscountMinor:      invoice.DiscountMinor,
+		TaxMinor:           invoice.TaxMinor,
+		TotalMinor:         invoice.TotalMinor,
+		DepositCreditMinor: invoice.DepositCreditMinor,
+		AmountDueMinor:     invoice.AmountDueMinor,
+		Lines:              make([]dto.InvoiceLineResponse, len(invoice.Lines)),
+		CreatedAt:          invoice.CreatedAt.Format(time.RFC3339),
+	}
+	if invoice.Number != nil {
+		response.Number = services.FormatInvoiceNumber(*invoice.Number)
+	}
+	if invoice.JobID != nil {
+		response.JobID = invoice.JobID.String()
+	}
+	if invoice.IssuedAt != nil {
+		response.IssuedAt = invoice.IssuedAt.Format(time.RFC3339)
+	}
+	if invoice.PaidAt != nil {
+		response.PaidAt = invoice.PaidAt.Format(time.RFC3339)
+	}
+	if invoice.VoidedAt != nil {
+		response.VoidedAt = invoice.VoidedAt.Format(time.RFC3339)
+	}
+	for i, line := range invoice.Lines {
+		response.Lines[i] = dto.InvoiceLineResponse{
+			Kind:            string(line.Kind),
+			Description:     line.Description,
+			Quantity:        line.Quantity,
+			UnitAmountMinor: line.UnitAmountMinor,
+			AmountMinor:     line.AmountMinor,
+			TaxInclusive:    line.TaxInclusive,
+		}
+	}
+	return response
+}
+
+func serviceResponse(service models.Service) dto.ServiceResponse {
+	response := dto.ServiceResponse{
+		ID:                 service.ID.String(),
+		BusinessID:         service.BusinessID.String(),
+		Name:               service.Name,
+		Description:        service.Description,
+		DurationMin:        service.DurationMin,
+		TotalPriceMinor:    service.TotalPriceMinor,
+		DepositAmountMinor: service.DepositAmountMinor,
+		CurrencyCode:       service.CurrencyCode,
+		CreatedAt:          service.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:          service.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if service.ArchivedAt != nil {
+		archivedAt := service.ArchivedAt.Format("2006-01-02T15:04:05Z07:00")
+		response.ArchivedAt = &archivedAt
+	}
+	return response
+}
+
+func bookingResponse(booking models.Booking) dto.BookingResponse {
+	response := dto.BookingResponse{
+		ID:           booking.ID.String(),
+		BusinessID:   booking.BusinessID.String(),
+		ServiceID:    booking.ServiceID.String(),
+		SlotID:       booking.SlotID.String(),
+		ServiceName:  booking.ServiceName,
+		SlotTime:     booking.SlotTime.Format("2006-01-02T15:04:05Z07:00"),
+		DurationMin:  booking.DurationMin,
+		VehicleClass: string(booking.VehicleClass),
+		Customer: dto.CustomerDetails{
+			Name:  booking.Customer.Name,
+			Email: booking.Customer.Email,
+			Phone: booking.Customer.Phone,
+		},
+		Status:           string(booking.Status),
+		DepositDueMinor:  booking.DepositDueMinor,
+		DepositPaidMinor: booking.DepositPaidMinor,
+		TotalPriceMinor:  booking.TotalPriceMinor,
+		DiscountMinor:    booking.DiscountMinor,
+		TaxMinor:         booking.TaxMinor,
+		PromoCode:        booking.PromoCode,
+		CurrencyCode:     booking.CurrencyCode,
+		RefundableMinor:  booking.RefundableMinor,
+		ForfeitedMinor:   booking.ForfeitedMinor,
+		LineItems:        lineItemResponses(booking.LineItems),
+		CreatedAt:        booking.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:        booking.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if booking.VehicleID != nil {
+		response.VehicleID = booking.VehicleID.String()
+	}
+	// Bookings made before line items were recorded have no breakdown.
+	if len(booking.LineItems) > 0 {
+		totals := services.TotalLineItems(booking.LineItems)
+		response.Breakdown = &dto.PriceBreakdownResponse{
+			SubtotalMinor: totals.SubtotalMinor,
+			DiscountMinor: totals.DiscountMinor,
+			TaxMinor:      totals.TaxMinor,
+			TotalMinor:    totals.TotalMinor,
+		}
+	}
+	return response
+}
+
+func lineItemResponses(items []models.BookingLineItem) []dto.LineItemResponse {
+	response := make([]dto.LineItemResponse, len(items))
+	for i, item := range items {
+		response[i] = dto.LineItemResponse{
+			Kind:            string(item.Kind),
+			Name:            item.Name,
+			Quantity:        item.Quantity,
+			UnitAmountMinor: item.UnitAmountMinor,
+			AmountMinor:     item.AmountMinor,
+			CurrencyCode:    item.CurrencyCode,
+			TaxInclusive:    item.TaxInclusive,
+			DurationMin:     item.DurationMin,
+		}
+		if item.ReferenceID != nil {
+			referenceID := item.ReferenceID.String()
+			response[i].ReferenceID = &referenceID
+		}
+	}
+	return response
+}
+
+func taxRateResponse(rate models.TaxRate) dto.TaxRateResponse {
+	return dto.TaxRateResponse{
+		ID:              rate.ID.String(),
+		Name:            rate.Name,
+		RateBasisPoints: rate.RateBasisPoints,
+		Inclusive:       rate.Inclusive,
+	}
+}
+
+func promoCodeResponse(promo models.PromoCode) dto.PromoCodeResponse {
+	response := dto.PromoCodeResponse{
+		ID:               promo.ID.String(),
+		Code:             promo.Code,
+		DiscountType:     string(promo.DiscountType),
+		PercentOff:       promo.PercentOff,
+		AmountOffMinor:   promo.AmountOffMinor,
+		MaxRedemptions:   promo.MaxRedemptions,
+		PerCustomerLimit: promo.PerCustomerLimit,
+		RedemptionCount:  promo.RedemptionCount,
+		ServiceIDs:       make([]string, len(promo.Restrictions)),
+		CreatedAt:        promo.CreatedAt.Format(time.RFC3339),
+	}
+	for i, restriction := range promo.Restrictions {
+		response.ServiceIDs[i] = restriction.ServiceID.String()
+	}
+	if promo.ValidFrom != nil {
+		response.ValidFrom = promo.ValidFrom.Format(time.RFC3339)
+	}
+	if promo.ValidUntil != nil {
+		response.ValidUntil = promo.ValidUntil.Format(time.RFC3339)
+	}
+	if promo.ArchivedAt != nil {
+		response.ArchivedAt = promo.ArchivedAt.Format(time.RFC3339)
+	}
+	return response
+}
+
+func priceVariantResponse(variant models.ServicePriceVariant) dto.PriceVariantResponse {
+	return dto.PriceVariantResponse{
+		ServiceID:          variant.ServiceID.String(),
+		VehicleClass:       string(variant.VehicleClass),
+		TotalPriceMinor:    variant.TotalPriceMinor,
+		DepositAmountMinor: variant.DepositAmountMinor,
+	}
+}
+
+func addOnResponse(addOn models.ServiceAddOn) dto.AddOnResponse {
+	return dto.AddOnResponse{
+		ID:               addOn.ID.String(),
+		ServiceID:        addOn.ServiceID.String(),
+		Name:             addOn.Name,
+		PriceMinor:       addOn.PriceMinor,
+		ExtraDurationMin: addOn.ExtraDurationMin,
+	}
+}
+
+// waitlistEntryResponse reports the preferred range as inclusive dates, the
+// way customers submitted it.
+func waitlistEntryResponse(entry models.WaitlistEntry) dto.WaitlistEntryResponse {
+	response := dto.WaitlistEntryResponse{
+		ID:            entry.ID.String(),
+		BusinessID:    entry.BusinessID.String(),
+		ServiceID:     entry.ServiceID.String(),
+		PreferredFrom: entry.PreferredFrom.Format("2006-01-02"),
+		PreferredTo:   entry.PreferredTo.AddDate(0, 0, -1).Format("2006-01-02"),
+		Customer: dto.CustomerDetails{
+			Name:  entry.Customer.Name,
+			Email: entry.Customer.Email,
+			Phone: entry.Customer.Phone,
+		},
+		Status:    string(entry.Status),
+		CreatedAt: entry.CreatedAt.Format(time.RFC3339),
+	}
+	if entry.OfferExpiresAt != nil {
+		expiresAt := entry.OfferExpiresAt.Format(time.RFC3339)
+		response.OfferExpiresAt = &expiresAt
+	}
+	return response
+}
+
+func bookingPolicyResponse(policy models.BookingPolicy) dto.BookingPolicyResponse {
+	return dto.BookingPolicyResponse{
+		BusinessID:               policy.BusinessID.String(),
+		SelfServiceCutoffHours:   policy.SelfServiceCutoffHours,
+		FreeCancelNoticeHours:    policy.FreeCancelNoticeHours,
+		LateCancelDepositKeepPct: policy.LateCancelDepositKeepPct,
+		NoShowDepositKeepPct:     policy.NoShowDepositKeepPct,
+	}
+}
+
+func bookingHistoryResponse(entry models.BookingHistory) dto.BookingHistoryResponse {
+	response := dto.BookingHistoryResponse{
+		ID:        entry.ID.String(),
+		BookingID: entry.BookingID.String(),
+		Action:    string(entry.Action),
+		CreatedAt: entry.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if entry.PreviousSlotID != nil {
+		response.PreviousSlotID = entry.PreviousSlotID.String()
+	}
+	if entry.PreviousSlotTime != nil {
+		response.PreviousSlotTime = entry.PreviousSlotTime.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return response
+}
+
+func formatClockMinutes(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+func parseClockMinutes(value string) (int, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+func availabilityRuleResponse(rule models.AvailabilityRule) dto.AvailabilityRuleResponse {
+	response := dto.AvailabilityRuleResponse{
+		ID:              rule.ID.String(),
+		BusinessID:      rule.BusinessID.String(),
+		Weekday:         rule.Weekday,
+		StartTime:       formatClockMinutes(rule.StartMinute),
+		EndTime:         formatClockMinutes(rule.EndMinute),
+		SlotDurationMin: rule.SlotDurationMin,
+		Capacity:        rule.Capacity,
+		Timezone:        rule.Timezone,
+		CreatedAt:       rule.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       rule.UpdatedAt.Format(time.RFC3339),
+	}
+	if rule.BreakStartMinute != nil && rule.BreakEndMinute != nil {
+		response.BreakStart = formatClockMinutes(*rule.BreakStartMinute)
+		response.BreakEnd = formatClockMinutes(*rule.BreakEndMinute)
+	}
+	return response
+}
+
+func availabilityRuleFromRequest(businessID uuid.UUID, req dto.AvailabilityRuleRequest) (*models.AvailabilityRule, error) {
+	startMinute, err := parseClockMinutes(req.StartTime)
+	if err != nil {
+		return nil, err
+	}
+	endMinute, err := parseClockMinutes(req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	rule := &models.AvailabilityRule{
+		BusinessID:      businessID,
+		Weekday:         *req.Weekday,
+		StartMinute:     startMinute,
+		EndMinute:       endMinute,
+		SlotDurationMin: req.SlotDurationMin,
+		Capacity:        req.Capacity,
+		Timezone:        req.Timezone,
+	}
+	if req.BreakStart != "" || req.BreakEnd != "" {
+		breakStart, err := parseClockMinutes(req.BreakStart)
+		if err != nil {
+			return nil, err
+		}
+		breakEnd, err := parseClockMinutes(req.BreakEnd)
+		if err != nil {
+			return nil, err
+		}
+		rule.BreakStartMinute = &breakStart
+		rule.BreakEndMinute = &breakEnd
+	}
+	return rule, nil
+}
+
 func (h *Handler) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status": "healthy",
@@ -150,6 +587,17 @@ func (h *Handler) GetCurrentUser(c *gin.Context) {
 		return
 	}
 
+	businessIDs := make([]uuid.UUID, len(memberships))
+	for i, membership := range memberships {
+		businessIDs[i] = membership.BusinessID
+	}
+	subscriptions, err := h.SubscriptionService.GetByBusinesses(businessIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch subscriptions"})
+		return
+	}
+
+	now := time.Now().UTC()
 	membershipResponse := make([]dto.MembershipResponse, len(memberships))
 	activeBusinessID := ""
 	for i, membership := range memberships {
@@ -166,6 +614,10 @@ func (h *Handler) GetCurrentUser(c *gin.Context) {
 				ThemeColor:  membership.Business.ThemeColor,
 			},
 		}
+		if sub, ok := subscriptions[membership.BusinessID]; ok {
+			membershipResponse[i].SubscriptionStatus = string(sub.Status)
+			membershipResponse[i].TrialDaysRemaining = services.TrialDaysRemaining(&sub, now)
+		}
 		if activeBusinessID == "" {
 			activeBusinessID = membership.BusinessID.String()
 		}
@@ -255,243 +707,1642 @@ func (h *Handler) GetServicesByBusiness(c *gin.Context) {
 
 	response := make([]dto.ServiceResponse, len(services))
 	for i, s := range services {
-		response[i] = dto.ServiceResponse{
-			ID:                 s.ID.String(),
-			BusinessID:         s.BusinessID.String(),
-			Name:               s.Name,
-			Description:        s.Description,
-			DurationMin:        s.DurationMin,
-			TotalPriceMinor:    s.TotalPriceMinor,
-			DepositAmountMinor: s.DepositAmountMinor,
-			CurrencyCode:       s.CurrencyCode,
-			CreatedAt:          s.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt:          s.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		}
+		response[i] = serviceResponse(s)
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
-// Slot Handlers
-func (h *Handler) GetSlotsByBusiness(c *gin.Context) {
-	businessID := c.Param("businessId")
-	businessUUID, err := uuid.Parse(businessID)
+func serviceFromRequest(req dto.CreateServiceRequest) *models.Service {
+	return &models.Service{
+		Name:               req.Name,
+		Description:        req.Description,
+		DurationMin:        req.DurationMin,
+		TotalPriceMinor:    req.TotalPriceMinor,
+		DepositAmountMinor: req.DepositAmountMinor,
+		CurrencyCode:       req.CurrencyCode,
+	}
+}
+
+const invalidServiceMessage = "Invalid service: name must be 3-100 characters, price positive, deposit no more than the total, and currency an ISO-4217 code"
+
+func (h *Handler) CreateService(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
 		return
 	}
 
-	slots, err := h.SlotService.GetAvailableByBusiness(businessUUID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch slots"})
+	var req dto.CreateServiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	response := make([]dto.SlotResponse, len(slots))
-	for i, s := range slots {
-		response[i] = dto.SlotResponse{
-			ID:         s.ID.String(),
-			BusinessID: s.BusinessID.String(),
-			StartTime:  s.StartTime.Format("2006-01-02T15:04:05Z07:00"),
-			EndTime:    s.EndTime.Format("2006-01-02T15:04:05Z07:00"),
-			IsBooked:   s.IsBooked,
-			CreatedAt:  s.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt:  s.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	service := serviceFromRequest(req)
+	service.BusinessID = businessID
+	if err := h.ServiceService.Create(service); err != nil {
+		if err == services.ErrBadRequest {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: invalidServiceMessage})
+			return
+		}
+		if err == services.ErrPlanLimitReached {
+			h.planLimitReached(c, businessID, "services")
+			return
 		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to create service"})
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusCreated, serviceResponse(*service))
 }
 
-// Booking Handlers
-func (h *Handler) CreateBooking(c *gin.Context) {
-	var req dto.CreateBookingRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
-		return
-	}
-
-	businessID, err := uuid.Parse(req.BusinessID)
+func (h *Handler) UpdateService(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
 		return
 	}
-	serviceID, err := uuid.Parse(req.ServiceID)
+	serviceID, err := uuid.Parse(c.Param("serviceId"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid service ID"})
 		return
 	}
-	slotID, err := uuid.Parse(req.SlotID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid slot ID"})
-		return
-	}
 
-	booking := &models.Booking{
-		BusinessID: businessID,
-		ServiceID:  serviceID,
-		SlotID:     slotID,
-		Customer: models.CustomerDetails{
-			Name:  req.Customer.Name,
-			Email: req.Customer.Email,
-			Phone: req.Customer.Phone,
-		},
+	var req dto.CreateServiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
 	}
 
-	if err := h.BookingService.Create(booking); err != nil {
-		if err == services.ErrBadRequest {
-			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid booking request"})
-			return
-		}
-		if err == services.ErrConflict {
-			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Selected slot is no longer available"})
-			return
+	service, err := h.ServiceService.Update(businessID, serviceID, serviceFromRequest(req))
+	if err != nil {
+		switch err {
+		case services.ErrNotFound:
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Service not found"})
+		case services.ErrBadRequest:
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: invalidServiceMessage})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to update service"})
 		}
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to create booking"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, dto.BookingResponse{
-		ID:          booking.ID.String(),
-		BusinessID:  booking.BusinessID.String(),
-		ServiceID:   booking.ServiceID.String(),
-		SlotID:      booking.SlotID.String(),
-		ServiceName: booking.ServiceName,
-		SlotTime:    booking.SlotTime.Format("2006-01-02T15:04:05Z07:00"),
-		Customer: dto.CustomerDetails{
-			Name:  booking.Customer.Name,
-			Email: booking.Customer.Email,
-			Phone: booking.Customer.Phone,
-		},
-		Status:           string(booking.Status),
-		DepositPaidMinor: booking.DepositPaidMinor,
-		TotalPriceMinor:  booking.TotalPriceMinor,
-		CurrencyCode:     booking.CurrencyCode,
-		CreatedAt:        booking.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:        booking.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	})
+	c.JSON(http.StatusOK, serviceResponse(*service))
 }
 
-func (h *Handler) ListBookings(c *gin.Context) {
-	businessUUID, err := currentBusinessID(c)
+func (h *Handler) ArchiveService(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
 		return
 	}
-
-	bookings, err := h.BookingService.GetByBusiness(businessUUID)
+	serviceID, err := uuid.Parse(c.Param("serviceId"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch bookings"})
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid service ID"})
 		return
 	}
 
-	response := make([]dto.BookingResponse, len(bookings))
-	for i, b := range bookings {
-		response[i] = dto.BookingResponse{
-			ID:          b.ID.String(),
-			BusinessID:  b.BusinessID.String(),
-			ServiceID:   b.ServiceID.String(),
-			SlotID:      b.SlotID.String(),
-			ServiceName: b.ServiceName,
-			SlotTime:    b.SlotTime.Format("2006-01-02T15:04:05Z07:00"),
-			Customer: dto.CustomerDetails{
-				Name:  b.Customer.Name,
-				Email: b.Customer.Email,
-				Phone: b.Customer.Phone,
-			},
-			Status:           string(b.Status),
-			DepositPaidMinor: b.DepositPaidMinor,
-			TotalPriceMinor:  b.TotalPriceMinor,
-			CurrencyCode:     b.CurrencyCode,
-			CreatedAt:        b.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			UpdatedAt:        b.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	service, err := h.ServiceService.Archive(businessID, serviceID)
+	if err != nil {
+		if err == services.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Service not found"})
+			return
 		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to archive service"})
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, serviceResponse(*service))
 }
 
-func (h *Handler) ListCustomers(c *gin.Context) {
-	businessID, err := currentBusinessID(c)
+// Add-on Handlers
+func (h *Handler) ListAddOns(c *gin.Context) {
+	businessID, err := uuid.Parse(c.Param("businessId"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
 		return
 	}
+	serviceID, err := uuid.Parse(c.Param("serviceId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid service ID"})
+		return
+	}
 
-	customers, err := h.CustomerService.GetByBusiness(businessID)
+	addOns, err := h.AddOnService.GetByService(businessID, serviceID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch customers"})
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch add-ons"})
 		return
 	}
 
-	response := make([]dto.CustomerResponse, len(customers))
-	for i, customer := range customers {
-		response[i] = customerResponse(customer)
+	response := make([]dto.AddOnResponse, len(addOns))
+	for i, addOn := range addOns {
+		response[i] = addOnResponse(addOn)
 	}
 	c.JSON(http.StatusOK, response)
 }
 
-func (h *Handler) CreateCustomer(c *gin.Context) {
+func (h *Handler) CreateAddOn(c *gin.Context) {
 	businessID, err := currentBusinessID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
 		return
 	}
+	serviceID, err := uuid.Parse(c.Param("serviceId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid service ID"})
+		return
+	}
 
-	var req dto.CreateCustomerRequest
+	var req dto.AddOnRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	customer := &models.Customer{BusinessID: businessID, Name: req.Name, Email: req.Email, Phone: req.Phone, Notes: req.Notes}
-	if err := h.CustomerService.Create(customer); err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to create customer"})
+	addOn := &models.ServiceAddOn{
+		BusinessID:       businessID,
+		ServiceID:        serviceID,
+		Name:             req.Name,
+		PriceMinor:       req.PriceMinor,
+		ExtraDurationMin: req.ExtraDurationMin,
+	}
+	if err := h.AddOnService.Create(addOn); err != nil {
+		switch err {
+		case services.ErrNotFound:
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Service not found"})
+		case services.ErrBadRequest:
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid add-on"})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to create add-on"})
+		}
 		return
 	}
-	c.JSON(http.StatusCreated, customerResponse(*customer))
+
+	c.JSON(http.StatusCreated, addOnResponse(*addOn))
 }
 
-func (h *Handler) ListVehicles(c *gin.Context) {
+func (h *Handler) UpdateAddOn(c *gin.Context) {
 	businessID, err := currentBusinessID(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
 		return
 	}
-
-	vehicles, err := h.VehicleService.GetByBusiness(businessID)
+	serviceID, err := uuid.Parse(c.Param("serviceId"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch vehicles"})
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid service ID"})
 		return
 	}
-
-	response := make([]dto.VehicleResponse, len(vehicles))
-	for i, vehicle := range vehicles {
-		response[i] = vehicleResponse(vehicle)
-	}
-	c.JSON(http.StatusOK, response)
-}
-
-func (h *Handler) CreateVehicle(c *gin.Context) {
-	businessID, err := currentBusinessID(c)
+	addOnID, err := uuid.Parse(c.Param("addOnId"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid add-on ID"})
 		return
 	}
 
-	var req dto.CreateVehicleRequest
+	var req dto.AddOnRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	customerID, err := uuid.Parse(req.CustomerID)
+	addOn, err := h.AddOnService.Update(businessID, serviceID, addOnID, &models.ServiceAddOn{
+		Name:             req.Name,
+		PriceMinor:       req.PriceMinor,
+		ExtraDurationMin: req.ExtraDurationMin,
+	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid customer ID"})
+		switch err {
+		case services.ErrNotFound:
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Add-on not found"})
+		case services.ErrBadRequest:
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid add-on"})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to update add-on"})
+		}
 		return
 	}
 
-	vehicle := &models.Vehicle{BusinessID: businessID, CustomerID: customerID, Year: req.Year, Make: req.Make, Model: req.Model, Color: req.Color, LicensePlate: req.LicensePlate}
+	c.JSON(http.StatusOK, addOnResponse(*addOn))
+}
+
+func (h *Handler) ArchiveAddOn(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	serviceID, err := uuid.Parse(c.Param("serviceId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid service ID"})
+		return
+	}
+	addOnID, err := uuid.Parse(c.Param("addOnId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid add-on ID"})
+		return
+	}
+
+	addOn, err := h.AddOnService.Archive(businessID, serviceID, addOnID)
+	if err != nil {
+		if err == services.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Add-on not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to archive add-on"})
+		return
+	}
+
+	c.JSON(http.StatusOK, addOnResponse(*addOn))
+}
+
+func (h *Handler) ListPriceVariants(c *gin.Context) {
+	businessID, err := uuid.Parse(c.Param("businessId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	serviceID, err := uuid.Parse(c.Param("serviceId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid service ID"})
+		return
+	}
+
+	variants, err := h.PriceVariantService.GetByService(businessID, serviceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch price variants"})
+		return
+	}
+
+	response := make([]dto.PriceVariantResponse, len(variants))
+	for i, variant := range variants {
+		response[i] = priceVariantResponse(variant)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) UpsertPriceVariant(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	serviceID, err := uuid.Parse(c.Param("serviceId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid service ID"})
+		return
+	}
+
+	var req dto.PriceVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	variant, err := h.PriceVariantService.Upsert(&models.ServicePriceVariant{
+		BusinessID:         businessID,
+		ServiceID:          serviceID,
+		VehicleClass:       models.VehicleClass(strings.ToUpper(c.Param("vehicleClass"))),
+		TotalPriceMinor:    req.TotalPriceMinor,
+		DepositAmountMinor: req.DepositAmountMinor,
+	})
+	if err != nil {
+		switch err {
+		case services.ErrNotFound:
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Service not found"})
+		case services.ErrBadRequest:
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid price variant"})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to save price variant"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, priceVariantResponse(*variant))
+}
+
+func (h *Handler) DeletePriceVariant(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	serviceID, err := uuid.Parse(c.Param("serviceId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid service ID"})
+		return
+	}
+
+	class := models.VehicleClass(strings.ToUpper(c.Param("vehicleClass")))
+	if err := h.PriceVariantService.Delete(businessID, serviceID, class); err != nil {
+		if err == services.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Price variant not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to delete price variant"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+func (h *Handler) ListTaxRates(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	rates, err := h.TaxRateService.GetByBusiness(businessID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch tax rates"})
+		return
+	}
+
+	response := make([]dto.TaxRateResponse, len(rates))
+	for i, rate := range rates {
+		response[i] = taxRateResponse(rate)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) CreateTaxRate(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	var req dto.TaxRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	rate := &models.TaxRate{BusinessID: businessID, Name: req.Name, RateBasisPoints: req.RateBasisPoints, Inclusive: req.Inclusive}
+	if err := h.TaxRateService.Create(rate); err != nil {
+		if err == services.ErrBadRequest {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid tax rate"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to create tax rate"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, taxRateResponse(*rate))
+}
+
+func (h *Handler) UpdateTaxRate(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	rateID, err := uuid.Parse(c.Param("taxRateId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid tax rate ID"})
+		return
+	}
+
+	var req dto.TaxRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	rate, err := h.TaxRateService.Update(businessID, rateID, &models.TaxRate{Name: req.Name, RateBasisPoints: req.RateBasisPoints, Inclusive: req.Inclusive})
+	if err != nil {
+		switch err {
+		case services.ErrNotFound:
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Tax rate not found"})
+		case services.ErrBadRequest:
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid tax rate"})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to update tax rate"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, taxRateResponse(*rate))
+}
+
+func (h *Handler) DeleteTaxRate(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	rateID, err := uuid.Parse(c.Param("taxRateId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid tax rate ID"})
+		return
+	}
+
+	if err := h.TaxRateService.Delete(businessID, rateID); err != nil {
+		if err == services.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Tax rate not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to delete tax rate"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+func (h *Handler) ListPromoCodes(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	promos, err := h.PromoCodeService.GetByBusiness(businessID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch promo codes"})
+		return
+	}
+
+	response := make([]dto.PromoCodeResponse, len(promos))
+	for i, promo := range promos {
+		response[i] = promoCodeResponse(promo)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) CreatePromoCode(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	var req dto.CreatePromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	promo := &models.PromoCode{
+		BusinessID:       businessID,
+		Code:             req.Code,
+		DiscountType:     models.PromoDiscountType(req.DiscountType),
+		PercentOff:       req.PercentOff,
+		AmountOffMinor:   req.AmountOffMinor,
+		MaxRedemptions:   req.MaxRedemptions,
+		PerCustomerLimit: req.PerCustomerLimit,
+	}
+	if req.ValidFrom != "" {
+		validFrom, err := time.Parse(time.RFC3339, req.ValidFrom)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "valid_from must be an RFC3339 time"})
+			return
+		}
+		promo.ValidFrom = &validFrom
+	}
+	if req.ValidUntil != "" {
+		validUntil, err := time.Parse(time.RFC3339, req.ValidUntil)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "valid_until must be an RFC3339 time"})
+			return
+		}
+		promo.ValidUntil = &validUntil
+	}
+	for _, id := range req.ServiceIDs {
+		serviceID, err := uuid.Parse(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid service ID"})
+			return
+		}
+		promo.Restrictions = append(promo.Restrictions, models.PromoCodeRestriction{ServiceID: serviceID})
+	}
+
+	if err := h.PromoCodeService.Create(promo); err != nil {
+		switch err {
+		case services.ErrConflict:
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Promo code already exists"})
+		case services.ErrBadRequest:
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid promo code"})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to create promo code"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, promoCodeResponse(*promo))
+}
+
+func (h *Handler) ArchivePromoCode(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	promoID, err := uuid.Parse(c.Param("promoCodeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid promo code ID"})
+		return
+	}
+
+	promo, err := h.PromoCodeService.Archive(businessID, promoID)
+	if err != nil {
+		if err == services.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Promo code not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to archive promo code"})
+		return
+	}
+
+	c.JSON(http.StatusOK, promoCodeResponse(*promo))
+}
+
+// Slot Handlers
+func (h *Handler) GetSlotsByBusiness(c *gin.Context) {
+	businessID := c.Param("businessId")
+	businessUUID, err := uuid.Parse(businessID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	var slots []models.Slot
+	if serviceID := c.Query("service_id"); serviceID != "" {
+		serviceUUID, err := uuid.Parse(serviceID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid service ID"})
+			return
+		}
+		service, err := h.ServiceService.GetByID(serviceUUID)
+		if err != nil {
+			if err == services.ErrNotFound {
+				c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Service not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch service"})
+			return
+		}
+		if service.BusinessID != businessUUID || service.ArchivedAt != nil {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Service not found"})
+			return
+		}
+		slots, err = h.SlotService.GetAvailableForDuration(businessUUID, service.DurationMin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch slots"})
+			return
+		}
+	} else {
+		slots, err = h.SlotService.GetAvailableByBusiness(businessUUID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch slots"})
+			return
+		}
+	}
+
+	response := make([]dto.SlotResponse, len(slots))
+	for i, s := range slots {
+		response[i] = dto.SlotResponse{
+			ID:                s.ID.String(),
+			BusinessID:        s.BusinessID.String(),
+			StartTime:         s.StartTime.Format("2006-01-02T15:04:05Z07:00"),
+			EndTime:           s.EndTime.Format("2006-01-02T15:04:05Z07:00"),
+			IsBooked:          s.BookedCount >= s.Capacity,
+			Capacity:          s.Capacity,
+			RemainingCapacity: max(s.Capacity-s.BookedCount, 0),
+			CreatedAt:         s.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt:         s.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) CreateSlotHold(c *gin.Context) {
+	var req dto.CreateSlotHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	businessID, err := uuid.Parse(req.BusinessID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	if !h.acceptsPublicBookings(c, businessID) {
+		return
+	}
+	serviceID, err := uuid.Parse(req.ServiceID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid service ID"})
+		return
+	}
+	slotID, err := uuid.Parse(req.SlotID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid slot ID"})
+		return
+	}
+
+	hold, token, err := h.SlotHoldService.Create(businessID, serviceID, slotID, slotHoldDuration)
+	if err != nil {
+		if err == services.ErrBadRequest {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid hold request"})
+			return
+		}
+		if err == services.ErrConflict {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Selected slot is no longer available"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to hold slot"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.SlotHoldResponse{
+		HoldToken:  token,
+		BusinessID: hold.BusinessID.String(),
+		ServiceID:  hold.ServiceID.String(),
+		SlotID:     hold.SlotID.String(),
+		ExpiresAt:  hold.ExpiresAt.Format(time.RFC3339),
+	})
+}
+
+func (h *Handler) ReleaseSlotHold(c *gin.Context) {
+	token := c.GetHeader(holdTokenHeader)
+	if token == "" {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Hold token is required"})
+		return
+	}
+	if err := h.SlotHoldService.Release(token); err != nil {
+		if err == services.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Hold not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to release hold"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// Availability Handlers
+func (h *Handler) ListAvailabilityRules(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	rules, err := h.AvailabilityService.GetByBusiness(businessID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch availability rules"})
+		return
+	}
+
+	response := make([]dto.AvailabilityRuleResponse, len(rules))
+	for i, rule := range rules {
+		response[i] = availabilityRuleResponse(rule)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) CreateAvailabilityRule(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	var req dto.AvailabilityRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	rule, err := availabilityRuleFromRequest(businessID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Times must use HH:MM format"})
+		return
+	}
+
+	if err := h.AvailabilityService.Create(rule); err != nil {
+		if err == services.ErrBadRequest {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid availability rule"})
+			return
+		}
+		if err == services.ErrConflict {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Availability rule overlaps another rule for that weekday"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to create availability rule"})
+		return
+	}
+	c.JSON(http.StatusCreated, availabilityRuleResponse(*rule))
+}
+
+func (h *Handler) UpdateAvailabilityRule(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	ruleID, err := uuid.Parse(c.Param("ruleId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid availability rule ID"})
+		return
+	}
+
+	var req dto.AvailabilityRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	rule, err := availabilityRuleFromRequest(businessID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Times must use HH:MM format"})
+		return
+	}
+
+	updated, err := h.AvailabilityService.Update(businessID, ruleID, rule)
+	if err != nil {
+		if err == services.ErrBadRequest {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid availability rule"})
+			return
+		}
+		if err == services.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Availability rule not found"})
+			return
+		}
+		if err == services.ErrConflict {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Availability rule overlaps another rule for that weekday"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to update availability rule"})
+		return
+	}
+	c.JSON(http.StatusOK, availabilityRuleResponse(*updated))
+}
+
+func (h *Handler) DeleteAvailabilityRule(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	ruleID, err := uuid.Parse(c.Param("ruleId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid availability rule ID"})
+		return
+	}
+
+	if err := h.AvailabilityService.Delete(businessID, ruleID); err != nil {
+		if err == services.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Availability rule not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to delete availability rule"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+func (h *Handler) RegenerateSlots(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	from := time.Now().UTC()
+	result, err := h.AvailabilityService.GenerateSlots(businessID, from, slotHorizonDays)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to regenerate slots"})
+		return
+	}
+	c.JSON(http.StatusOK, dto.SlotGenerationResponse{
+		Created: result.Created,
+		Removed: result.Removed,
+		From:    from.Format(time.RFC3339),
+		Until:   from.AddDate(0, 0, slotHorizonDays).Format(time.RFC3339),
+	})
+}
+
+// Booking Handlers
+func (h *Handler) CreateBooking(c *gin.Context) {
+	var req dto.CreateBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	businessID, err := uuid.Parse(req.BusinessID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	if !h.acceptsPublicBookings(c, businessID) {
+		return
+	}
+	serviceID, err := uuid.Parse(req.ServiceID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid service ID"})
+		return
+	}
+	slotID, err := uuid.Parse(req.SlotID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid slot ID"})
+		return
+	}
+
+	addOnIDs := make([]uuid.UUID, len(req.AddOnIDs))
+	for i, id := range req.AddOnIDs {
+		addOnIDs[i], err = uuid.Parse(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid add-on ID"})
+			return
+		}
+	}
+
+	booking := &models.Booking{
+		BusinessID: businessID,
+		ServiceID:  serviceID,
+		SlotID:     slotID,
+		Customer: models.CustomerDetails{
+			Name:  req.Customer.Name,
+			Email: req.Customer.Email,
+			Phone: req.Customer.Phone,
+		},
+	}
+
+	opts := services.BookingOptions{
+		HoldToken:    req.HoldToken,
+		AddOnIDs:     addOnIDs,
+		VehicleClass: models.VehicleClass(req.VehicleClass),
+		PromoCode:    req.PromoCode,
+	}
+	if req.VehicleID != "" {
+		vehicleID, err := uuid.Parse(req.VehicleID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid vehicle ID"})
+			return
+		}
+		opts.VehicleID = &vehicleID
+	}
+	if err := h.BookingService.CreateWithOptions(booking, opts); err != nil {
+		if err == services.ErrBadRequest {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid booking request"})
+			return
+		}
+		if err == services.ErrConflict {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Selected slot is no longer available"})
+			return
+		}
+		if err == services.ErrInvalidPromoCode {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Promo code cannot be applied to this booking"})
+			return
+		}
+		if err == services.ErrPlanLimitReached {
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "This workshop is not taking online bookings right now"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to create booking"})
+		return
+	}
+
+	response := managedBookingResponse(*booking)
+	if intent, err := h.BookingService.GetPendingDepositPayment(booking.ID); err == nil {
+		response.DepositPayment = &dto.DepositPaymentResponse{
+			Provider:     intent.Provider,
+			IntentID:     intent.ProviderIntentID,
+			ClientSecret: intent.ClientSecret,
+			AmountMinor:  intent.AmountMinor,
+			CurrencyCode: intent.CurrencyCode,
+			ExpiresAt:    intent.ExpiresAt.Format(time.RFC3339),
+		}
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// PaymentWebhook receives payment outcomes from the payment provider.
+func (h *Handler) PaymentWebhook(c *gin.Context) {
+	payload, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid webhook body"})
+		return
+	}
+	h.applyPaymentWebhook(c, payload, c.GetHeader(payments.SignatureHeader))
+}
+
+// CompleteFakePayment stands in for the customer paying with the fake
+// provider: it settles the intent and delivers the provider's webhook.
+func (h *Handler) CompleteFakePayment(c *gin.Context) {
+	var req dto.CompleteFakePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	payload, signature, err := h.FakePayments.Complete(c.Param("intentId"), req.Succeeded)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Payment not found"})
+		return
+	}
+	h.applyPaymentWebhook(c, payload, signature)
+}
+
+func (h *Handler) applyPaymentWebhook(c *gin.Context, payload []byte, signature string) {
+	if err := h.BookingService.HandlePaymentWebhook(payload, signature, time.Now().UTC()); err != nil {
+		switch err {
+		case payments.ErrInvalidSignature:
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid webhook signature"})
+		case services.ErrNotFound:
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Payment not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to process payment webhook"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// BillingWebhook receives subscription changes from the billing provider.
+func (h *Handler) BillingWebhook(c *gin.Context) {
+	payload, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid webhook body"})
+		return
+	}
+	h.applyBillingWebhook(c, payload, c.GetHeader(billing.SignatureHeader))
+}
+
+// EmitFakeBillingEvent stands in for a change made at the billing provider: it
+// signs the event and delivers the provider's webhook.
+func (h *Handler) EmitFakeBillingEvent(c *gin.Context) {
+	var req dto.EmitFakeBillingEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	event := billing.Event{
+		Type:       billing.EventType(req.Type),
+		BusinessID: req.BusinessID,
+		Plan:       req.Plan,
+		Status:     req.Status,
+	}
+	if req.CurrentPeriodEnd != "" {
+		periodEnd, err := time.Parse(time.RFC3339, req.CurrentPeriodEnd)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "current_period_end must be an RFC3339 timestamp"})
+			return
+		}
+		event.CurrentPeriodEnd = periodEnd.UTC()
+	}
+	payload, signature, err := h.FakeBilling.Emit(event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to emit billing event"})
+		return
+	}
+	h.applyBillingWebhook(c, payload, signature)
+}
+
+func (h *Handler) applyBillingWebhook(c *gin.Context, payload []byte, signature string) {
+	if err := h.SubscriptionService.HandleBillingWebhook(payload, signature); err != nil {
+		switch err {
+		case billing.ErrInvalidSignature:
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid webhook signature"})
+		case services.ErrBadRequest:
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid billing event"})
+		case services.ErrNotFound:
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Subscription not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to process billing webhook"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// acceptsPublicBookings reports whether customers can book with the workshop,
+// responding with an error when they cannot. Workshops whose subscription has
+// lapsed stop taking new bookings once any trial grace period is over.
+func (h *Handler) acceptsPublicBookings(c *gin.Context, businessID uuid.UUID) bool {
+	active, err := h.SubscriptionService.TakesPublicBookings(businessID, time.Now().UTC())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to check workshop availability"})
+		return false
+	}
+	if !active {
+		c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "This workshop is not taking online bookings right now"})
+		return false
+	}
+	return true
+}
+
+// managedBookingResponse adds a fresh manage token for the customer. A signing
+// failure is logged rather than returned because the booking already exists.
+func managedBookingResponse(booking models.Booking) dto.BookingResponse {
+	response := bookingResponse(booking)
+	token, err := auth.GenerateManageToken(booking.ID.String(), time.Now().UTC().Add(manageTokenLifetime))
+	if err != nil {
+		log.Printf("Failed to issue manage token for booking %s: %v", booking.ID, err)
+		return response
+	}
+	response.ManageToken = token
+	return response
+}
+
+// manageTokenLifetime caps how long a signed manage token is accepted at all.
+// Within it, the token stops working once the booking's current slot has
+// ended, so it follows the booking when the workshop reschedules it.
+const manageTokenLifetime = 365 * 24 * time.Hour
+
+func manageTokenExpiry(booking models.Booking) time.Time {
+	return booking.SlotTime.Add(time.Duration(booking.DurationMin) * time.Minute)
+}
+
+func (h *Handler) ListBookings(c *gin.Context) {
+	businessUUID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	bookings, err := h.BookingService.GetByBusiness(businessUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch bookings"})
+		return
+	}
+
+	response := make([]dto.BookingResponse, len(bookings))
+	for i, b := range bookings {
+		response[i] = bookingResponse(b)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) UpdateBookingStatus(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	bookingID, err := uuid.Parse(c.Param("bookingId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid booking ID"})
+		return
+	}
+
+	var req dto.UpdateBookingStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	booking, err := h.BookingService.UpdateStatus(businessID, bookingID, models.BookingStatus(req.Status))
+	if err != nil {
+		if err == services.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Booking not found"})
+			return
+		}
+		if err == services.ErrInvalidTransition {
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Booking cannot move to status " + req.Status})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to update booking status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bookingResponse(*booking))
+}
+
+func (h *Handler) RescheduleBooking(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	bookingID, err := uuid.Parse(c.Param("bookingId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid booking ID"})
+		return
+	}
+
+	var req dto.RescheduleBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	slotID, err := uuid.Parse(req.SlotID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid slot ID"})
+		return
+	}
+
+	booking, err := h.BookingService.Reschedule(businessID, bookingID, slotID)
+	if err != nil {
+		switch err {
+		case services.ErrNotFound:
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Booking not found"})
+		case services.ErrBadRequest:
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid reschedule request"})
+		case services.ErrInvalidTransition:
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Booking can no longer be rescheduled"})
+		case services.ErrConflict:
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Selected slot is no longer available"})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to reschedule booking"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, bookingResponse(*booking))
+}
+
+func (h *Handler) GetBookingHistory(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	bookingID, err := uuid.Parse(c.Param("bookingId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid booking ID"})
+		return
+	}
+
+	history, err := h.BookingService.GetHistory(businessID, bookingID)
+	if err != nil {
+		if err == services.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Booking not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch booking history"})
+		return
+	}
+
+	response := make([]dto.BookingHistoryResponse, len(history))
+	for i, entry := range history {
+		response[i] = bookingHistoryResponse(entry)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Self-service Handlers
+
+// managedBooking loads the booking named by the request's manage token. The
+// token is refused once the booking's current slot has ended.
+func (h *Handler) managedBooking(c *gin.Context) (*models.Booking, bool) {
+	bookingID, err := auth.ValidateManageToken(c.GetHeader(auth.ManageTokenHeader))
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Booking not found"})
+		return nil, false
+	}
+	id, err := uuid.Parse(bookingID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Booking not found"})
+		return nil, false
+	}
+
+	booking, err := h.BookingService.GetByID(id)
+	if err != nil {
+		if err == services.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Booking not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch booking"})
+		return nil, false
+	}
+	if !time.Now().UTC().Before(manageTokenExpiry(*booking)) {
+		c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Booking not found"})
+		return nil, false
+	}
+	return booking, true
+}
+
+func (h *Handler) GetManagedBooking(c *gin.Context) {
+	booking, ok := h.managedBooking(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, bookingResponse(*booking))
+}
+
+func (h *Handler) CancelManagedBooking(c *gin.Context) {
+	managed, ok := h.managedBooking(c)
+	if !ok {
+		return
+	}
+
+	booking, err := h.BookingService.SelfServiceCancel(managed.ID, time.Now().UTC())
+	if err != nil {
+		switch err {
+		case services.ErrNotFound:
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Booking not found"})
+		case services.ErrForbidden:
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "This booking can no longer be changed online"})
+		case services.ErrInvalidTransition:
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Booking can no longer be cancelled"})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to cancel booking"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, bookingResponse(*booking))
+}
+
+func (h *Handler) RescheduleManagedBooking(c *gin.Context) {
+	managed, ok := h.managedBooking(c)
+	if !ok {
+		return
+	}
+
+	var req dto.RescheduleBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	slotID, err := uuid.Parse(req.SlotID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid slot ID"})
+		return
+	}
+
+	booking, err := h.BookingService.SelfServiceReschedule(managed.ID, slotID, time.Now().UTC())
+	if err != nil {
+		switch err {
+		case services.ErrNotFound:
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Booking not found"})
+		case services.ErrForbidden:
+			c.JSON(http.StatusForbidden, dto.ErrorResponse{Error: "This booking can no longer be changed online"})
+		case services.ErrBadRequest:
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid reschedule request"})
+		case services.ErrInvalidTransition:
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Booking can no longer be rescheduled"})
+		case services.ErrConflict:
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Selected slot is no longer available"})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to reschedule booking"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, managedBookingResponse(*booking))
+}
+
+// Waitlist Handlers
+func (h *Handler) CreateWaitlistEntry(c *gin.Context) {
+	var req dto.CreateWaitlistEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	businessID, err := uuid.Parse(req.BusinessID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	if !h.acceptsPublicBookings(c, businessID) {
+		return
+	}
+	serviceID, err := uuid.Parse(req.ServiceID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid service ID"})
+		return
+	}
+	preferredFrom, err := time.Parse("2006-01-02", req.PreferredFrom)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "preferred_from must be a YYYY-MM-DD date"})
+		return
+	}
+	preferredTo, err := time.Parse("2006-01-02", req.PreferredTo)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "preferred_to must be a YYYY-MM-DD date"})
+		return
+	}
+
+	entry := &models.WaitlistEntry{
+		BusinessID:    businessID,
+		ServiceID:     serviceID,
+		PreferredFrom: preferredFrom,
+		PreferredTo:   preferredTo.AddDate(0, 0, 1),
+		Customer: models.CustomerDetails{
+			Name:  req.Customer.Name,
+			Email: req.Customer.Email,
+			Phone: req.Customer.Phone,
+		},
+	}
+	if err := h.WaitlistService.Create(entry, time.Now().UTC()); err != nil {
+		if err == services.ErrBadRequest {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid waitlist request"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to join waitlist"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, waitlistEntryResponse(*entry))
+}
+
+func (h *Handler) ListWaitlist(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	entries, err := h.WaitlistService.GetByBusiness(businessID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch waitlist"})
+		return
+	}
+
+	response := make([]dto.WaitlistEntryResponse, len(entries))
+	for i, entry := range entries {
+		response[i] = waitlistEntryResponse(entry)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Subscription Handlers
+func (h *Handler) GetSubscription(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	sub, err := h.SubscriptionService.GetByBusiness(businessID)
+	if err != nil {
+		if err == services.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Workshop has no subscription"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch subscription"})
+		return
+	}
+	c.JSON(http.StatusOK, subscriptionResponse(*sub, time.Now().UTC()))
+}
+
+func (h *Handler) GetUsage(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	usage, err := h.SubscriptionService.Usage(businessID, time.Now().UTC())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch usage"})
+		return
+	}
+	c.JSON(http.StatusOK, dto.UsageResponse{
+		Plan:            string(usage.Plan),
+		PeriodStart:     usage.PeriodStart.Format(time.RFC3339),
+		StaffSeats:      usageMetric(usage.StaffSeats, usage.Limits.StaffSeats),
+		MonthlyBookings: usageMetric(usage.MonthlyBookings, usage.Limits.MonthlyBookings),
+		Services:        usageMetric(usage.Services, usage.Limits.Services),
+	})
+}
+
+// planLimitReached responds that the workshop's plan has no room for another
+// unit of limit.
+func (h *Handler) planLimitReached(c *gin.Context, businessID uuid.UUID, limit string) {
+	usage, err := h.SubscriptionService.Usage(businessID, time.Now().UTC())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch usage"})
+		return
+	}
+	limits := map[string]int{
+		"staff_seats":      usage.Limits.StaffSeats,
+		"monthly_bookings": usage.Limits.MonthlyBookings,
+		"services":         usage.Limits.Services,
+	}
+	c.JSON(http.StatusPaymentRequired, dto.PlanLimitResponse{
+		Error: "Your plan does not allow more " + strings.ReplaceAll(limit, "_", " "),
+		Code:  "plan_limit_reached",
+		Limit: limit,
+		Max:   limits[limit],
+	})
+}
+
+// Invitation Handlers
+func (h *Handler) ListInvitations(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	invitations, err := h.InvitationService.ListPending(businessID, time.Now().UTC())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch invitations"})
+		return
+	}
+
+	response := make([]dto.InvitationResponse, len(invitations))
+	for i, invitation := range invitations {
+		response[i] = invitationResponse(invitation)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) CreateInvitation(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+
+	var req dto.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	invitation, err := h.InvitationService.Create(businessID, userID, req.Email, models.MembershipRole(req.Role), time.Now().UTC())
+	if err != nil {
+		switch err {
+		case services.ErrBadRequest:
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid invitation"})
+		case services.ErrConflict:
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "This person is already a member of the workshop"})
+		case services.ErrPlanLimitReached:
+			h.planLimitReached(c, businessID, "staff_seats")
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to create invitation"})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, invitationResponse(*invitation))
+}
+
+func (h *Handler) RevokeInvitation(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	invitationID, err := uuid.Parse(c.Param("invitationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid invitation ID"})
+		return
+	}
+
+	if err := h.InvitationService.Revoke(businessID, invitationID, time.Now().UTC()); err != nil {
+		if err == services.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Invitation not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to revoke invitation"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+// Booking Policy Handlers
+func (h *Handler) GetBookingPolicy(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	policy, err := h.BookingPolicyService.Get(businessID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch booking policy"})
+		return
+	}
+	c.JSON(http.StatusOK, bookingPolicyResponse(*policy))
+}
+
+func (h *Handler) UpdateBookingPolicy(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	var req dto.UpdateBookingPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	policy, err := h.BookingPolicyService.Get(businessID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch booking policy"})
+		return
+	}
+	if req.SelfServiceCutoffHours != nil {
+		policy.SelfServiceCutoffHours = *req.SelfServiceCutoffHours
+	}
+	if req.FreeCancelNoticeHours != nil {
+		policy.FreeCancelNoticeHours = *req.FreeCancelNoticeHours
+	}
+	if req.LateCancelDepositKeepPct != nil {
+		policy.LateCancelDepositKeepPct = *req.LateCancelDepositKeepPct
+	}
+	if req.NoShowDepositKeepPct != nil {
+		policy.NoShowDepositKeepPct = *req.NoShowDepositKeepPct
+	}
+
+	policy, err = h.BookingPolicyService.Update(businessID, policy)
+	if err != nil {
+		if err == services.ErrBadRequest {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid booking policy"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to update booking policy"})
+		return
+	}
+	c.JSON(http.StatusOK, bookingPolicyResponse(*policy))
+}
+
+func (h *Handler) ListCustomers(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	customers, err := h.CustomerService.GetByBusiness(businessID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch customers"})
+		return
+	}
+
+	response := make([]dto.CustomerResponse, len(customers))
+	for i, customer := range customers {
+		response[i] = customerResponse(customer)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) CreateCustomer(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	var req dto.CreateCustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	customer := &models.Customer{BusinessID: businessID, Name: req.Name, Email: req.Email, Phone: req.Phone, Notes: req.Notes}
+	if err := h.CustomerService.Create(customer); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to create customer"})
+		return
+	}
+	c.JSON(http.StatusCreated, customerResponse(*customer))
+}
+
+func (h *Handler) ListVehicles(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	vehicles, err := h.VehicleService.GetByBusiness(businessID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch vehicles"})
+		return
+	}
+
+	response := make([]dto.VehicleResponse, len(vehicles))
+	for i, vehicle := range vehicles {
+		response[i] = vehicleResponse(vehicle)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) CreateVehicle(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	var req dto.CreateVehicleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	customerID, err := uuid.Parse(req.CustomerID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid customer ID"})
+		return
+	}
+
+	vehicle := &models.Vehicle{BusinessID: businessID, CustomerID: customerID, Year: req.Year, Make: req.Make, Model: req.Model, Color: req.Color, LicensePlate: req.LicensePlate, Class: models.VehicleClass(req.Class)}
 	if err := h.VehicleService.Create(vehicle); err != nil {
 		if err == services.ErrBadRequest {
 			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Customer does not belong to this workshop"})
@@ -505,6 +2356,285 @@ func (h *Handler) CreateVehicle(c *gin.Context) {
 	c.JSON(http.StatusCreated, vehicleResponse(*vehicle))
 }
 
+func (h *Handler) ListInvoices(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	invoices, err := h.InvoiceService.GetByBusiness(businessID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch invoices"})
+		return
+	}
+
+	response := make([]dto.InvoiceResponse, len(invoices))
+	for i, invoice := range invoices {
+		response[i] = invoiceResponse(invoice)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) CreateInvoice(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+
+	var req dto.CreateInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if (req.BookingID == "") == (req.JobID == "") {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Provide either booking_id or job_id"})
+		return
+	}
+
+	var created *models.Invoice
+	if req.JobID != "" {
+		jobID, parseErr := uuid.Parse(req.JobID)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid job ID"})
+			return
+		}
+		created, err = h.InvoiceService.CreateFromJob(businessID, jobID)
+	} else {
+		bookingID, parseErr := uuid.Parse(req.BookingID)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid booking ID"})
+			return
+		}
+		created, err = h.InvoiceService.CreateFromBooking(businessID, bookingID)
+	}
+	if err != nil {
+		switch err {
+		case services.ErrNotFound:
+			if req.JobID != "" {
+				c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Job not found"})
+				return
+			}
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Booking not found"})
+		case services.ErrBadRequest:
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Job has no booking to invoice"})
+		case services.ErrInvalidTransition:
+			if req.JobID != "" {
+				c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Only delivered jobs for confirmed or completed bookings can be invoiced"})
+				return
+			}
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Only completed bookings can be invoiced"})
+		case services.ErrConflict:
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Booking already has an open invoice"})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to create invoice"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, invoiceResponse(*created))
+}
+
+func (h *Handler) IssueInvoice(c *gin.Context) {
+	h.transitionInvoice(c, "issued", h.InvoiceService.Issue)
+}
+
+func (h *Handler) MarkInvoicePaid(c *gin.Context) {
+	h.transitionInvoice(c, "marked paid", h.InvoiceService.MarkPaid)
+}
+
+func (h *Handler) VoidInvoice(c *gin.Context) {
+	h.transitionInvoice(c, "voided", h.InvoiceService.Void)
+}
+
+func (h *Handler) transitionInvoice(c *gin.Context, action string, transition func(businessID, id uuid.UUID, now time.Time) (*models.Invoice, error)) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	invoiceID, err := uuid.Parse(c.Param("invoiceId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid invoice ID"})
+		return
+	}
+
+	invoice, err := transition(businessID, invoiceID, time.Now().UTC())
+	if err != nil {
+		switch err {
+		case services.ErrNotFound:
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Invoice not found"})
+		case services.ErrInvalidTransition, services.ErrConflict:
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Invoice cannot be " + action + " in its current status"})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to update invoice"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, invoiceResponse(*invoice))
+}
+
+// RenderInvoice serves the invoice as a printable HTML page.
+func (h *Handler) RenderInvoice(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	invoiceID, err := uuid.Parse(c.Param("invoiceId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid invoice ID"})
+		return
+	}
+
+	inv, err := h.InvoiceService.GetByID(businessID, invoiceID)
+	if err != nil {
+		if err == services.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Invoice not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch invoice"})
+		return
+	}
+	business, err := h.BusinessService.GetByID(businessID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch business"})
+		return
+	}
+
+	doc := invoice.Document{Invoice: *inv, Business: *business, Number: "DRAFT"}
+	if inv.Number != nil {
+		doc.Number = services.FormatInvoiceNumber(*inv.Number)
+	}
+	var page bytes.Buffer
+	if err := invoice.Render(&page, doc); err != nil {
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to render invoice"})
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
+
+func (h *Handler) ListBookingPayments(c *gin.Context) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	bookingID, err := uuid.Parse(c.Param("bookingId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid booking ID"})
+		return
+	}
+
+	payments, balance, err := h.PaymentService.GetByBooking(businessID, bookingID)
+	if err != nil {
+		if err == services.ErrNotFound {
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Booking not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to fetch payments"})
+		return
+	}
+
+	response := dto.BookingPaymentsResponse{
+		Payments: make([]dto.PaymentRecordResponse, len(payments)),
+		Balance:  bookingBalanceResponse(balance),
+	}
+	for i, payment := range payments {
+		response.Payments[i] = paymentRecordResponse(payment)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) RecordPayment(c *gin.Context) {
+	h.recordPayment(c, models.PaymentKindPayment)
+}
+
+func (h *Handler) RecordRefund(c *gin.Context) {
+	h.recordPayment(c, models.PaymentKindRefund)
+}
+
+func (h *Handler) recordPayment(c *gin.Context, kind models.PaymentKind) {
+	businessID, err := currentBusinessID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid business ID"})
+		return
+	}
+	userID, err := getCurrentUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid user ID"})
+		return
+	}
+	bookingID, err := uuid.Parse(c.Param("bookingId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid booking ID"})
+		return
+	}
+
+	var req dto.RecordPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	payment := &models.PaymentRecord{
+		BusinessID:   businessID,
+		BookingID:    bookingID,
+		Kind:         kind,
+		Method:       models.PaymentMethod(req.Method),
+		AmountMinor:  req.AmountMinor,
+		Note:         strings.TrimSpace(req.Note),
+		RecordedByID: &userID,
+	}
+	if req.InvoiceID != "" {
+		invoiceID, parseErr := uuid.Parse(req.InvoiceID)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid invoice ID"})
+			return
+		}
+		payment.InvoiceID = &invoiceID
+	}
+	if req.RecordedAt != "" {
+		recordedAt, parseErr := time.Parse(time.RFC3339, req.RecordedAt)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid recorded_at format, use RFC3339"})
+			return
+		}
+		payment.RecordedAt = recordedAt.UTC()
+	}
+	now := time.Now().UTC()
+	if payment.RecordedAt.After(now) {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "recorded_at cannot be in the future"})
+		return
+	}
+
+	balance, err := h.PaymentService.Record(payment, now)
+	if err != nil {
+		switch err {
+		case services.ErrNotFound:
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{Error: "Booking not found"})
+		case services.ErrBadRequest:
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invoice is not an open invoice for this booking"})
+		case services.ErrConflict:
+			if kind == models.PaymentKindRefund {
+				c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Refund exceeds the amount paid"})
+			} else {
+				c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "Payment exceeds the outstanding balance"})
+			}
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to record payment"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.RecordPaymentResponse{
+		Payment: paymentRecordResponse(*payment),
+		Balance: bookingBalanceResponse(balance),
+	})
+}
+
 func (h *Handler) ListJobs(c *gin.Context) {
 	businessID, err := currentBusinessID(c)
 	if err != nil {
@@ -568,16 +2698,19 @@ func (h *Handler) CreateJob(c *gin.Context) {
 	}
 
 	job := &models.Job{BusinessID: businessID, CustomerID: customerID, VehicleID: vehicleID, BookingID: bookingID, Title: req.Title, Status: status, ScheduledAt: scheduledAt, Notes: req.Notes}
+	for _, item := range req.LineItems {
+		job.LineItems = append(job.LineItems, models.JobLineItem{Name: item.Name, Quantity: item.Quantity, UnitAmountMinor: item.UnitAmountMinor})
+	}
 	if err := h.JobService.Create(job); err != nil {
 		if err == services.ErrBadRequest {
-			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Customer, vehicle, or booking does not belong to this workshop"})
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invalid line item, or customer, vehicle, or booking does not belong to this workshop"})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to create job"})
 		return
 	}
 
-	h.JobService.DB.Preload("Customer", "business_id = ?", businessID).Preload("Vehicle", "business_id = ?", businessID).First(job, "id = ? AND business_id = ?", job.ID, businessID)
+	h.JobService.DB.Preload("Customer", "business_id = ?", businessID).Preload("Vehicle", "business_id = ?", businessID).Preload("LineItems").First(job, "id = ? AND business_id = ?", job.ID, businessID)
 	c.JSON(http.StatusCreated, jobResponse(*job))
 }
 
@@ -611,6 +2744,45 @@ func (h *Handler) Register(c *gin.Context) {
 	})
 }
 
+// AcceptInvitation joins the invited workshop, creating the account when
+// needed, and signs the member in.
+func (h *Handler) AcceptInvitation(c *gin.Context) {
+	var req dto.AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	user, token, err := h.InvitationService.Accept(req.Token, req.Name, req.Password, time.Now().UTC())
+	if err != nil {
+		switch err {
+		case services.ErrNotFound:
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Invitation is invalid or has expired"})
+		case services.ErrBadRequest:
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Error: "Name is required to create your account"})
+		case services.ErrUnauthorized:
+			c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Error: "Invalid credentials"})
+		case services.ErrConflict:
+			c.JSON(http.StatusConflict, dto.ErrorResponse{Error: "You are already a member of this workshop"})
+		case services.ErrPlanLimitReached:
+			c.JSON(http.StatusPaymentRequired, dto.ErrorResponse{Error: "The workshop has no free staff seats"})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Error: "Failed to accept invitation"})
+		}
+		return
+	}
+	setSessionCookie(c, token)
+
+	c.JSON(http.StatusOK, dto.AuthResponse{
+		User: dto.UserResponse{
+			ID:        user.ID.String(),
+			Email:     user.Email,
+			Name:      user.Name,
+			CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		},
+	})
+}
+
 func (h *Handler) Login(c *gin.Context) {
 	var req dto.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
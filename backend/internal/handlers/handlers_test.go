@@ -11,7 +11,9 @@ import (
 
 	"blytz.cloud/backend/internal/auth"
 	"blytz.cloud/backend/internal/middleware"
+	"blytz.cloud/backend/internal/notify"
 	"blytz.cloud/backend/internal/repository"
+	"blytz.cloud/backend/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -33,11 +35,21 @@ func setupHandlerTestDB(t *testing.T) *gorm.DB {
 	statements := []string{
 		`CREATE TABLE users (id text PRIMARY KEY, email text NOT NULL, name text, password_hash text NOT NULL, token_version integer NOT NULL DEFAULT 1, created_at datetime, updated_at datetime)`,
 		`CREATE TABLE businesses (id text PRIMARY KEY, name text NOT NULL, slug text NOT NULL, vertical text NOT NULL, description text, theme_color text, created_at datetime, updated_at datetime)`,
+		`CREATE TABLE subscriptions (id text PRIMARY KEY, business_id text NOT NULL UNIQUE, plan text NOT NULL, status text NOT NULL, current_period_end datetime NOT NULL, canceled_at datetime, last_event_at datetime, created_at datetime, updated_at datetime)`,
+		`CREATE TABLE invitations (id text PRIMARY KEY, business_id text NOT NULL, email text NOT NULL, role text NOT NULL, invited_by_id text NOT NULL, expires_at datetime NOT NULL, accepted_at datetime, revoked_at datetime, created_at datetime, updated_at datetime)`,
 		`CREATE TABLE memberships (id text PRIMARY KEY, user_id text NOT NULL, business_id text NOT NULL, role text NOT NULL, created_at datetime, updated_at datetime)`,
-		`CREATE TABLE bookings (id text PRIMARY KEY, business_id text NOT NULL, service_id text NOT NULL, slot_id text NOT NULL, service_name text NOT NULL, slot_time datetime NOT NULL, name text NOT NULL, email text NOT NULL, phone text NOT NULL, status text NOT NULL, deposit_paid_minor integer NOT NULL, total_price_minor integer NOT NULL, currency_code text NOT NULL, created_at datetime, updated_at datetime)`,
-		`CREATE TABLE customers (id text PRIMARY KEY, business_id text NOT NULL, name text NOT NULL, email text NOT NULL, phone text NOT NULL, notes text, created_at datetime, updated_at datetime)`,
-		`CREATE TABLE vehicles (id text PRIMARY KEY, business_id text NOT NULL, customer_id text NOT NULL, year integer, make text NOT NULL, model text NOT NULL, color text, license_plate text, created_at datetime, updated_at datetime)`,
+		`CREATE TABLE bookings (id text PRIMARY KEY, business_id text NOT NULL, service_id text NOT NULL, slot_id text NOT NULL, service_name text NOT NULL, slot_time datetime NOT NULL, duration_min integer NOT NULL DEFAULT 0, vehicle_id text, vehicle_class text, name text NOT NULL, email text NOT NULL, phone text NOT NULL, status text NOT NULL, deposit_due_minor integer NOT NULL DEFAULT 0, deposit_paid_minor integer NOT NULL, total_price_minor integer NOT NULL, discount_minor integer NOT NULL DEFAULT 0, tax_minor integer NOT NULL DEFAULT 0, promo_code text, currency_code text NOT NULL, refundable_minor integer NOT NULL DEFAULT 0, forfeited_minor integer NOT NULL DEFAULT 0, created_at datetime, updated_at datetime)`,
+		`CREATE TABLE customers (id text PRIMARY KEY, business_id text NOT NULL, name text NOT NULL, email text NOT NULL, phone text NOT NULL, notes text, no_show_count integer NOT NULL DEFAULT 0, created_at datetime, updated_at datetime)`,
+		`CREATE TABLE vehicles (id text PRIMARY KEY, business_id text NOT NULL, customer_id text NOT NULL, year integer, make text NOT NULL, model text NOT NULL, color text, license_plate text, class text, created_at datetime, updated_at datetime)`,
 		`CREATE TABLE jobs (id text PRIMARY KEY, business_id text NOT NULL, customer_id text NOT NULL, vehicle_id text NOT NULL, booking_id text, title text NOT NULL, status text NOT NULL, scheduled_at datetime NOT NULL, notes text, created_at datetime, updated_at datetime)`,
+		`CREATE TABLE job_line_items (id text PRIMARY KEY, job_id text NOT NULL, business_id text NOT NULL, name text NOT NULL, quantity integer NOT NULL DEFAULT 1, unit_amount_minor integer NOT NULL DEFAULT 0, amount_minor integer NOT NULL DEFAULT 0, created_at datetime)`,
+		`CREATE TABLE services (id text PRIMARY KEY, business_id text NOT NULL, name text NOT NULL, description text, duration_min integer NOT NULL, total_price_minor integer NOT NULL, deposit_amount_minor integer NOT NULL, currency_code text NOT NULL, archived_at datetime, created_at datetime, updated_at datetime)`,
+		`CREATE TABLE slots (id text PRIMARY KEY, business_id text NOT NULL, start_time datetime NOT NULL, end_time datetime NOT NULL, capacity integer NOT NULL DEFAULT 1, booked_count integer NOT NULL DEFAULT 0, created_at datetime, updated_at datetime)`,
+		`CREATE TABLE booking_line_items (id text PRIMARY KEY, booking_id text NOT NULL, business_id text NOT NULL, kind text NOT NULL, reference_id text, name text NOT NULL, quantity integer NOT NULL DEFAULT 1, unit_amount_minor integer NOT NULL DEFAULT 0, amount_minor integer NOT NULL DEFAULT 0, currency_code text NOT NULL DEFAULT 'USD', tax_inclusive boolean NOT NULL DEFAULT false, duration_min integer NOT NULL DEFAULT 0, created_at datetime)`,
+		`CREATE TABLE tax_rates (id text PRIMARY KEY, business_id text NOT NULL, name text NOT NULL, rate_basis_points integer NOT NULL, inclusive boolean NOT NULL DEFAULT false, created_at datetime, updated_at datetime)`,
+		`CREATE TABLE booking_slots (id text PRIMARY KEY, booking_id text NOT NULL, slot_id text NOT NULL, business_id text NOT NULL, created_at datetime)`,
+		`CREATE TABLE booking_policies (id text PRIMARY KEY, business_id text NOT NULL UNIQUE, self_service_cutoff_hours integer NOT NULL DEFAULT 24, free_cancel_notice_hours integer NOT NULL DEFAULT 24, late_cancel_deposit_keep_pct integer NOT NULL DEFAULT 100, no_show_deposit_keep_pct integer NOT NULL DEFAULT 100, created_at datetime, updated_at datetime)`,
+		`CREATE TABLE idempotency_keys (id text PRIMARY KEY, scope text NOT NULL, key text NOT NULL, request_hash text NOT NULL, status_code integer NOT NULL DEFAULT 0, response_body text, expires_at datetime NOT NULL, created_at datetime, updated_at datetime, UNIQUE (scope, key))`,
 	}
 
 	for _, statement := range statements {
@@ -65,6 +77,7 @@ func seedHandlerTestData(t *testing.T, db *gorm.DB) (string, string, string) {
 		fmt.Sprintf(`INSERT INTO users (id, email, name, password_hash, token_version, created_at, updated_at) VALUES ('%s', 'owner@example.com', 'Owner', '%s', 1, '%s', '%s')`, userID, hashedPassword, now, now),
 		fmt.Sprintf(`INSERT INTO businesses (id, name, slug, vertical, description, theme_color, created_at, updated_at) VALUES ('%s', 'DetailPro Automotive', 'detail-pro', 'Automotive', 'Premium detailing workshop', 'blue', '%s', '%s')`, businessID, now, now),
 		fmt.Sprintf(`INSERT INTO businesses (id, name, slug, vertical, description, theme_color, created_at, updated_at) VALUES ('%s', 'Other Workshop', 'other-workshop', 'Automotive', 'Second workshop', 'zinc', '%s', '%s')`, otherBusinessID, now, now),
+		fmt.Sprintf(`INSERT INTO subscriptions (id, business_id, plan, status, current_period_end, created_at, updated_at) VALUES ('%s', '%s', 'PRO', 'ACTIVE', '%s', '%s', '%s')`, uuid.New().String(), businessID, time.Now().UTC().AddDate(0, 1, 0).Format(time.RFC3339), now, now),
 		fmt.Sprintf(`INSERT INTO memberships (id, user_id, business_id, role, created_at, updated_at) VALUES ('%s', '%s', '%s', 'OWNER', '%s', '%s')`, uuid.New().String(), userID, businessID, now, now),
 		fmt.Sprintf(`INSERT INTO bookings (id, business_id, service_id, slot_id, service_name, slot_time, name, email, phone, status, deposit_paid_minor, total_price_minor, currency_code, created_at, updated_at) VALUES ('%s', '%s', '%s', '%s', 'Full Interior Detail', '%s', 'Alice Smith', 'alice@example.com', '555-0101', 'CONFIRMED', 5000, 20000, 'USD', '%s', '%s')`, uuid.New().String(), businessID, uuid.New().String(), uuid.New().String(), now, now, now),
 		fmt.Sprintf(`INSERT INTO customers (id, business_id, name, email, phone, notes, created_at, updated_at) VALUES ('%s', '%s', 'Alice Smith', 'alice@example.com', '555-0101', 'VIP detail client', '%s', '%s')`, uuid.New().String(), businessID, now, now),
@@ -92,13 +105,24 @@ func setupHandlerRouter(db *gorm.DB) *gin.Engine {
 	authRoutes.Use(middleware.RequireAllowedOrigin([]string{testOrigin}), middleware.RateLimitByIP(30, time.Minute), middleware.RateLimitByIPAndEmail(10, time.Minute))
 	authRoutes.POST("/login", handler.Login)
 	authRoutes.POST("/register", handler.Register)
+	authRoutes.POST("/invitations/accept", handler.AcceptInvitation)
 	v1.GET("/auth/me", auth.AuthMiddleware(handler.AuthService), handler.GetCurrentUser)
 	v1.POST("/auth/logout", middleware.RequireAllowedOrigin([]string{testOrigin}), auth.AuthMiddleware(handler.AuthService), handler.Logout)
-	operator := v1.Group("/businesses/:businessId")
-	operator.Use(auth.AuthMiddleware(handler.AuthService), middleware.RequireBusinessMembership(handler.AuthService))
-	operator.GET("/bookings", handler.ListBookings)
+	manage := v1.Group("/bookings/manage")
+	manage.GET("", handler.GetManagedBooking)
+	manage.POST("/cancel", handler.CancelManagedBooking)
+	workshop := v1.Group("/businesses/:businessId")
+	workshop.Use(auth.AuthMiddleware(handler.AuthService), middleware.RequireBusinessMembership(handler.AuthService))
+	bookings := workshop.Group("/bookings")
+	bookings.Use(middleware.RequireBookingSubscription(handler.SubscriptionService))
+	bookings.GET("", handler.ListBookings)
+	bookings.PATCH("/:bookingId/status", middleware.RequireAllowedOrigin([]string{testOrigin}), handler.UpdateBookingStatus)
+	operator := workshop.Group("")
+	operator.Use(middleware.RequireActiveSubscription(handler.SubscriptionService))
 	operator.GET("/customers", handler.ListCustomers)
+	operator.POST("/customers", middleware.RequireAllowedOrigin([]string{testOrigin}), middleware.Idempotency(handler.IdempotencyService, 24*time.Hour), handler.CreateCustomer)
 	operator.POST("/vehicles", middleware.RequireAllowedOrigin([]string{testOrigin}), handler.CreateVehicle)
+	operator.POST("/invitations", middleware.RequireBusinessOwner(handler.AuthService), middleware.RequireAllowedOrigin([]string{testOrigin}), handler.CreateInvitation)
 	return router
 }
 
@@ -179,6 +203,49 @@ func TestListBookingsRequiresMatchingMembership(t *testing.T) {
 	}
 }
 
+func TestUpdateBookingStatusEnforcesTransitions(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	userID, businessID, _ := seedHandlerTestData(t, db)
+	router := setupHandlerRouter(db)
+
+	var bookingID string
+	if err := db.Raw(`SELECT id FROM bookings WHERE business_id = ?`, businessID).Scan(&bookingID).Error; err != nil {
+		t.Fatalf("load booking id: %v", err)
+	}
+
+	patchStatus := func(status string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/api/v1/businesses/"+businessID+"/bookings/"+bookingID+"/status", strings.NewReader(`{"status":"`+status+`"}`))
+		req.Header.Set("Authorization", authHeaderForTest(t, userID))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", testOrigin)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	if recorder := patchStatus("PENDING"); recorder.Code != http.StatusConflict {
+		t.Fatalf("expected 409 moving CONFIRMED back to PENDING, got %d", recorder.Code)
+	}
+
+	recorder := patchStatus("COMPLETED")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200 completing confirmed booking, got %d", recorder.Code)
+	}
+	var payload struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if payload.Status != "COMPLETED" {
+		t.Fatalf("expected status COMPLETED, got %s", payload.Status)
+	}
+
+	if recorder := patchStatus("CANCELLED"); recorder.Code != http.StatusConflict {
+		t.Fatalf("expected 409 cancelling completed booking, got %d", recorder.Code)
+	}
+}
+
 func TestListCustomersRequiresMatchingMembership(t *testing.T) {
 	db := setupHandlerTestDB(t)
 	userID, businessID, otherBusinessID := seedHandlerTestData(t, db)
@@ -226,6 +293,155 @@ func TestCreateVehicleRejectsForeignCustomerReference(t *testing.T) {
 	}
 }
 
+func TestLapsedSubscriptionBlocksWritesButNotReads(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	userID, businessID, _ := seedHandlerTestData(t, db)
+	if err := db.Exec(`UPDATE subscriptions SET status = 'PAST_DUE' WHERE business_id = ?`, businessID).Error; err != nil {
+		t.Fatalf("lapse subscription: %v", err)
+	}
+	router := setupHandlerRouter(db)
+
+	body := `{"name":"Bob Jones","email":"bob@example.com","phone":"555-0202"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/businesses/"+businessID+"/customers", strings.NewReader(body))
+	req.Header.Set("Authorization", authHeaderForTest(t, userID))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", testOrigin)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusPaymentRequired {
+		t.Fatalf("expected 402 for a lapsed workshop, got %d", recorder.Code)
+	}
+	var response struct {
+		Code         string `json:"code"`
+		Subscription struct {
+			Status string `json:"status"`
+		} `json:"subscription"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if response.Code != "subscription_inactive" || response.Subscription.Status != "PAST_DUE" {
+		t.Fatalf("expected a subscription_inactive body for PAST_DUE, got %s", recorder.Body.String())
+	}
+
+	readRequest := httptest.NewRequest(http.MethodGet, "/api/v1/businesses/"+businessID+"/customers", nil)
+	readRequest.Header.Set("Authorization", authHeaderForTest(t, userID))
+	readRecorder := httptest.NewRecorder()
+	router.ServeHTTP(readRecorder, readRequest)
+
+	if readRecorder.Code != http.StatusOK {
+		t.Fatalf("expected 200 for reads on a lapsed workshop, got %d", readRecorder.Code)
+	}
+}
+
+func TestTrialGracePeriodKeepsBookingsManageable(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	userID, businessID, _ := seedHandlerTestData(t, db)
+	// The trial ended a day ago, inside the grace period in which public
+	// bookings are still accepted.
+	if err := db.Exec(`UPDATE subscriptions SET status = 'TRIALING', current_period_end = ? WHERE business_id = ?`, time.Now().UTC().Add(-24*time.Hour), businessID).Error; err != nil {
+		t.Fatalf("end trial: %v", err)
+	}
+	router := setupHandlerRouter(db)
+
+	var bookingID string
+	if err := db.Raw(`SELECT id FROM bookings WHERE business_id = ?`, businessID).Scan(&bookingID).Error; err != nil {
+		t.Fatalf("load booking id: %v", err)
+	}
+	write := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/businesses/"+businessID+path, strings.NewReader(body))
+		req.Header.Set("Authorization", authHeaderForTest(t, userID))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", testOrigin)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	if recorder := write(http.MethodPatch, "/bookings/"+bookingID+"/status", `{"status":"COMPLETED"}`); recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200 managing a booking during the grace period, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := write(http.MethodPost, "/customers", `{"name":"Bob Jones","email":"bob@example.com","phone":"555-0202"}`); recorder.Code != http.StatusPaymentRequired {
+		t.Fatalf("expected 402 for other changes during the grace period, got %d", recorder.Code)
+	}
+}
+
+type recordingNotifier struct {
+	messages []notify.Message
+}
+
+func (n *recordingNotifier) Send(msg notify.Message) error {
+	n.messages = append(n.messages, msg)
+	return nil
+}
+
+func TestStaffInvitationCreatesMemberOnlyOwnersCanInvite(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	userID, businessID, _ := seedHandlerTestData(t, db)
+	router := setupHandlerRouter(db)
+	notifier := &recordingNotifier{}
+	notify.SetNotifier(notifier)
+	t.Cleanup(func() { notify.SetNotifier(notify.LogNotifier{}) })
+
+	invite := func(asUserID, email string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/businesses/"+businessID+"/invitations", strings.NewReader(`{"email":"`+email+`","role":"STAFF"}`))
+		req.Header.Set("Authorization", authHeaderForTest(t, asUserID))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", testOrigin)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	if recorder := invite(userID, "Staff@Example.com"); recorder.Code != http.StatusCreated {
+		t.Fatalf("expected 201 for an owner invite, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if len(notifier.messages) != 1 || notifier.messages[0].To != "staff@example.com" {
+		t.Fatalf("expected one invitation sent to staff@example.com, got %+v", notifier.messages)
+	}
+	body := notifier.messages[0].Body
+	token := body[strings.Index(body, "invite_token=")+len("invite_token="):]
+
+	accept := httptest.NewRequest(http.MethodPost, "/api/v1/auth/invitations/accept", strings.NewReader(`{"token":"`+token+`","name":"Sam Staff","password":"password123"}`))
+	accept.Header.Set("Content-Type", "application/json")
+	accept.Header.Set("Origin", testOrigin)
+	acceptRecorder := httptest.NewRecorder()
+	router.ServeHTTP(acceptRecorder, accept)
+
+	if acceptRecorder.Code != http.StatusOK {
+		t.Fatalf("expected 200 for accepting the invitation, got %d: %s", acceptRecorder.Code, acceptRecorder.Body.String())
+	}
+	var accepted struct {
+		User struct {
+			ID string `json:"id"`
+		} `json:"user"`
+	}
+	if err := json.Unmarshal(acceptRecorder.Body.Bytes(), &accepted); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	var role string
+	if err := db.Raw(`SELECT role FROM memberships WHERE user_id = ? AND business_id = ?`, accepted.User.ID, businessID).Scan(&role).Error; err != nil {
+		t.Fatalf("load membership: %v", err)
+	}
+	if role != "STAFF" {
+		t.Fatalf("expected the new user to join as STAFF, got %q", role)
+	}
+
+	replay := httptest.NewRequest(http.MethodPost, "/api/v1/auth/invitations/accept", strings.NewReader(`{"token":"`+token+`","name":"Sam Staff","password":"password123"}`))
+	replay.Header.Set("Content-Type", "application/json")
+	replay.Header.Set("Origin", testOrigin)
+	replayRecorder := httptest.NewRecorder()
+	router.ServeHTTP(replayRecorder, replay)
+	if replayRecorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 when reusing an invitation, got %d", replayRecorder.Code)
+	}
+
+	if recorder := invite(accepted.User.ID, "other@example.com"); recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 when staff invite, got %d", recorder.Code)
+	}
+}
+
 func TestLoginIsRateLimitedByIP(t *testing.T) {
 	db := setupHandlerTestDB(t)
 	_, _, _ = seedHandlerTestData(t, db)
@@ -335,3 +551,175 @@ func TestLogoutRevokesCurrentSession(t *testing.T) {
 		t.Fatalf("expected 401 after logout revocation, got %d", authMeRecorder.Code)
 	}
 }
+
+func TestCreateCustomerReplaysIdempotentRequest(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	userID, businessID, _ := seedHandlerTestData(t, db)
+	router := setupHandlerRouter(db)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/businesses/"+businessID+"/customers", strings.NewReader(body))
+		req.Header.Set("Authorization", authHeaderForTest(t, userID))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", testOrigin)
+		req.Header.Set("Idempotency-Key", "retry-key-1")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	body := `{"name":"Bob Jones","email":"bob@example.com","phone":"555-0303"}`
+	first := post(body)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expected 201 for first request, got %d: %s", first.Code, first.Body.String())
+	}
+
+	second := post(body)
+	if second.Code != http.StatusCreated {
+		t.Fatalf("expected replayed 201, got %d", second.Code)
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Fatalf("expected replayed body %s, got %s", first.Body.String(), second.Body.String())
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatal("expected replay header on repeated request")
+	}
+
+	var count int64
+	if err := db.Table("customers").Where("email = ?", "bob@example.com").Count(&count).Error; err != nil {
+		t.Fatalf("count customers: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected one customer to be created, got %d", count)
+	}
+
+	mismatched := post(`{"name":"Bob Jones","email":"bob.jones@example.com","phone":"555-0303"}`)
+	if mismatched.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for reused key with different body, got %d", mismatched.Code)
+	}
+}
+
+func TestIdempotentRequestRejectsOversizedBody(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	userID, businessID, _ := seedHandlerTestData(t, db)
+	router := setupHandlerRouter(db)
+
+	body := `{"name":"Bob Jones","email":"bob@example.com","phone":"555-0303","notes":"` + strings.Repeat("x", 1<<20) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/businesses/"+businessID+"/customers", strings.NewReader(body))
+	req.Header.Set("Authorization", authHeaderForTest(t, userID))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", testOrigin)
+	req.Header.Set("Idempotency-Key", "large-body")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for an oversized idempotent request, got %d", recorder.Code)
+	}
+	var stored int64
+	if err := db.Table("idempotency_keys").Count(&stored).Error; err != nil {
+		t.Fatalf("count idempotency keys: %v", err)
+	}
+	if stored != 0 {
+		t.Fatalf("expected no key to be stored for a rejected request, got %d", stored)
+	}
+}
+
+func TestIdempotentResponseIsStoredWithoutSecrets(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	router := gin.New()
+	router.POST("/bookings", middleware.Idempotency(services.NewIdempotencyService(db), time.Hour), func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{
+			"id":           "booking-1",
+			"manage_token": "manage-secret",
+			"payment":      gin.H{"client_secret": "payment-secret"},
+		})
+	})
+
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/bookings", strings.NewReader(`{}`))
+		req.Header.Set("Idempotency-Key", uuid.NewSHA1(uuid.NameSpaceURL, []byte("secret-replay")).String())
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	if first := post(); !strings.Contains(first.Body.String(), "manage-secret") {
+		t.Fatalf("expected the first response to carry the manage token, got %s", first.Body.String())
+	}
+	var storedBody string
+	if err := db.Table("idempotency_keys").Select("response_body").Scan(&storedBody).Error; err != nil {
+		t.Fatalf("load stored response: %v", err)
+	}
+	if strings.Contains(storedBody, "manage-secret") || strings.Contains(storedBody, "payment-secret") {
+		t.Fatalf("expected secrets to be kept out of the stored response, got %s", storedBody)
+	}
+
+	replay := post()
+	if replay.Code != http.StatusCreated || !strings.Contains(replay.Body.String(), "booking-1") {
+		t.Fatalf("expected the replay to return the stored booking, got %d: %s", replay.Code, replay.Body.String())
+	}
+	if strings.Contains(replay.Body.String(), "secret") {
+		t.Fatalf("expected the replay to omit secrets, got %s", replay.Body.String())
+	}
+}
+
+func TestManageTokenViewsAndCancelsOnlyItsBooking(t *testing.T) {
+	db := setupHandlerTestDB(t)
+	_, businessID, _ := seedHandlerTestData(t, db)
+	router := setupHandlerRouter(db)
+
+	bookingID := uuid.New().String()
+	slotTime := time.Now().UTC().Add(72 * time.Hour)
+	now := time.Now().UTC().Format(time.RFC3339)
+	if err := db.Exec(fmt.Sprintf(`INSERT INTO bookings (id, business_id, service_id, slot_id, service_name, slot_time, duration_min, name, email, phone, status, deposit_paid_minor, total_price_minor, currency_code, created_at, updated_at) VALUES ('%s', '%s', '%s', '%s', 'Full Interior Detail', '%s', 120, 'Bob Jones', 'bob@example.com', '555-0303', 'CONFIRMED', 5000, 20000, 'USD', '%s', '%s')`, bookingID, businessID, uuid.New().String(), uuid.New().String(), slotTime.Format(time.RFC3339), now, now)).Error; err != nil {
+		t.Fatalf("seed booking: %v", err)
+	}
+
+	token, err := auth.GenerateManageToken(bookingID, time.Now().UTC().Add(manageTokenLifetime))
+	if err != nil {
+		t.Fatalf("generate manage token: %v", err)
+	}
+	manageRequest := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/bookings/manage"+path, nil)
+		req.Header.Set(auth.ManageTokenHeader, token)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	viewRecorder := manageRequest(http.MethodGet, "", token)
+	if viewRecorder.Code != http.StatusOK {
+		t.Fatalf("expected 200 viewing booking, got %d", viewRecorder.Code)
+	}
+	var viewed struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(viewRecorder.Body.Bytes(), &viewed); err != nil {
+		t.Fatalf("decode booking: %v", err)
+	}
+	if viewed.ID != bookingID {
+		t.Fatalf("expected booking %s, got %s", bookingID, viewed.ID)
+	}
+
+	sessionToken := strings.TrimPrefix(authHeaderForTest(t, uuid.New().String()), "Bearer ")
+	if sessionRecorder := manageRequest(http.MethodGet, "", sessionToken); sessionRecorder.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a session token, got %d", sessionRecorder.Code)
+	}
+
+	// The workshop moving the booking must not break the customer's link.
+	if err := db.Exec(`UPDATE bookings SET slot_time = ? WHERE id = ?`, slotTime.Add(14*24*time.Hour), bookingID).Error; err != nil {
+		t.Fatalf("move booking: %v", err)
+	}
+	if movedRecorder := manageRequest(http.MethodGet, "", token); movedRecorder.Code != http.StatusOK {
+		t.Fatalf("expected 200 viewing a moved booking, got %d", movedRecorder.Code)
+	}
+
+	cancelRecorder := manageRequest(http.MethodPost, "/cancel", token)
+	if cancelRecorder.Code != http.StatusOK {
+		t.Fatalf("expected 200 cancelling booking, got %d: %s", cancelRecorder.Code, cancelRecorder.Body.String())
+	}
+	if !strings.Contains(cancelRecorder.Body.String(), `"status":"CANCELLED"`) {
+		t.Fatalf("expected cancelled booking, got %s", cancelRecorder.Body.String())
+	}
+}
@@ -0,0 +1,113 @@
+// Package invoice renders invoices as printable HTML. Browsers print the page
+// to PDF, so no external rendering service is needed.
+package invoice
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"blytz.cloud/backend/internal/models"
+	"blytz.cloud/backend/internal/validator"
+)
+
+// Document is everything printed on an invoice.
+type Document struct {
+	Invoice  models.Invoice
+	Business models.Business
+	Number   string
+}
+
+var page = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"money": formatMinor,
+	"date":  formatDate,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Invoice {{.Number}} - {{.Business.Name}}</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #111; margin: 2.5rem; }
+  header { display: flex; justify-content: space-between; margin-bottom: 2rem; }
+  h1 { font-size: 1.5rem; margin: 0 0 .25rem; }
+  table { width: 100%; border-collapse: collapse; }
+  th, td { padding: .5rem; border-bottom: 1px solid #ddd; text-align: left; }
+  td.amount, th.amount { text-align: right; }
+  tfoot td { border-bottom: none; }
+  .status { text-transform: uppercase; letter-spacing: .05em; color: #555; }
+  .void { color: #b00; }
+  @media print { body { margin: 1cm; } }
+</style>
+</head>
+<body>
+<header>
+  <div>
+    <h1>{{.Business.Name}}</h1>
+    <div>{{.Business.Description}}</div>
+  </div>
+  <div>
+    <h1>Invoice {{.Number}}</h1>
+    <div class="status{{if eq .Invoice.Status "VOID"}} void{{end}}">{{.Invoice.Status}}</div>
+    {{with .Invoice.IssuedAt}}<div>Issued {{date .}}</div>{{end}}
+  </div>
+</header>
+<section>
+  <strong>Bill to</strong>
+  <div>{{.Invoice.CustomerName}}</div>
+  <div>{{.Invoice.CustomerEmail}}</div>
+  {{with .Invoice.Reference}}<div>Re: {{.}}</div>{{end}}
+</section>
+<br>
+<table>
+  <thead>
+    <tr><th>Description</th><th class="amount">Qty</th><th class="amount">Unit</th><th class="amount">Amount</th></tr>
+  </thead>
+  <tbody>
+    {{range .Invoice.Lines}}
+    <tr>
+      <td>{{.Description}}{{if .TaxInclusive}} (included){{end}}</td>
+      <td class="amount">{{.Quantity}}</td>
+      <td class="amount">{{money .UnitAmountMinor $.Invoice.CurrencyCode}}</td>
+      <td class="amount">{{money .AmountMinor $.Invoice.CurrencyCode}}</td>
+    </tr>
+    {{end}}
+  </tbody>
+  <tfoot>
+    <tr><td colspan="3" class="amount">Subtotal</td><td class="amount">{{money .Invoice.SubtotalMinor .Invoice.CurrencyCode}}</td></tr>
+    {{if .Invoice.DiscountMinor}}<tr><td colspan="3" class="amount">Discount</td><td class="amount">-{{money .Invoice.DiscountMinor .Invoice.CurrencyCode}}</td></tr>{{end}}
+    {{if .Invoice.TaxMinor}}<tr><td colspan="3" class="amount">Tax</td><td class="amount">{{money .Invoice.TaxMinor .Invoice.CurrencyCode}}</td></tr>{{end}}
+    <tr><td colspan="3" class="amount"><strong>Total</strong></td><td class="amount"><strong>{{money .Invoice.TotalMinor .Invoice.CurrencyCode}}</strong></td></tr>
+    {{if .Invoice.DepositCreditMinor}}<tr><td colspan="3" class="amount">Paid</td><td class="amount">-{{money .Invoice.DepositCreditMinor .Invoice.CurrencyCode}}</td></tr>{{end}}
+    <tr><td colspan="3" class="amount"><strong>Amount due</strong></td><td class="amount"><strong>{{money .Invoice.AmountDueMinor .Invoice.CurrencyCode}}</strong></td></tr>
+  </tfoot>
+</table>
+</body>
+</html>
+`))
+
+// Render writes doc as a standalone HTML page.
+func Render(w io.Writer, doc Document) error {
+	return page.Execute(w, doc)
+}
+
+func formatMinor(amount int64, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := validator.MinorUnitDigits(currency)
+	if digits == 0 {
+		return fmt.Sprintf("%s%s %d", sign, currency, amount)
+	}
+	unit := int64(1)
+	for i := 0; i < digits; i++ {
+		unit *= 10
+	}
+	return fmt.Sprintf("%s%s %d.%0*d", sign, currency, amount/unit, digits, amount%unit)
+}
+
+func formatDate(t *time.Time) string {
+	return t.Format("2 Jan 2006")
+}
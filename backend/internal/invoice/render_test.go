@@ -0,0 +1,21 @@
+package invoice
+
+import "testing"
+
+func TestFormatMinorUsesTheCurrencyMinorUnit(t *testing.T) {
+	cases := []struct {
+		amount   int64
+		currency string
+		want     string
+	}{
+		{12345, "USD", "USD 123.45"},
+		{-5, "EUR", "-EUR 0.05"},
+		{12345, "JPY", "JPY 12345"},
+		{12345, "KWD", "KWD 12.345"},
+	}
+	for _, tc := range cases {
+		if got := formatMinor(tc.amount, tc.currency); got != tc.want {
+			t.Errorf("formatMinor(%d, %q) = %q, want %q", tc.amount, tc.currency, got, tc.want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 
+	"blytz.cloud/backend/internal/models"
 	"blytz.cloud/backend/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -41,3 +42,36 @@ func RequireBusinessMembership(authService *services.AuthService) gin.HandlerFun
 		c.Next()
 	}
 }
+
+// RequireBusinessOwner limits a route to the workshop's owners. It must run
+// after RequireBusinessMembership.
+func RequireBusinessOwner(authService *services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := uuid.Parse(c.GetString("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			c.Abort()
+			return
+		}
+		businessID, err := uuid.Parse(c.GetString("business_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid business ID"})
+			c.Abort()
+			return
+		}
+
+		role, err := authService.MembershipRole(userID, businessID)
+		if err != nil && err != services.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify membership"})
+			c.Abort()
+			return
+		}
+		if role != models.MembershipRoleOwner {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only workshop owners can do this"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"blytz.cloud/backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	idempotencyHeader          = "Idempotency-Key"
+	maxIdempotencyKeyLength    = 255
+	maxIdempotentRequestLength = 1 << 20
+)
+
+// secretResponseFields are never written to the idempotency store: a manage
+// token or payment client secret must not sit in plaintext in the database for
+// the lifetime of the key.
+var secretResponseFields = map[string]bool{
+	"manage_token":  true,
+	"client_secret": true,
+	"hold_token":    true,
+}
+
+type capturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *capturingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *capturingWriter) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// Idempotency replays the stored response when a request repeats an
+// Idempotency-Key header with the same body, and rejects the key with 422
+// when it is reused for a different body. Requests without the header pass
+// through untouched. Keys are scoped to the signed-in user; anonymous clients
+// share one scope per route, so their keys must be UUIDs to avoid colliding.
+// Secret fields are dropped from the stored response, so a replay omits them.
+func Idempotency(idempotencyService *services.IdempotencyService, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(idempotencyHeader))
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			c.Abort()
+			return
+		}
+
+		userID := c.GetString("user_id")
+		if userID == "" {
+			if _, err := uuid.Parse(key); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be a UUID"})
+				c.Abort()
+				return
+			}
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentRequestLength+1))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		if len(body) > maxIdempotentRequestLength {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body is too large"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+
+		sum := sha256.Sum256(body)
+		scope := c.Request.Method + " " + c.Request.URL.Path + " " + userID
+		record, replay, err := idempotencyService.Begin(scope, key, hex.EncodeToString(sum[:]), ttl)
+		if err != nil {
+			switch err {
+			case services.ErrKeyReused:
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request"})
+			case services.ErrConflict:
+				c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process Idempotency-Key"})
+			}
+			c.Abort()
+			return
+		}
+		if replay {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(record.StatusCode, "application/json; charset=utf-8", []byte(record.ResponseBody))
+			c.Abort()
+			return
+		}
+
+		writer := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		finished := false
+		defer func() {
+			// A handler that panicked or failed must not leave the key in
+			// flight until it expires; release it so the client can retry.
+			if finished && writer.Status() < http.StatusInternalServerError {
+				return
+			}
+			if err := idempotencyService.Abandon(record.ID); err != nil {
+				log.Printf("Failed to release idempotency key: %v", err)
+			}
+		}()
+		c.Next()
+		finished = true
+
+		if writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+		if err := idempotencyService.Complete(record.ID, writer.Status(), redactSecretFields(writer.body.String())); err != nil {
+			log.Printf("Failed to store idempotent response: %v", err)
+		}
+	}
+}
+
+// redactSecretFields removes secretResponseFields from a JSON response body at
+// any depth. Bodies without secrets are returned unchanged so replays stay
+// byte-for-byte identical.
+func redactSecretFields(body string) string {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(body), &decoded); err != nil {
+		return body
+	}
+	if !dropSecretFields(decoded) {
+		return body
+	}
+	redacted, err := json.Marshal(decoded)
+	if err != nil {
+		return ""
+	}
+	return string(redacted)
+}
+
+func dropSecretFields(value interface{}) bool {
+	dropped := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if secretResponseFields[key] {
+				delete(v, key)
+				dropped = true
+				continue
+			}
+			if dropSecretFields(field) {
+				dropped = true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if dropSecretFields(item) {
+				dropped = true
+			}
+		}
+	}
+	return dropped
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"blytz.cloud/backend/internal/dto"
+	"blytz.cloud/backend/internal/models"
+	"blytz.cloud/backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequireActiveSubscription blocks changes to a workshop whose subscription
+// has lapsed. Reads stay available so owners can still see their data and
+// billing state. It must run after RequireBusinessMembership.
+func RequireActiveSubscription(subscriptionService *services.SubscriptionService) gin.HandlerFunc {
+	return requireSubscription(subscriptionService, services.SubscriptionActive)
+}
+
+// RequireBookingSubscription guards changes to existing bookings. They stay
+// allowed for as long as the workshop takes public bookings, including a
+// trial's grace period, so the owner can manage what customers book then.
+// It must run after RequireBusinessMembership.
+func RequireBookingSubscription(subscriptionService *services.SubscriptionService) gin.HandlerFunc {
+	return requireSubscription(subscriptionService, services.SubscriptionTakesPublicBookings)
+}
+
+func requireSubscription(subscriptionService *services.SubscriptionService, allowed func(*models.Subscription, time.Time) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		businessID, err := uuid.Parse(c.GetString("business_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid business ID"})
+			c.Abort()
+			return
+		}
+
+		sub, err := subscriptionService.GetByBusiness(businessID)
+		if err != nil && err != services.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify subscription"})
+			c.Abort()
+			return
+		}
+		if allowed(sub, time.Now().UTC()) {
+			c.Next()
+			return
+		}
+
+		body := dto.SubscriptionInactiveResponse{
+			Error: "Workshop subscription is not active",
+			Code:  "subscription_inactive",
+		}
+		if sub != nil {
+			body.Subscription = &dto.SubscriptionStateResponse{
+				Plan:             string(sub.Plan),
+				Status:           string(sub.Status),
+				CurrentPeriodEnd: sub.CurrentPeriodEnd.Format(time.RFC3339),
+			}
+		}
+		c.JSON(http.StatusPaymentRequired, body)
+		c.Abort()
+	}
+}
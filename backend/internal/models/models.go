@@ -8,14 +8,53 @@ import (
 )
 
 type BookingStatus string
+type BookingHistoryAction string
+type SlotHoldStatus string
+type WaitlistStatus string
+type LineItemKind string
+type VehicleClass string
+type PromoDiscountType string
 type MembershipRole string
 type JobStatus string
+type InvoiceStatus string
+type PaymentKind string
+type PaymentMethod string
+type PaymentIntentStatus string
+type SubscriptionPlan string
+type SubscriptionStatus string
 
 const (
 	BookingStatusPending   BookingStatus = "PENDING"
 	BookingStatusConfirmed BookingStatus = "CONFIRMED"
 	BookingStatusCompleted BookingStatus = "COMPLETED"
 	BookingStatusCancelled BookingStatus = "CANCELLED"
+	BookingStatusNoShow    BookingStatus = "NO_SHOW"
+
+	BookingHistoryActionRescheduled BookingHistoryAction = "RESCHEDULED"
+
+	SlotHoldStatusActive   SlotHoldStatus = "ACTIVE"
+	SlotHoldStatusConsumed SlotHoldStatus = "CONSUMED"
+	SlotHoldStatusReleased SlotHoldStatus = "RELEASED"
+
+	VehicleClassSedan     VehicleClass = "SEDAN"
+	VehicleClassHatchback VehicleClass = "HATCHBACK"
+	VehicleClassSUV       VehicleClass = "SUV"
+	VehicleClassTruck     VehicleClass = "TRUCK"
+	VehicleClassVan       VehicleClass = "VAN"
+
+	PromoDiscountPercent PromoDiscountType = "PERCENT"
+	PromoDiscountFixed   PromoDiscountType = "FIXED"
+
+	LineItemKindService  LineItemKind = "SERVICE"
+	LineItemKindAddOn    LineItemKind = "ADD_ON"
+	LineItemKindDiscount LineItemKind = "DISCOUNT"
+	LineItemKindTax      LineItemKind = "TAX"
+	LineItemKindExtra    LineItemKind = "EXTRA"
+
+	WaitlistStatusWaiting WaitlistStatus = "WAITING"
+	WaitlistStatusOffered WaitlistStatus = "OFFERED"
+	WaitlistStatusBooked  WaitlistStatus = "BOOKED"
+	WaitlistStatusExpired WaitlistStatus = "EXPIRED"
 
 	MembershipRoleOwner MembershipRole = "OWNER"
 	MembershipRoleStaff MembershipRole = "STAFF"
@@ -24,6 +63,35 @@ const (
 	JobStatusInProgress JobStatus = "IN_PROGRESS"
 	JobStatusReady      JobStatus = "READY"
 	JobStatusDelivered  JobStatus = "DELIVERED"
+
+	InvoiceStatusDraft  InvoiceStatus = "DRAFT"
+	InvoiceStatusIssued InvoiceStatus = "ISSUED"
+	InvoiceStatusPaid   InvoiceStatus = "PAID"
+	InvoiceStatusVoid   InvoiceStatus = "VOID"
+
+	PaymentKindPayment PaymentKind = "PAYMENT"
+	PaymentKindRefund  PaymentKind = "REFUND"
+
+	PaymentMethodCash     PaymentMethod = "CASH"
+	PaymentMethodCard     PaymentMethod = "CARD"
+	PaymentMethodTransfer PaymentMethod = "TRANSFER"
+
+	PaymentIntentStatusPending       PaymentIntentStatus = "PENDING"
+	PaymentIntentStatusSucceeded     PaymentIntentStatus = "SUCCEEDED"
+	PaymentIntentStatusFailed        PaymentIntentStatus = "FAILED"
+	PaymentIntentStatusExpired       PaymentIntentStatus = "EXPIRED"
+	PaymentIntentStatusRefundPending PaymentIntentStatus = "REFUND_PENDING"
+	PaymentIntentStatusRefunded      PaymentIntentStatus = "REFUNDED"
+
+	SubscriptionPlanStarter SubscriptionPlan = "STARTER"
+	SubscriptionPlanPro     SubscriptionPlan = "PRO"
+
+	SubscriptionStatusTrialing SubscriptionStatus = "TRIALING"
+	SubscriptionStatusActive   SubscriptionStatus = "ACTIVE"
+	SubscriptionStatusPastDue  SubscriptionStatus = "PAST_DUE"
+	SubscriptionStatusCanceled SubscriptionStatus = "CANCELED"
+	// SubscriptionStatusRestricted marks a trial that ended without a paid plan.
+	SubscriptionStatusRestricted SubscriptionStatus = "RESTRICTED"
 )
 
 type Business struct {
@@ -38,28 +106,210 @@ type Business struct {
 }
 
 type Service struct {
-	ID                 uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	BusinessID         uuid.UUID `json:"business_id" gorm:"type:uuid;not null"`
-	Name               string    `json:"name" gorm:"not null"`
-	Description        string    `json:"description"`
-	DurationMin        int       `json:"duration_min" gorm:"not null"`
-	TotalPriceMinor    int64     `json:"total_price_minor" gorm:"not null;default:0"`
-	DepositAmountMinor int64     `json:"deposit_amount_minor" gorm:"not null;default:0"`
-	CurrencyCode       string    `json:"currency_code" gorm:"size:3;not null;default:'USD'"`
-	CreatedAt          time.Time `json:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at"`
-	Business           Business  `json:"business" gorm:"foreignKey:BusinessID"`
+	ID                 uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BusinessID         uuid.UUID  `json:"business_id" gorm:"type:uuid;not null"`
+	Name               string     `json:"name" gorm:"not null"`
+	Description        string     `json:"description"`
+	DurationMin        int        `json:"duration_min" gorm:"not null"`
+	TotalPriceMinor    int64      `json:"total_price_minor" gorm:"not null;default:0"`
+	DepositAmountMinor int64      `json:"deposit_amount_minor" gorm:"not null;default:0"`
+	CurrencyCode       string     `json:"currency_code" gorm:"size:3;not null;default:'USD'"`
+	ArchivedAt         *time.Time `json:"archived_at" gorm:"index"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	Business           Business   `json:"business" gorm:"foreignKey:BusinessID"`
+}
+
+// ServiceAddOn is an optional extra a customer can choose on top of a service.
+// It adds to the booking's price and duration.
+type ServiceAddOn struct {
+	ID               uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BusinessID       uuid.UUID  `json:"business_id" gorm:"type:uuid;not null;index"`
+	ServiceID        uuid.UUID  `json:"service_id" gorm:"type:uuid;not null;index"`
+	Name             string     `json:"name" gorm:"not null"`
+	PriceMinor       int64      `json:"price_minor" gorm:"not null;default:0"`
+	ExtraDurationMin int        `json:"extra_duration_min" gorm:"not null;default:0"`
+	ArchivedAt       *time.Time `json:"archived_at" gorm:"index"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// ServicePriceVariant overrides a service's price and deposit for one
+// vehicle class.
+type ServicePriceVariant struct {
+	ID                 uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BusinessID         uuid.UUID    `json:"business_id" gorm:"type:uuid;not null;index"`
+	ServiceID          uuid.UUID    `json:"service_id" gorm:"type:uuid;not null;uniqueIndex:idx_price_variant_service_class"`
+	VehicleClass       VehicleClass `json:"vehicle_class" gorm:"size:20;not null;uniqueIndex:idx_price_variant_service_class"`
+	TotalPriceMinor    int64        `json:"total_price_minor" gorm:"not null;default:0"`
+	DepositAmountMinor int64        `json:"deposit_amount_minor" gorm:"not null;default:0"`
+	CreatedAt          time.Time    `json:"created_at"`
+	UpdatedAt          time.Time    `json:"updated_at"`
+}
+
+// PromoCode discounts bookings of a business. A zero MaxRedemptions or
+// PerCustomerLimit means unlimited, and a code with no Restrictions applies to
+// every service.
+type PromoCode struct {
+	ID               uuid.UUID              `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BusinessID       uuid.UUID              `json:"business_id" gorm:"type:uuid;not null;uniqueIndex:idx_promo_business_code"`
+	Code             string                 `json:"code" gorm:"size:32;not null;uniqueIndex:idx_promo_business_code"`
+	DiscountType     PromoDiscountType      `json:"discount_type" gorm:"size:20;not null"`
+	PercentOff       int                    `json:"percent_off" gorm:"not null;default:0"`
+	AmountOffMinor   int64                  `json:"amount_off_minor" gorm:"not null;default:0"`
+	ValidFrom        *time.Time             `json:"valid_from"`
+	ValidUntil       *time.Time             `json:"valid_until"`
+	MaxRedemptions   int                    `json:"max_redemptions" gorm:"not null;default:0"`
+	PerCustomerLimit int                    `json:"per_customer_limit" gorm:"not null;default:0"`
+	RedemptionCount  int                    `json:"redemption_count" gorm:"not null;default:0"`
+	ArchivedAt       *time.Time             `json:"archived_at"`
+	CreatedAt        time.Time              `json:"created_at"`
+	UpdatedAt        time.Time              `json:"updated_at"`
+	Restrictions     []PromoCodeRestriction `json:"restrictions" gorm:"foreignKey:PromoCodeID"`
+}
+
+// PromoCodeRestriction limits a promo code to one service.
+type PromoCodeRestriction struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	PromoCodeID uuid.UUID `json:"promo_code_id" gorm:"type:uuid;not null;index"`
+	ServiceID   uuid.UUID `json:"service_id" gorm:"type:uuid;not null"`
+}
+
+// PromoRedemption records one use of a promo code by a booking.
+type PromoRedemption struct {
+	ID            uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	PromoCodeID   uuid.UUID `json:"promo_code_id" gorm:"type:uuid;not null;index"`
+	BusinessID    uuid.UUID `json:"business_id" gorm:"type:uuid;not null;index"`
+	BookingID     uuid.UUID `json:"booking_id" gorm:"type:uuid;not null;uniqueIndex"`
+	CustomerEmail string    `json:"customer_email" gorm:"not null;index"`
+	DiscountMinor int64     `json:"discount_minor" gorm:"not null;default:0"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
+// TaxRate is a tax a business charges on bookings, in basis points (1/100 of
+// a percent). Inclusive rates are already contained in service prices;
+// exclusive rates are added on top.
+type TaxRate struct {
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BusinessID      uuid.UUID `json:"business_id" gorm:"type:uuid;not null;index"`
+	Name            string    `json:"name" gorm:"not null"`
+	RateBasisPoints int       `json:"rate_basis_points" gorm:"not null"`
+	Inclusive       bool      `json:"inclusive" gorm:"not null;default:false"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Slot is a bookable time window. Capacity is the number of parallel
+// bookings (for example service bays) the window can take.
 type Slot struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BusinessID  uuid.UUID `json:"business_id" gorm:"type:uuid;not null;index"`
+	StartTime   time.Time `json:"start_time" gorm:"not null;index"`
+	EndTime     time.Time `json:"end_time" gorm:"not null"`
+	Capacity    int       `json:"capacity" gorm:"not null;default:1"`
+	BookedCount int       `json:"booked_count" gorm:"not null;default:0"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Business    Business  `json:"business" gorm:"foreignKey:BusinessID"`
+}
+
+// SlotHold reserves slot capacity for a customer while they finish checkout.
+// Only the hash of the hold token is stored.
+type SlotHold struct {
+	ID         uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BusinessID uuid.UUID      `json:"business_id" gorm:"type:uuid;not null;index"`
+	ServiceID  uuid.UUID      `json:"service_id" gorm:"type:uuid;not null"`
+	SlotID     uuid.UUID      `json:"slot_id" gorm:"type:uuid;not null;index"`
+	TokenHash  string         `json:"-" gorm:"uniqueIndex;not null"`
+	Status     SlotHoldStatus `json:"status" gorm:"not null;default:'ACTIVE';index"`
+	ExpiresAt  time.Time      `json:"expires_at" gorm:"not null;index"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// SlotHoldSlot links a hold to every slot whose capacity it has taken.
+type SlotHoldSlot struct {
+	ID     uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	HoldID uuid.UUID `json:"hold_id" gorm:"type:uuid;not null;index"`
+	SlotID uuid.UUID `json:"slot_id" gorm:"type:uuid;not null"`
+}
+
+// AvailabilityRule describes the recurring opening hours for one weekday.
+// Times are stored as minutes after local midnight in the rule's timezone.
+type AvailabilityRule struct {
+	ID               uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BusinessID       uuid.UUID `json:"business_id" gorm:"type:uuid;not null;index"`
+	Weekday          int       `json:"weekday" gorm:"not null"`
+	StartMinute      int       `json:"start_minute" gorm:"not null"`
+	EndMinute        int       `json:"end_minute" gorm:"not null"`
+	SlotDurationMin  int       `json:"slot_duration_min" gorm:"not null"`
+	Capacity         int       `json:"capacity" gorm:"not null;default:1"`
+	BreakStartMinute *int      `json:"break_start_minute"`
+	BreakEndMinute   *int      `json:"break_end_minute"`
+	Timezone         string    `json:"timezone" gorm:"not null;default:'UTC'"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+	Business         Business  `json:"business" gorm:"foreignKey:BusinessID"`
+}
+
+// WaitlistEntry is a customer waiting for a slot starting at or after
+// PreferredFrom and before PreferredTo (exclusive). While OFFERED, OfferHoldID points at the slot hold reserved
+// for them, whose token is the claim token sent to the customer.
+type WaitlistEntry struct {
+	ID             uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BusinessID     uuid.UUID       `json:"business_id" gorm:"type:uuid;not null;index"`
+	ServiceID      uuid.UUID       `json:"service_id" gorm:"type:uuid;not null"`
+	PreferredFrom  time.Time       `json:"preferred_from" gorm:"not null"`
+	PreferredTo    time.Time       `json:"preferred_to" gorm:"not null"`
+	Customer       CustomerDetails `json:"customer" gorm:"embedded"`
+	Status         WaitlistStatus  `json:"status" gorm:"not null;default:'WAITING';index"`
+	OfferHoldID    *uuid.UUID      `json:"offer_hold_id" gorm:"type:uuid;index"`
+	OfferExpiresAt *time.Time      `json:"offer_expires_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// Subscription is a workshop's SaaS plan. A workshop can make changes and take
+// public bookings while it is trialing or active and CurrentPeriodEnd has not
+// passed. A trial that runs out is RESTRICTED until the workshop picks a plan.
+// After signup it changes only through billing provider events; LastEventAt is
+// when the newest applied event occurred, so late deliveries of older events
+// are ignored.
+type Subscription struct {
+	ID               uuid.UUID          `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BusinessID       uuid.UUID          `json:"business_id" gorm:"type:uuid;not null;uniqueIndex"`
+	Plan             SubscriptionPlan   `json:"plan" gorm:"not null"`
+	Status           SubscriptionStatus `json:"status" gorm:"not null;index"`
+	CurrentPeriodEnd time.Time          `json:"current_period_end" gorm:"not null"`
+	CanceledAt       *time.Time         `json:"canceled_at"`
+	LastEventAt      *time.Time         `json:"last_event_at"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+}
+
+// BillingEvent records a billing provider webhook that has been processed, so
+// a redelivered event is recognised and skipped.
+type BillingEvent struct {
 	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Provider   string    `json:"provider" gorm:"not null;uniqueIndex:idx_billing_event_provider_id"`
+	EventID    string    `json:"event_id" gorm:"not null;uniqueIndex:idx_billing_event_provider_id"`
+	Type       string    `json:"type" gorm:"not null"`
 	BusinessID uuid.UUID `json:"business_id" gorm:"type:uuid;not null;index"`
-	StartTime  time.Time `json:"start_time" gorm:"not null;index"`
-	EndTime    time.Time `json:"end_time" gorm:"not null"`
-	IsBooked   bool      `json:"is_booked" gorm:"default:false"`
+	OccurredAt time.Time `json:"occurred_at" gorm:"not null"`
 	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
-	Business   Business  `json:"business" gorm:"foreignKey:BusinessID"`
+}
+
+// BookingPolicy holds the per-business rules for customer self-service and
+// for how much of the deposit is kept when a booking does not go ahead.
+type BookingPolicy struct {
+	ID                       uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BusinessID               uuid.UUID `json:"business_id" gorm:"type:uuid;not null;uniqueIndex"`
+	SelfServiceCutoffHours   int       `json:"self_service_cutoff_hours" gorm:"not null;default:24"`
+	FreeCancelNoticeHours    int       `json:"free_cancel_notice_hours" gorm:"not null;default:24"`
+	LateCancelDepositKeepPct int       `json:"late_cancel_deposit_keep_pct" gorm:"not null;default:100"`
+	NoShowDepositKeepPct     int       `json:"no_show_deposit_keep_pct" gorm:"not null;default:100"`
+	CreatedAt                time.Time `json:"created_at"`
+	UpdatedAt                time.Time `json:"updated_at"`
 }
 
 type CustomerDetails struct {
@@ -69,22 +319,90 @@ type CustomerDetails struct {
 }
 
 type Booking struct {
-	ID               uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	BusinessID       uuid.UUID       `json:"business_id" gorm:"type:uuid;not null;index"`
-	ServiceID        uuid.UUID       `json:"service_id" gorm:"type:uuid;not null"`
-	SlotID           uuid.UUID       `json:"slot_id" gorm:"type:uuid;not null;index"`
-	ServiceName      string          `json:"service_name" gorm:"not null"`
-	SlotTime         time.Time       `json:"slot_time" gorm:"not null"`
-	Customer         CustomerDetails `json:"customer" gorm:"embedded"`
-	Status           BookingStatus   `json:"status" gorm:"not null;default:'PENDING'"`
-	DepositPaidMinor int64           `json:"deposit_paid_minor" gorm:"not null;default:0"`
-	TotalPriceMinor  int64           `json:"total_price_minor" gorm:"not null;default:0"`
-	CurrencyCode     string          `json:"currency_code" gorm:"size:3;not null;default:'USD'"`
-	CreatedAt        time.Time       `json:"created_at"`
-	UpdatedAt        time.Time       `json:"updated_at"`
-	Business         Business        `json:"business" gorm:"foreignKey:BusinessID"`
-	Service          Service         `json:"service" gorm:"foreignKey:ServiceID"`
-	Slot             Slot            `json:"slot" gorm:"foreignKey:SlotID"`
+	ID               uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BusinessID       uuid.UUID         `json:"business_id" gorm:"type:uuid;not null;index"`
+	ServiceID        uuid.UUID         `json:"service_id" gorm:"type:uuid;not null"`
+	SlotID           uuid.UUID         `json:"slot_id" gorm:"type:uuid;not null;index"`
+	ServiceName      string            `json:"service_name" gorm:"not null"`
+	SlotTime         time.Time         `json:"slot_time" gorm:"not null"`
+	DurationMin      int               `json:"duration_min" gorm:"not null;default:0"`
+	VehicleID        *uuid.UUID        `json:"vehicle_id" gorm:"type:uuid"`
+	VehicleClass     VehicleClass      `json:"vehicle_class" gorm:"size:20"`
+	Customer         CustomerDetails   `json:"customer" gorm:"embedded"`
+	Status           BookingStatus     `json:"status" gorm:"not null;default:'PENDING'"`
+	DepositDueMinor  int64             `json:"deposit_due_minor" gorm:"not null;default:0"`
+	DepositPaidMinor int64             `json:"deposit_paid_minor" gorm:"not null;default:0"`
+	TotalPriceMinor  int64             `json:"total_price_minor" gorm:"not null;default:0"`
+	DiscountMinor    int64             `json:"discount_minor" gorm:"not null;default:0"`
+	TaxMinor         int64             `json:"tax_minor" gorm:"not null;default:0"`
+	PromoCode        string            `json:"promo_code" gorm:"size:32"`
+	CurrencyCode     string            `json:"currency_code" gorm:"size:3;not null;default:'USD'"`
+	RefundableMinor  int64             `json:"refundable_minor" gorm:"not null;default:0"`
+	ForfeitedMinor   int64             `json:"forfeited_minor" gorm:"not null;default:0"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+	Business         Business          `json:"business" gorm:"foreignKey:BusinessID"`
+	Service          Service           `json:"service" gorm:"foreignKey:ServiceID"`
+	Slot             Slot              `json:"slot" gorm:"foreignKey:SlotID"`
+	LineItems        []BookingLineItem `json:"line_items" gorm:"foreignKey:BookingID"`
+}
+
+// BookingLineItem snapshots one priced part of a booking, such as the base
+// service, a chosen add-on, a discount or tax, so later catalog edits do not
+// change it. Name is the line's description and AmountMinor its total
+// (Quantity × UnitAmountMinor); discount lines are negative. Tax lines marked
+// TaxInclusive report tax already contained in the price and do not add to the
+// total. Booking totals are derived from these lines.
+type BookingLineItem struct {
+	ID              uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BookingID       uuid.UUID    `json:"booking_id" gorm:"type:uuid;not null;index"`
+	BusinessID      uuid.UUID    `json:"business_id" gorm:"type:uuid;not null;index"`
+	Kind            LineItemKind `json:"kind" gorm:"not null"`
+	ReferenceID     *uuid.UUID   `json:"reference_id" gorm:"type:uuid"`
+	Name            string       `json:"name" gorm:"not null"`
+	Quantity        int          `json:"quantity" gorm:"not null;default:1"`
+	UnitAmountMinor int64        `json:"unit_amount_minor" gorm:"not null;default:0"`
+	AmountMinor     int64        `json:"amount_minor" gorm:"not null;default:0"`
+	CurrencyCode    string       `json:"currency_code" gorm:"size:3;not null;default:'USD'"`
+	TaxInclusive    bool         `json:"tax_inclusive" gorm:"not null;default:false"`
+	DurationMin     int          `json:"duration_min" gorm:"not null;default:0"`
+	CreatedAt       time.Time    `json:"created_at"`
+}
+
+// BookingSlot links a booking to every slot it occupies. Services longer than
+// a single slot reserve a contiguous run of slots starting at Booking.SlotID.
+type BookingSlot struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BookingID  uuid.UUID `json:"booking_id" gorm:"type:uuid;not null;index"`
+	SlotID     uuid.UUID `json:"slot_id" gorm:"type:uuid;not null;index"`
+	BusinessID uuid.UUID `json:"business_id" gorm:"type:uuid;not null;index"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// BookingHistory records changes made to a booking after it was created.
+type BookingHistory struct {
+	ID               uuid.UUID            `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BookingID        uuid.UUID            `json:"booking_id" gorm:"type:uuid;not null;index"`
+	BusinessID       uuid.UUID            `json:"business_id" gorm:"type:uuid;not null;index"`
+	Action           BookingHistoryAction `json:"action" gorm:"not null"`
+	PreviousSlotID   *uuid.UUID           `json:"previous_slot_id" gorm:"type:uuid"`
+	PreviousSlotTime *time.Time           `json:"previous_slot_time"`
+	CreatedAt        time.Time            `json:"created_at"`
+}
+
+// IdempotencyKey stores the outcome of a create request so a retry carrying
+// the same Idempotency-Key header replays the original response. A zero
+// StatusCode means the first request is still being processed.
+type IdempotencyKey struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Scope        string    `json:"scope" gorm:"not null;uniqueIndex:idx_idempotency_scope_key"`
+	Key          string    `json:"key" gorm:"not null;uniqueIndex:idx_idempotency_scope_key"`
+	RequestHash  string    `json:"request_hash" gorm:"not null"`
+	StatusCode   int       `json:"status_code" gorm:"not null;default:0"`
+	ResponseBody string    `json:"response_body" gorm:"type:text"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
 
 // User model for operators
@@ -109,49 +427,169 @@ type Membership struct {
 	Business   Business       `json:"business" gorm:"foreignKey:BusinessID"`
 }
 
+// Invitation asks someone, by email, to join a business with Role. It can be
+// accepted once, before ExpiresAt, unless it has been revoked.
+type Invitation struct {
+	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BusinessID  uuid.UUID      `json:"business_id" gorm:"type:uuid;not null;index"`
+	Email       string         `json:"email" gorm:"not null;index"`
+	Role        MembershipRole `json:"role" gorm:"not null"`
+	InvitedByID uuid.UUID      `json:"invited_by_id" gorm:"type:uuid;not null"`
+	ExpiresAt   time.Time      `json:"expires_at" gorm:"not null"`
+	AcceptedAt  *time.Time     `json:"accepted_at"`
+	RevokedAt   *time.Time     `json:"revoked_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
 type Customer struct {
-	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	BusinessID uuid.UUID `json:"business_id" gorm:"type:uuid;not null;index"`
-	Name       string    `json:"name" gorm:"not null"`
-	Email      string    `json:"email" gorm:"not null;index"`
-	Phone      string    `json:"phone" gorm:"not null"`
-	Notes      string    `json:"notes"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
-	Business   Business  `json:"business" gorm:"foreignKey:BusinessID"`
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BusinessID  uuid.UUID `json:"business_id" gorm:"type:uuid;not null;index"`
+	Name        string    `json:"name" gorm:"not null"`
+	Email       string    `json:"email" gorm:"not null;index"`
+	Phone       string    `json:"phone" gorm:"not null"`
+	Notes       string    `json:"notes"`
+	NoShowCount int       `json:"no_show_count" gorm:"not null;default:0"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Business    Business  `json:"business" gorm:"foreignKey:BusinessID"`
 }
 
 type Vehicle struct {
-	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	BusinessID   uuid.UUID `json:"business_id" gorm:"type:uuid;not null;index"`
-	CustomerID   uuid.UUID `json:"customer_id" gorm:"type:uuid;not null;index"`
-	Year         int       `json:"year"`
-	Make         string    `json:"make" gorm:"not null"`
-	Model        string    `json:"model" gorm:"not null"`
-	Color        string    `json:"color"`
-	LicensePlate string    `json:"license_plate"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	Business     Business  `json:"business" gorm:"foreignKey:BusinessID"`
-	Customer     Customer  `json:"customer" gorm:"foreignKey:CustomerID"`
+	ID           uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BusinessID   uuid.UUID    `json:"business_id" gorm:"type:uuid;not null;index"`
+	CustomerID   uuid.UUID    `json:"customer_id" gorm:"type:uuid;not null;index"`
+	Year         int          `json:"year"`
+	Make         string       `json:"make" gorm:"not null"`
+	Model        string       `json:"model" gorm:"not null"`
+	Color        string       `json:"color"`
+	LicensePlate string       `json:"license_plate"`
+	Class        VehicleClass `json:"class" gorm:"size:20"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+	Business     Business     `json:"business" gorm:"foreignKey:BusinessID"`
+	Customer     Customer     `json:"customer" gorm:"foreignKey:CustomerID"`
 }
 
 type Job struct {
-	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	BusinessID  uuid.UUID  `json:"business_id" gorm:"type:uuid;not null;index"`
-	CustomerID  uuid.UUID  `json:"customer_id" gorm:"type:uuid;not null;index"`
-	VehicleID   uuid.UUID  `json:"vehicle_id" gorm:"type:uuid;not null;index"`
-	BookingID   *uuid.UUID `json:"booking_id" gorm:"type:uuid;index"`
-	Title       string     `json:"title" gorm:"not null"`
-	Status      JobStatus  `json:"status" gorm:"not null;default:'SCHEDULED'"`
-	ScheduledAt time.Time  `json:"scheduled_at" gorm:"not null;index"`
-	Notes       string     `json:"notes"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
-	Business    Business   `json:"business" gorm:"foreignKey:BusinessID"`
-	Customer    Customer   `json:"customer" gorm:"foreignKey:CustomerID"`
-	Vehicle     Vehicle    `json:"vehicle" gorm:"foreignKey:VehicleID"`
-	Booking     *Booking   `json:"booking,omitempty" gorm:"foreignKey:BookingID"`
+	ID          uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BusinessID  uuid.UUID     `json:"business_id" gorm:"type:uuid;not null;index"`
+	CustomerID  uuid.UUID     `json:"customer_id" gorm:"type:uuid;not null;index"`
+	VehicleID   uuid.UUID     `json:"vehicle_id" gorm:"type:uuid;not null;index"`
+	BookingID   *uuid.UUID    `json:"booking_id" gorm:"type:uuid;index"`
+	Title       string        `json:"title" gorm:"not null"`
+	Status      JobStatus     `json:"status" gorm:"not null;default:'SCHEDULED'"`
+	ScheduledAt time.Time     `json:"scheduled_at" gorm:"not null;index"`
+	Notes       string        `json:"notes"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	Business    Business      `json:"business" gorm:"foreignKey:BusinessID"`
+	Customer    Customer      `json:"customer" gorm:"foreignKey:CustomerID"`
+	Vehicle     Vehicle       `json:"vehicle" gorm:"foreignKey:VehicleID"`
+	Booking     *Booking      `json:"booking,omitempty" gorm:"foreignKey:BookingID"`
+	LineItems   []JobLineItem `json:"line_items" gorm:"foreignKey:JobID"`
+}
+
+// JobLineItem is work or parts a job added beyond what its booking priced.
+// Invoicing the job bills each one as an EXTRA line.
+type JobLineItem struct {
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	JobID           uuid.UUID `json:"job_id" gorm:"type:uuid;not null;index"`
+	BusinessID      uuid.UUID `json:"business_id" gorm:"type:uuid;not null;index"`
+	Name            string    `json:"name" gorm:"not null"`
+	Quantity        int       `json:"quantity" gorm:"not null;default:1"`
+	UnitAmountMinor int64     `json:"unit_amount_minor" gorm:"not null;default:0"`
+	AmountMinor     int64     `json:"amount_minor" gorm:"not null;default:0"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Invoice bills a customer for a booking, optionally through the job that
+// carried it out. Drafts have no number; issuing assigns the next number in
+// the business's gapless sequence. Totals are copied from the lines, and
+// DepositCreditMinor is what the payments ledger showed paid when drafted.
+type Invoice struct {
+	ID                 uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BusinessID         uuid.UUID     `json:"business_id" gorm:"type:uuid;not null;uniqueIndex:idx_invoice_business_number"`
+	Number             *int          `json:"number" gorm:"uniqueIndex:idx_invoice_business_number"`
+	BookingID          uuid.UUID     `json:"booking_id" gorm:"type:uuid;not null;index"`
+	JobID              *uuid.UUID    `json:"job_id" gorm:"type:uuid;index"`
+	Status             InvoiceStatus `json:"status" gorm:"not null;default:'DRAFT'"`
+	Reference          string        `json:"reference"`
+	CustomerName       string        `json:"customer_name" gorm:"not null"`
+	CustomerEmail      string        `json:"customer_email" gorm:"not null"`
+	CurrencyCode       string        `json:"currency_code" gorm:"size:3;not null"`
+	SubtotalMinor      int64         `json:"subtotal_minor" gorm:"not null;default:0"`
+	DiscountMinor      int64         `json:"discount_minor" gorm:"not null;default:0"`
+	TaxMinor           int64         `json:"tax_minor" gorm:"not null;default:0"`
+	TotalMinor         int64         `json:"total_minor" gorm:"not null;default:0"`
+	DepositCreditMinor int64         `json:"deposit_credit_minor" gorm:"not null;default:0"`
+	AmountDueMinor     int64         `json:"amount_due_minor" gorm:"not null;default:0"`
+	IssuedAt           *time.Time    `json:"issued_at"`
+	PaidAt             *time.Time    `json:"paid_at"`
+	VoidedAt           *time.Time    `json:"voided_at"`
+	CreatedAt          time.Time     `json:"created_at"`
+	UpdatedAt          time.Time     `json:"updated_at"`
+	Lines              []InvoiceLine `json:"lines" gorm:"foreignKey:InvoiceID"`
+}
+
+// InvoiceLine is a booking line item copied onto an invoice.
+type InvoiceLine struct {
+	ID              uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	InvoiceID       uuid.UUID    `json:"invoice_id" gorm:"type:uuid;not null;index"`
+	Position        int          `json:"position" gorm:"not null"`
+	Kind            LineItemKind `json:"kind" gorm:"not null"`
+	Description     string       `json:"description" gorm:"not null"`
+	Quantity        int          `json:"quantity" gorm:"not null;default:1"`
+	UnitAmountMinor int64        `json:"unit_amount_minor" gorm:"not null;default:0"`
+	AmountMinor     int64        `json:"amount_minor" gorm:"not null;default:0"`
+	TaxInclusive    bool         `json:"tax_inclusive" gorm:"not null;default:false"`
+}
+
+// PaymentRecord is one entry in a booking's payments ledger: money the
+// workshop received from the customer or refunded to them. AmountMinor is
+// always positive; Kind says which way it moved. Entries are never edited, so
+// a mistaken payment is corrected with a refund. RecordedByID is the staff
+// member who entered it, or nil for deposits paid online.
+type PaymentRecord struct {
+	ID           uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BusinessID   uuid.UUID     `json:"business_id" gorm:"type:uuid;not null;index"`
+	BookingID    uuid.UUID     `json:"booking_id" gorm:"type:uuid;not null;index"`
+	InvoiceID    *uuid.UUID    `json:"invoice_id" gorm:"type:uuid;index"`
+	Kind         PaymentKind   `json:"kind" gorm:"not null"`
+	Method       PaymentMethod `json:"method" gorm:"not null"`
+	AmountMinor  int64         `json:"amount_minor" gorm:"not null"`
+	CurrencyCode string        `json:"currency_code" gorm:"size:3;not null"`
+	Note         string        `json:"note"`
+	RecordedAt   time.Time     `json:"recorded_at" gorm:"not null"`
+	RecordedByID *uuid.UUID    `json:"recorded_by_id" gorm:"type:uuid"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+// PaymentIntent tracks a deposit the customer is paying online through a
+// payment provider. The booking stays PENDING, holding its slots, until the
+// provider's webhook reports the outcome or ExpiresAt passes.
+// A payment that arrives after the booking was cancelled is REFUND_PENDING
+// until the provider accepts its refund, then REFUNDED.
+type PaymentIntent struct {
+	ID               uuid.UUID           `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	BusinessID       uuid.UUID           `json:"business_id" gorm:"type:uuid;not null;index"`
+	BookingID        uuid.UUID           `json:"booking_id" gorm:"type:uuid;not null;index"`
+	Provider         string              `json:"provider" gorm:"not null"`
+	ProviderIntentID string              `json:"provider_intent_id" gorm:"not null;uniqueIndex"`
+	ClientSecret     string              `json:"-" gorm:"not null"`
+	AmountMinor      int64               `json:"amount_minor" gorm:"not null"`
+	CurrencyCode     string              `json:"currency_code" gorm:"size:3;not null"`
+	Status           PaymentIntentStatus `json:"status" gorm:"not null;default:'PENDING';index"`
+	ExpiresAt        time.Time           `json:"expires_at" gorm:"not null;index"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+}
+
+// InvoiceSequence holds the last invoice number issued by a business. It is
+// incremented in the issuing transaction, so a rolled-back issue leaves no gap.
+type InvoiceSequence struct {
+	BusinessID uuid.UUID `json:"business_id" gorm:"type:uuid;primary_key"`
+	LastNumber int       `json:"last_number" gorm:"not null;default:0"`
 }
 
 // BeforeCreate hook for GORM
@@ -176,6 +614,27 @@ func (sl *Slot) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+func (h *SlotHold) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == uuid.Nil {
+		h.ID = uuid.New()
+	}
+	return nil
+}
+
+func (hs *SlotHoldSlot) BeforeCreate(tx *gorm.DB) error {
+	if hs.ID == uuid.Nil {
+		hs.ID = uuid.New()
+	}
+	return nil
+}
+
+func (r *AvailabilityRule) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
 func (b *Booking) BeforeCreate(tx *gorm.DB) error {
 	if b.ID == uuid.Nil {
 		b.ID = uuid.New()
@@ -183,6 +642,139 @@ func (b *Booking) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+func (bs *BookingSlot) BeforeCreate(tx *gorm.DB) error {
+	if bs.ID == uuid.Nil {
+		bs.ID = uuid.New()
+	}
+	return nil
+}
+
+func (h *BookingHistory) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == uuid.Nil {
+		h.ID = uuid.New()
+	}
+	return nil
+}
+
+func (p *PromoCode) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+func (p *PromoCodeRestriction) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+func (r *PromoRedemption) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+func (i *Invoice) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+func (l *InvoiceLine) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
+
+func (p *PaymentRecord) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+func (s *Subscription) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+func (i *Invitation) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+func (e *BillingEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+func (i *PaymentIntent) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+func (r *TaxRate) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+func (v *ServicePriceVariant) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
+
+func (a *ServiceAddOn) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+func (l *BookingLineItem) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
+
+func (w *WaitlistEntry) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
+
+func (p *BookingPolicy) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+func (k *IdempotencyKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
+
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == uuid.Nil {
 		u.ID = uuid.New()
@@ -217,3 +809,10 @@ func (j *Job) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+func (l *JobLineItem) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
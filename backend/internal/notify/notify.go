@@ -0,0 +1,60 @@
+// Package notify delivers customer-facing messages such as waitlist offers.
+// Until a real email or SMS provider is wired in, messages are logged.
+package notify
+
+import (
+	"log"
+	"strings"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+	// Secrets lists values in Body, such as claim tokens, that grant access
+	// to whoever reads them. They are delivered but never logged.
+	Secrets []string
+}
+
+type Notifier interface {
+	Send(msg Message) error
+}
+
+// LogNotifier writes messages to the server log with their secrets redacted.
+// ShowSecrets keeps them so one-time tokens can be used in local development;
+// it must stay off anywhere the log is shared.
+type LogNotifier struct {
+	ShowSecrets bool
+}
+
+func (n LogNotifier) Send(msg Message) error {
+	body := msg.Body
+	if !n.ShowSecrets {
+		body = Redact(msg)
+	}
+	log.Printf("notify to=%s subject=%q body=%q", msg.To, msg.Subject, body)
+	return nil
+}
+
+// Redact returns msg's body with each of its secrets replaced.
+func Redact(msg Message) string {
+	body := msg.Body
+	for _, secret := range msg.Secrets {
+		if secret != "" {
+			body = strings.ReplaceAll(body, secret, "[redacted]")
+		}
+	}
+	return body
+}
+
+var notifier Notifier = LogNotifier{}
+
+func SetNotifier(n Notifier) {
+	if n != nil {
+		notifier = n
+	}
+}
+
+func Send(msg Message) error {
+	return notifier.Send(msg)
+}
@@ -0,0 +1,31 @@
+package notify
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLogNotifierRedactsSecrets(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	msg := Message{To: "alice@example.com", Subject: "Offer", Body: "Book with hold_token=secret-token", Secrets: []string{"secret-token"}}
+	if err := (LogNotifier{}).Send(msg); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if strings.Contains(buf.String(), "secret-token") || !strings.Contains(buf.String(), "hold_token=[redacted]") {
+		t.Fatalf("expected the token to be redacted, got %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := (LogNotifier{ShowSecrets: true}).Send(msg); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if !strings.Contains(buf.String(), "hold_token=secret-token") {
+		t.Fatalf("expected the token with ShowSecrets, got %q", buf.String())
+	}
+}
@@ -0,0 +1,119 @@
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// FakeProvider is an in-process provider for development and tests. It never
+// moves money: Complete stands in for the customer paying and returns the
+// signed webhook the provider would have sent.
+type FakeProvider struct {
+	secret []byte
+
+	mu      sync.Mutex
+	intents map[string]*fakeIntent
+}
+
+type fakeIntent struct {
+	amountMinor   int64
+	currencyCode  string
+	paid          bool
+	refundedMinor int64
+}
+
+func NewFakeProvider(webhookSecret string) *FakeProvider {
+	return &FakeProvider{
+		secret:  []byte(webhookSecret),
+		intents: make(map[string]*fakeIntent),
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) CreateIntent(req IntentRequest) (Intent, error) {
+	id := "fake_pi_" + uuid.NewString()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.intents[id] = &fakeIntent{amountMinor: req.AmountMinor, currencyCode: req.CurrencyCode}
+	return Intent{
+		ID:           id,
+		ClientSecret: id + "_secret_" + uuid.NewString(),
+		AmountMinor:  req.AmountMinor,
+		CurrencyCode: req.CurrencyCode,
+	}, nil
+}
+
+// Complete settles an intent as paid or failed and returns the webhook body
+// and signature announcing it.
+func (p *FakeProvider) Complete(intentID string, succeeded bool) ([]byte, string, error) {
+	p.mu.Lock()
+	intent, ok := p.intents[intentID]
+	if ok && succeeded {
+		intent.paid = true
+	}
+	p.mu.Unlock()
+	if !ok {
+		return nil, "", ErrUnknownIntent
+	}
+
+	event := Event{
+		ID:           "fake_evt_" + uuid.NewString(),
+		Type:         EventPaymentFailed,
+		IntentID:     intentID,
+		AmountMinor:  intent.amountMinor,
+		CurrencyCode: intent.currencyCode,
+	}
+	if succeeded {
+		event.Type = EventPaymentSucceeded
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, "", err
+	}
+	return payload, p.sign(payload), nil
+}
+
+func (p *FakeProvider) VerifyWebhook(payload []byte, signature string) (Event, error) {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, p.mac(payload)) {
+		return Event{}, ErrInvalidSignature
+	}
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return Event{}, err
+	}
+	return event, nil
+}
+
+func (p *FakeProvider) Refund(intentID string, amountMinor int64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return ErrUnknownIntent
+	}
+	if !intent.paid || intent.refundedMinor+amountMinor > intent.amountMinor {
+		return ErrRefundTooLarge
+	}
+	intent.refundedMinor += amountMinor
+	return nil
+}
+
+func (p *FakeProvider) sign(payload []byte) string {
+	return hex.EncodeToString(p.mac(payload))
+}
+
+func (p *FakeProvider) mac(payload []byte) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
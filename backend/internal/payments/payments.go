@@ -0,0 +1,58 @@
+// Package payments collects booking deposits online through a payment
+// provider. Providers confirm payments asynchronously with signed webhooks,
+// so a booking is only confirmed once its webhook has been verified.
+package payments
+
+import "errors"
+
+// SignatureHeader carries the webhook signature.
+const SignatureHeader = "X-Payment-Signature"
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrUnknownIntent    = errors.New("unknown payment intent")
+	ErrRefundTooLarge   = errors.New("refund exceeds the amount paid")
+)
+
+type EventType string
+
+const (
+	EventPaymentSucceeded EventType = "payment.succeeded"
+	EventPaymentFailed    EventType = "payment.failed"
+)
+
+// IntentRequest asks the provider to collect an amount. Reference is shown to
+// the customer and echoed back by the provider, usually the booking ID.
+type IntentRequest struct {
+	AmountMinor  int64
+	CurrencyCode string
+	Reference    string
+}
+
+// Intent is a payment the customer can complete with ClientSecret.
+type Intent struct {
+	ID           string
+	ClientSecret string
+	AmountMinor  int64
+	CurrencyCode string
+}
+
+// Event is a verified webhook notification about an intent.
+type Event struct {
+	ID           string    `json:"id"`
+	Type         EventType `json:"type"`
+	IntentID     string    `json:"intent_id"`
+	AmountMinor  int64     `json:"amount_minor"`
+	CurrencyCode string    `json:"currency_code"`
+}
+
+type Provider interface {
+	// Name identifies the provider on stored intents.
+	Name() string
+	CreateIntent(req IntentRequest) (Intent, error)
+	// VerifyWebhook checks the signature on a webhook body and decodes it.
+	// It returns ErrInvalidSignature when the body was not sent by the
+	// provider.
+	VerifyWebhook(payload []byte, signature string) (Event, error)
+	Refund(intentID string, amountMinor int64) error
+}
@@ -40,17 +40,129 @@ func NewRepository(cfg *config.Config) (*Repository, error) {
 func (r *Repository) AutoMigrate() error {
 	return r.DB.AutoMigrate(
 		&models.Business{},
+		&models.Subscription{},
+		&models.BillingEvent{},
 		&models.Service{},
+		&models.ServiceAddOn{},
+		&models.ServicePriceVariant{},
+		&models.PromoCode{},
+		&models.PromoCodeRestriction{},
+		&models.TaxRate{},
 		&models.Slot{},
+		&models.SlotHold{},
+		&models.SlotHoldSlot{},
+		&models.WaitlistEntry{},
+		&models.AvailabilityRule{},
+		&models.BookingPolicy{},
 		&models.Booking{},
+		&models.BookingSlot{},
+		&models.BookingLineItem{},
+		&models.PromoRedemption{},
+		&models.BookingHistory{},
 		&models.User{},
 		&models.Membership{},
+		&models.Invitation{},
 		&models.Customer{},
 		&models.Vehicle{},
 		&models.Job{},
+		&models.JobLineItem{},
+		&models.InvoiceSequence{},
+		&models.Invoice{},
+		&models.InvoiceLine{},
+		&models.PaymentRecord{},
+		&models.PaymentIntent{},
+		&models.IdempotencyKey{},
 	)
 }
 
+// MigrateSlotCapacity converts the legacy is_booked flag into booked_count
+// and drops the old column once every booked slot has been carried over.
+func (r *Repository) MigrateSlotCapacity() error {
+	if !r.DB.Migrator().HasColumn(&models.Slot{}, "is_booked") {
+		return nil
+	}
+
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			UPDATE slots
+			SET booked_count = 1
+			WHERE is_booked = true
+			  AND booked_count = 0
+		`).Error; err != nil {
+			return fmt.Errorf("backfill slot booked_count: %w", err)
+		}
+		if err := tx.Migrator().DropColumn(&models.Slot{}, "is_booked"); err != nil {
+			return fmt.Errorf("drop slot is_booked: %w", err)
+		}
+		return nil
+	})
+}
+
+// MigrateSubscriptions starts a trial for every workshop created before
+// subscriptions existed, so none is locked out the moment enforcement ships.
+func (r *Repository) MigrateSubscriptions(trialPeriod time.Duration) error {
+	var businesses []models.Business
+	if err := r.DB.
+		Where("NOT EXISTS (SELECT 1 FROM subscriptions WHERE subscriptions.business_id = businesses.id)").
+		Find(&businesses).Error; err != nil {
+		return fmt.Errorf("find workshops without a subscription: %w", err)
+	}
+
+	periodEnd := time.Now().UTC().Add(trialPeriod)
+	for _, business := range businesses {
+		if err := r.DB.Create(&models.Subscription{
+			BusinessID:       business.ID,
+			Plan:             models.SubscriptionPlanStarter,
+			Status:           models.SubscriptionStatusTrialing,
+			CurrentPeriodEnd: periodEnd,
+		}).Error; err != nil {
+			return fmt.Errorf("start trial for workshop %s: %w", business.ID, err)
+		}
+	}
+	return nil
+}
+
+// MigrateDepositDue copies the deposit recorded on bookings made before the
+// payments ledger into deposit_due_minor, which now holds the deposit the
+// service asked for, and records each of those deposits as a PAYMENT in the
+// ledger so balances, refunds and invoices account for it. Bookings that
+// already have ledger entries are left alone, so it is safe to run again.
+func (r *Repository) MigrateDepositDue() error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			UPDATE bookings
+			SET deposit_due_minor = deposit_paid_minor
+			WHERE deposit_due_minor = 0
+			  AND deposit_paid_minor > 0
+		`).Error; err != nil {
+			return fmt.Errorf("backfill booking deposit_due_minor: %w", err)
+		}
+
+		var bookings []models.Booking
+		if err := tx.
+			Where("deposit_paid_minor > 0").
+			Where("NOT EXISTS (SELECT 1 FROM payment_records WHERE payment_records.booking_id = bookings.id)").
+			Find(&bookings).Error; err != nil {
+			return fmt.Errorf("find deposits missing from the ledger: %w", err)
+		}
+		for _, booking := range bookings {
+			if err := tx.Create(&models.PaymentRecord{
+				BusinessID:   booking.BusinessID,
+				BookingID:    booking.ID,
+				Kind:         models.PaymentKindPayment,
+				Method:       models.PaymentMethodCard,
+				AmountMinor:  booking.DepositPaidMinor,
+				CurrencyCode: booking.CurrencyCode,
+				Note:         "Deposit paid before the payments ledger",
+				RecordedAt:   booking.CreatedAt,
+			}).Error; err != nil {
+				return fmt.Errorf("record deposit for booking %s: %w", booking.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
 func (r *Repository) BackfillMoneyToMinorUnits(defaultCurrencyCode string) error {
 	if defaultCurrencyCode == "" {
 		defaultCurrencyCode = "USD"
@@ -130,6 +242,12 @@ func (r *Repository) SeedData() error {
 
 	for _, biz := range businesses {
 		r.DB.Create(&biz)
+		r.DB.Create(&models.Subscription{
+			BusinessID:       biz.ID,
+			Plan:             models.SubscriptionPlanPro,
+			Status:           models.SubscriptionStatusActive,
+			CurrentPeriodEnd: time.Now().UTC().AddDate(1, 0, 0),
+		})
 	}
 
 	// Get businesses for services/slots reference
@@ -0,0 +1,29 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Every runs fn once immediately and then on every interval until ctx is
+// cancelled. Errors are logged so one failed run does not stop later ones.
+func Every(ctx context.Context, name string, interval time.Duration, fn func(now time.Time) error) {
+	run := func() {
+		if err := fn(time.Now().UTC()); err != nil {
+			log.Printf("Scheduled job %s failed: %v", name, err)
+		}
+	}
+
+	run()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"blytz.cloud/backend/internal/models"
+	"blytz.cloud/backend/internal/validator"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const maxAddOnPriceMinor = 10000000
+
+type AddOnService struct {
+	*BaseService
+}
+
+func NewAddOnService(db *gorm.DB) *AddOnService {
+	return &AddOnService{
+		BaseService: NewBaseService(db),
+	}
+}
+
+// GetByService lists the active add-ons offered with a service.
+func (s *AddOnService) GetByService(businessID, serviceID uuid.UUID) ([]models.ServiceAddOn, error) {
+	var addOns []models.ServiceAddOn
+	if err := s.DB.Where("business_id = ? AND service_id = ? AND archived_at IS NULL", businessID, serviceID).
+		Order("name ASC").
+		Find(&addOns).Error; err != nil {
+		return nil, err
+	}
+	return addOns, nil
+}
+
+func (s *AddOnService) Create(addOn *models.ServiceAddOn) error {
+	if err := validateAddOn(addOn); err != nil {
+		return err
+	}
+
+	var count int64
+	if err := s.DB.Model(&models.Service{}).
+		Where("id = ? AND business_id = ? AND archived_at IS NULL", addOn.ServiceID, addOn.BusinessID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return s.DB.Create(addOn).Error
+}
+
+func (s *AddOnService) Update(businessID, serviceID, id uuid.UUID, addOn *models.ServiceAddOn) (*models.ServiceAddOn, error) {
+	if err := validateAddOn(addOn); err != nil {
+		return nil, err
+	}
+
+	var existing models.ServiceAddOn
+	if err := s.DB.Where("id = ? AND service_id = ? AND business_id = ? AND archived_at IS NULL", id, serviceID, businessID).First(&existing).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	existing.Name = addOn.Name
+	existing.PriceMinor = addOn.PriceMinor
+	existing.ExtraDurationMin = addOn.ExtraDurationMin
+	if err := s.DB.Save(&existing).Error; err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// Archive stops an add-on from being offered. Bookings keep their snapshot.
+func (s *AddOnService) Archive(businessID, serviceID, id uuid.UUID) (*models.ServiceAddOn, error) {
+	var addOn models.ServiceAddOn
+	if err := s.DB.Where("id = ? AND service_id = ? AND business_id = ?", id, serviceID, businessID).First(&addOn).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if addOn.ArchivedAt != nil {
+		return &addOn, nil
+	}
+
+	now := time.Now().UTC()
+	if err := s.DB.Model(&models.ServiceAddOn{}).Where("id = ? AND archived_at IS NULL", addOn.ID).Update("archived_at", now).Error; err != nil {
+		return nil, err
+	}
+	addOn.ArchivedAt = &now
+	return &addOn, nil
+}
+
+func validateAddOn(addOn *models.ServiceAddOn) error {
+	addOn.Name = strings.TrimSpace(addOn.Name)
+	if !validator.ValidateServiceName(addOn.Name) {
+		return ErrBadRequest
+	}
+	if addOn.PriceMinor < 0 || addOn.PriceMinor > maxAddOnPriceMinor {
+		return ErrBadRequest
+	}
+	if addOn.ExtraDurationMin < 0 {
+		return ErrBadRequest
+	}
+	return nil
+}
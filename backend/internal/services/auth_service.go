@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"blytz.cloud/backend/internal/auth"
 	"blytz.cloud/backend/internal/models"
@@ -55,12 +56,11 @@ func (s *AuthService) Register(email, name, password string) (*models.User, stri
 			return err
 		}
 
-		membership := models.Membership{UserID: user.ID, BusinessID: business.ID, Role: models.MembershipRoleOwner}
-		if err := tx.Create(&membership).Error; err != nil {
+		if err := startTrial(tx, business.ID, time.Now().UTC()); err != nil {
 			return err
 		}
 
-		return nil
+		return addMembership(tx, &models.Membership{UserID: user.ID, BusinessID: business.ID, Role: models.MembershipRoleOwner})
 	})
 	if err != nil {
 		return nil, "", err
@@ -169,3 +169,16 @@ func (s *AuthService) UserHasBusinessAccess(userID, businessID uuid.UUID) (bool,
 	}
 	return count > 0, nil
 }
+
+// MembershipRole returns the user's role in the business, or ErrNotFound when
+// they are not a member.
+func (s *AuthService) MembershipRole(userID, businessID uuid.UUID) (models.MembershipRole, error) {
+	var membership models.Membership
+	if err := s.DB.Where("user_id = ? AND business_id = ?", userID, businessID).First(&membership).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	return membership.Role, nil
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"blytz.cloud/backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AvailabilityService struct {
+	*BaseService
+}
+
+// SlotGenerationResult reports how many slots a generation run added and
+// how many stale unbooked slots it removed.
+type SlotGenerationResult struct {
+	Created int
+	Removed int
+}
+
+func NewAvailabilityService(db *gorm.DB) *AvailabilityService {
+	return &AvailabilityService{
+		BaseService: NewBaseService(db),
+	}
+}
+
+func (s *AvailabilityService) GetByBusiness(businessID uuid.UUID) ([]models.AvailabilityRule, error) {
+	var rules []models.AvailabilityRule
+	if err := s.DB.Where("business_id = ?", businessID).Order("weekday ASC, start_minute ASC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Create adds a weekly availability rule. It returns ErrConflict when the
+// rule's hours overlap another rule for the same weekday.
+func (s *AvailabilityService) Create(rule *models.AvailabilityRule) error {
+	if err := validateAvailabilityRule(rule); err != nil {
+		return err
+	}
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkAvailabilityOverlap(tx, rule, uuid.Nil); err != nil {
+			return err
+		}
+		return tx.Create(rule).Error
+	})
+}
+
+func (s *AvailabilityService) Update(businessID, id uuid.UUID, rule *models.AvailabilityRule) (*models.AvailabilityRule, error) {
+	if err := validateAvailabilityRule(rule); err != nil {
+		return nil, err
+	}
+
+	var existing models.AvailabilityRule
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND business_id = ?", id, businessID).First(&existing).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		existing.Weekday = rule.Weekday
+		existing.StartMinute = rule.StartMinute
+		existing.EndMinute = rule.EndMinute
+		existing.SlotDurationMin = rule.SlotDurationMin
+		existing.Capacity = rule.Capacity
+		existing.BreakStartMinute = rule.BreakStartMinute
+		existing.BreakEndMinute = rule.BreakEndMinute
+		existing.Timezone = rule.Timezone
+		if err := checkAvailabilityOverlap(tx, &existing, existing.ID); err != nil {
+			return err
+		}
+		return tx.Save(&existing).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+func (s *AvailabilityService) Delete(businessID, id uuid.UUID) error {
+	result := s.DB.Where("id = ? AND business_id = ?", id, businessID).Delete(&models.AvailabilityRule{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GenerateSlots materializes the business's availability rules into slots
+// between from and the end of the horizon. Existing slots that still match a
+// rule are kept (with their capacity refreshed), unbooked slots that no longer
+// match are removed, and slots with any booking are never modified.
+func (s *AvailabilityService) GenerateSlots(businessID uuid.UUID, from time.Time, horizonDays int) (SlotGenerationResult, error) {
+	var result SlotGenerationResult
+	until := from.AddDate(0, 0, horizonDays)
+
+	rules, err := s.GetByBusiness(businessID)
+	if err != nil {
+		return result, err
+	}
+	candidates, err := buildSlotCandidates(rules, from, horizonDays)
+	if err != nil {
+		return result, err
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		var existing []models.Slot
+		if err := tx.Where("business_id = ? AND start_time >= ? AND start_time < ?", businessID, from, until).Find(&existing).Error; err != nil {
+			return err
+		}
+
+		wanted := make(map[[2]int64]slotWindow, len(candidates))
+		for _, candidate := range candidates {
+			wanted[candidate.key()] = candidate
+		}
+
+		kept := make(map[[2]int64]struct{}, len(existing))
+		var booked []slotWindow
+		var staleIDs []uuid.UUID
+		for _, slot := range existing {
+			window := slotWindow{start: slot.StartTime, end: slot.EndTime}
+			if slot.BookedCount > 0 {
+				booked = append(booked, window)
+				kept[window.key()] = struct{}{}
+				continue
+			}
+			if candidate, ok := wanted[window.key()]; ok {
+				kept[window.key()] = struct{}{}
+				if slot.Capacity != candidate.capacity {
+					if err := tx.Model(&models.Slot{}).
+						Where("id = ? AND booked_count = 0", slot.ID).
+						Update("capacity", candidate.capacity).Error; err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			staleIDs = append(staleIDs, slot.ID)
+		}
+
+		if len(staleIDs) > 0 {
+			// A slot booked since it was loaded is left alone by the booked_count
+			// guard. Cancelled and rescheduled bookings keep pointing at their
+			// slot, so a slot they reference is closed instead of deleted.
+			const referenced = "EXISTS (SELECT 1 FROM bookings WHERE bookings.slot_id = slots.id)"
+			deleteResult := tx.Where("id IN ? AND booked_count = 0 AND NOT "+referenced, staleIDs).Delete(&models.Slot{})
+			if deleteResult.Error != nil {
+				return deleteResult.Error
+			}
+			closeResult := tx.Model(&models.Slot{}).
+				Where("id IN ? AND booked_count = 0 AND capacity > 0 AND "+referenced, staleIDs).
+				Update("capacity", 0)
+			if closeResult.Error != nil {
+				return closeResult.Error
+			}
+			result.Removed = int(deleteResult.RowsAffected + closeResult.RowsAffected)
+		}
+
+		for _, candidate := range candidates {
+			if _, ok := kept[candidate.key()]; ok {
+				continue
+			}
+			if candidate.overlapsAny(booked) {
+				continue
+			}
+			slot := models.Slot{BusinessID: businessID, StartTime: candidate.start, EndTime: candidate.end, Capacity: candidate.capacity}
+			if err := tx.Create(&slot).Error; err != nil {
+				return err
+			}
+			kept[candidate.key()] = struct{}{}
+			result.Created++
+		}
+		return nil
+	})
+	return result, err
+}
+
+// RegenerateAll rolls the slot horizon forward for every business that has
+// availability rules. A business that fails is logged and skipped so it does
+// not hold up the others; the failures are returned together.
+func (s *AvailabilityService) RegenerateAll(from time.Time, horizonDays int) error {
+	var businessIDs []uuid.UUID
+	if err := s.DB.Model(&models.AvailabilityRule{}).Distinct("business_id").Pluck("business_id", &businessIDs).Error; err != nil {
+		return err
+	}
+	var errs []error
+	for _, businessID := range businessIDs {
+		if _, err := s.GenerateSlots(businessID, from, horizonDays); err != nil {
+			log.Printf("Failed to regenerate slots for business %s: %v", businessID, err)
+			errs = append(errs, fmt.Errorf("business %s: %w", businessID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type slotWindow struct {
+	start    time.Time
+	end      time.Time
+	capacity int
+}
+
+func (w slotWindow) key() [2]int64 {
+	return [2]int64{w.start.Unix(), w.end.Unix()}
+}
+
+func (w slotWindow) overlapsAny(windows []slotWindow) bool {
+	for _, other := range windows {
+		if w.start.Before(other.end) && other.start.Before(w.end) {
+			return true
+		}
+	}
+	return false
+}
+
+func buildSlotCandidates(rules []models.AvailabilityRule, from time.Time, horizonDays int) ([]slotWindow, error) {
+	var candidates []slotWindow
+	for _, rule := range rules {
+		location, err := time.LoadLocation(rule.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		localFrom := from.In(location)
+		for day := 0; day < horizonDays; day++ {
+			date := time.Date(localFrom.Year(), localFrom.Month(), localFrom.Day()+day, 0, 0, 0, 0, location)
+			if int(date.Weekday()) != rule.Weekday {
+				continue
+			}
+			for minute := rule.StartMinute; minute+rule.SlotDurationMin <= rule.EndMinute; minute += rule.SlotDurationMin {
+				if overlapsBreak(rule, minute, minute+rule.SlotDurationMin) {
+					continue
+				}
+				start := time.Date(date.Year(), date.Month(), date.Day(), minute/60, minute%60, 0, 0, location)
+				end := time.Date(date.Year(), date.Month(), date.Day(), (minute+rule.SlotDurationMin)/60, (minute+rule.SlotDurationMin)%60, 0, 0, location)
+				if start.Before(from) {
+					continue
+				}
+				candidates = append(candidates, slotWindow{start: start.UTC(), end: end.UTC(), capacity: rule.Capacity})
+			}
+		}
+	}
+	return candidates, nil
+}
+
+func overlapsBreak(rule models.AvailabilityRule, startMinute, endMinute int) bool {
+	if rule.BreakStartMinute == nil || rule.BreakEndMinute == nil {
+		return false
+	}
+	return startMinute < *rule.BreakEndMinute && *rule.BreakStartMinute < endMinute
+}
+
+// checkAvailabilityOverlap returns ErrConflict when another of the business's
+// rules for the same weekday covers any of rule's hours, since both would
+// generate the same slots. The rule with excludeID is the one being updated.
+func checkAvailabilityOverlap(tx *gorm.DB, rule *models.AvailabilityRule, excludeID uuid.UUID) error {
+	var overlapping int64
+	if err := tx.Model(&models.AvailabilityRule{}).
+		Where("business_id = ? AND weekday = ? AND id <> ?", rule.BusinessID, rule.Weekday, excludeID).
+		Where("start_minute < ? AND end_minute > ?", rule.EndMinute, rule.StartMinute).
+		Count(&overlapping).Error; err != nil {
+		return err
+	}
+	if overlapping > 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+func validateAvailabilityRule(rule *models.AvailabilityRule) error {
+	if rule.Timezone == "" {
+		rule.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(rule.Timezone); err != nil {
+		return ErrBadRequest
+	}
+	if rule.Capacity == 0 {
+		rule.Capacity = 1
+	}
+	if rule.Capacity < 0 {
+		return ErrBadRequest
+	}
+	if rule.Weekday < 0 || rule.Weekday > 6 {
+		return ErrBadRequest
+	}
+	if rule.StartMinute < 0 || rule.EndMinute > 24*60 || rule.StartMinute >= rule.EndMinute {
+		return ErrBadRequest
+	}
+	if rule.SlotDurationMin <= 0 || rule.SlotDurationMin > rule.EndMinute-rule.StartMinute {
+		return ErrBadRequest
+	}
+	if (rule.BreakStartMinute == nil) != (rule.BreakEndMinute == nil) {
+		return ErrBadRequest
+	}
+	if rule.BreakStartMinute != nil {
+		if *rule.BreakStartMinute >= *rule.BreakEndMinute || *rule.BreakStartMinute < rule.StartMinute || *rule.BreakEndMinute > rule.EndMinute {
+			return ErrBadRequest
+		}
+	}
+	return nil
+}
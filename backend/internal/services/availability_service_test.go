@@ -0,0 +1,221 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"blytz.cloud/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func setupAvailabilityTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db := setupBookingTestDB(t)
+	if err := db.Exec(`CREATE TABLE availability_rules (
+		id text PRIMARY KEY,
+		business_id text NOT NULL,
+		weekday integer NOT NULL,
+		start_minute integer NOT NULL,
+		end_minute integer NOT NULL,
+		slot_duration_min integer NOT NULL,
+		capacity integer NOT NULL DEFAULT 1,
+		break_start_minute integer,
+		break_end_minute integer,
+		timezone text NOT NULL,
+		created_at datetime,
+		updated_at datetime
+	)`).Error; err != nil {
+		t.Fatalf("create availability schema: %v", err)
+	}
+	return db
+}
+
+func weekdayRule(business models.Business, weekday time.Weekday) *models.AvailabilityRule {
+	breakStart := 13 * 60
+	breakEnd := 14 * 60
+	return &models.AvailabilityRule{
+		BusinessID:       business.ID,
+		Weekday:          int(weekday),
+		StartMinute:      9 * 60,
+		EndMinute:        18 * 60,
+		SlotDurationMin:  60,
+		BreakStartMinute: &breakStart,
+		BreakEndMinute:   &breakEnd,
+		Timezone:         "UTC",
+	}
+}
+
+func TestAvailabilityServiceGenerateSlotsSkipsBreaksAndIsIdempotent(t *testing.T) {
+	db := setupAvailabilityTestDB(t)
+	business, _, _ := seedBookingTestRecords(t, db)
+	if err := db.Where("business_id = ?", business.ID).Delete(&models.Slot{}).Error; err != nil {
+		t.Fatalf("clear seeded slots: %v", err)
+	}
+	availabilityService := NewAvailabilityService(db)
+
+	for _, weekday := range []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday} {
+		if err := availabilityService.Create(weekdayRule(business, weekday)); err != nil {
+			t.Fatalf("create rule: %v", err)
+		}
+	}
+
+	monday := time.Date(2030, time.January, 7, 0, 0, 0, 0, time.UTC)
+	result, err := availabilityService.GenerateSlots(business.ID, monday, 7)
+	if err != nil {
+		t.Fatalf("generate slots: %v", err)
+	}
+	if result.Created != 40 {
+		t.Fatalf("expected 40 slots for Mon-Fri with a lunch break, got %d", result.Created)
+	}
+
+	var lunchSlots int64
+	if err := db.Model(&models.Slot{}).Where("start_time = ?", monday.Add(13*time.Hour)).Count(&lunchSlots).Error; err != nil {
+		t.Fatalf("count lunch slots: %v", err)
+	}
+	if lunchSlots != 0 {
+		t.Fatal("expected no slot during the lunch break")
+	}
+
+	again, err := availabilityService.GenerateSlots(business.ID, monday, 7)
+	if err != nil {
+		t.Fatalf("regenerate slots: %v", err)
+	}
+	if again.Created != 0 || again.Removed != 0 {
+		t.Fatalf("expected regeneration to be a no-op, got %+v", again)
+	}
+}
+
+func TestAvailabilityServiceRejectsOverlappingRules(t *testing.T) {
+	db := setupAvailabilityTestDB(t)
+	business, _, _ := seedBookingTestRecords(t, db)
+	availabilityService := NewAvailabilityService(db)
+
+	morning := &models.AvailabilityRule{BusinessID: business.ID, Weekday: int(time.Monday), StartMinute: 9 * 60, EndMinute: 12 * 60, SlotDurationMin: 60}
+	if err := availabilityService.Create(morning); err != nil {
+		t.Fatalf("create morning rule: %v", err)
+	}
+	overlapping := &models.AvailabilityRule{BusinessID: business.ID, Weekday: int(time.Monday), StartMinute: 11 * 60, EndMinute: 15 * 60, SlotDurationMin: 60}
+	if err := availabilityService.Create(overlapping); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected an overlapping rule to conflict, got %v", err)
+	}
+
+	afternoon := &models.AvailabilityRule{BusinessID: business.ID, Weekday: int(time.Monday), StartMinute: 12 * 60, EndMinute: 17 * 60, SlotDurationMin: 60}
+	if err := availabilityService.Create(afternoon); err != nil {
+		t.Fatalf("create adjoining rule: %v", err)
+	}
+	tuesday := &models.AvailabilityRule{BusinessID: business.ID, Weekday: int(time.Tuesday), StartMinute: 9 * 60, EndMinute: 17 * 60, SlotDurationMin: 60}
+	if err := availabilityService.Create(tuesday); err != nil {
+		t.Fatalf("create rule for another weekday: %v", err)
+	}
+
+	widened := *morning
+	widened.EndMinute = 13 * 60
+	if _, err := availabilityService.Update(business.ID, morning.ID, &widened); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected widening into the afternoon rule to conflict, got %v", err)
+	}
+	narrowed := *morning
+	narrowed.StartMinute = 10 * 60
+	if _, err := availabilityService.Update(business.ID, morning.ID, &narrowed); err != nil {
+		t.Fatalf("expected a rule to be updatable within its own hours: %v", err)
+	}
+}
+
+func TestAvailabilityServiceGenerateSlotsNeverTouchesBookedSlots(t *testing.T) {
+	db := setupAvailabilityTestDB(t)
+	business, _, _ := seedBookingTestRecords(t, db)
+	availabilityService := NewAvailabilityService(db)
+
+	monday := time.Date(2030, time.January, 7, 0, 0, 0, 0, time.UTC)
+	booked := models.Slot{
+		BusinessID:  business.ID,
+		StartTime:   monday.Add(9*time.Hour + 30*time.Minute),
+		EndTime:     monday.Add(10*time.Hour + 30*time.Minute),
+		Capacity:    1,
+		BookedCount: 1,
+	}
+	stale := models.Slot{
+		BusinessID: business.ID,
+		StartTime:  monday.Add(20 * time.Hour),
+		EndTime:    monday.Add(21 * time.Hour),
+	}
+	for _, slot := range []*models.Slot{&booked, &stale} {
+		if err := db.Create(slot).Error; err != nil {
+			t.Fatalf("create slot: %v", err)
+		}
+	}
+
+	if err := availabilityService.Create(weekdayRule(business, time.Monday)); err != nil {
+		t.Fatalf("create rule: %v", err)
+	}
+	result, err := availabilityService.GenerateSlots(business.ID, monday, 1)
+	if err != nil {
+		t.Fatalf("generate slots: %v", err)
+	}
+	if result.Removed != 1 {
+		t.Fatalf("expected the stale unbooked slot to be removed, got %d", result.Removed)
+	}
+	// 09:00 and 10:00 overlap the booked 09:30 slot, so only 6 of 8 are created.
+	if result.Created != 6 {
+		t.Fatalf("expected 6 new slots around the booked slot, got %d", result.Created)
+	}
+
+	var persisted models.Slot
+	if err := db.First(&persisted, "id = ?", booked.ID).Error; err != nil {
+		t.Fatalf("expected booked slot to survive regeneration: %v", err)
+	}
+	if persisted.BookedCount != 1 {
+		t.Fatal("expected booked slot to stay booked")
+	}
+}
+
+func TestAvailabilityServiceGenerateSlotsClosesStaleSlotsBookingsReference(t *testing.T) {
+	db := setupAvailabilityTestDB(t)
+	business, service, _ := seedBookingTestRecords(t, db)
+	availabilityService := NewAvailabilityService(db)
+	bookingService := NewBookingService(db)
+
+	monday := time.Date(2030, time.January, 7, 0, 0, 0, 0, time.UTC)
+	stale := models.Slot{
+		BusinessID: business.ID,
+		StartTime:  monday.Add(20 * time.Hour),
+		EndTime:    monday.Add(22 * time.Hour),
+		Capacity:   1,
+	}
+	if err := db.Create(&stale).Error; err != nil {
+		t.Fatalf("create slot: %v", err)
+	}
+	booking := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     stale.ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.Create(booking); err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+	if _, err := bookingService.UpdateStatus(business.ID, booking.ID, models.BookingStatusCancelled); err != nil {
+		t.Fatalf("cancel booking: %v", err)
+	}
+
+	if err := availabilityService.Create(weekdayRule(business, time.Monday)); err != nil {
+		t.Fatalf("create rule: %v", err)
+	}
+	result, err := availabilityService.GenerateSlots(business.ID, monday, 1)
+	if err != nil {
+		t.Fatalf("generate slots: %v", err)
+	}
+	if result.Removed != 1 {
+		t.Fatalf("expected the stale slot to be taken out of availability, got %d", result.Removed)
+	}
+
+	var persisted models.Slot
+	if err := db.First(&persisted, "id = ?", stale.ID).Error; err != nil {
+		t.Fatalf("expected the slot the cancelled booking references to be kept: %v", err)
+	}
+	if persisted.Capacity != 0 {
+		t.Fatalf("expected the kept slot to be closed, got capacity %d", persisted.Capacity)
+	}
+}
@@ -7,11 +7,15 @@ import (
 )
 
 var (
-	ErrNotFound     = errors.New("resource not found")
-	ErrConflict     = errors.New("resource already exists")
-	ErrBadRequest   = errors.New("invalid request")
-	ErrUnauthorized = errors.New("unauthorized")
-	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound          = errors.New("resource not found")
+	ErrConflict          = errors.New("resource already exists")
+	ErrBadRequest        = errors.New("invalid request")
+	ErrUnauthorized      = errors.New("unauthorized")
+	ErrForbidden         = errors.New("forbidden")
+	ErrInvalidTransition = errors.New("invalid status transition")
+	ErrKeyReused         = errors.New("idempotency key reused with a different request")
+	ErrInvalidPromoCode  = errors.New("promo code cannot be applied")
+	ErrPlanLimitReached  = errors.New("plan limit reached")
 )
 
 type BaseService struct {
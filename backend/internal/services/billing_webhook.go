@@ -0,0 +1,137 @@
+package services
+
+import (
+	"blytz.cloud/backend/internal/billing"
+	"blytz.cloud/backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var billingProvider billing.Provider
+
+// SetBillingProvider enables billing webhooks. Without a provider,
+// subscriptions keep the state they were given at signup or by migration.
+func SetBillingProvider(provider billing.Provider) {
+	billingProvider = provider
+}
+
+// HandleBillingWebhook verifies a billing provider webhook and applies it to
+// the business's subscription. Each event is recorded in the same transaction
+// as its change, so a redelivered event is skipped, and an event older than
+// the last one applied is recorded without changing the subscription.
+func (s *SubscriptionService) HandleBillingWebhook(payload []byte, signature string) error {
+	if billingProvider == nil {
+		return ErrNotFound
+	}
+	event, err := billingProvider.VerifyWebhook(payload, signature)
+	if err != nil {
+		return err
+	}
+	businessID, err := uuid.Parse(event.BusinessID)
+	if err != nil || event.ID == "" || event.OccurredAt.IsZero() {
+		return ErrBadRequest
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.BillingEvent{
+			Provider:   billingProvider.Name(),
+			EventID:    event.ID,
+			Type:       string(event.Type),
+			BusinessID: businessID,
+			OccurredAt: event.OccurredAt,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		return applyBillingEvent(tx, businessID, event)
+	})
+}
+
+func applyBillingEvent(tx *gorm.DB, businessID uuid.UUID, event billing.Event) error {
+	// Lock the subscription so events for the same business apply in turn.
+	var sub models.Subscription
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("business_id = ?", businessID).First(&sub).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+	exists := err == nil
+	if !exists {
+		var business models.Business
+		if err := tx.Where("id = ?", businessID).First(&business).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrNotFound
+			}
+			return err
+		}
+		sub.BusinessID = businessID
+	}
+	if sub.LastEventAt != nil && event.OccurredAt.Before(*sub.LastEventAt) {
+		return nil
+	}
+
+	switch event.Type {
+	case billing.EventSubscriptionCreated, billing.EventSubscriptionUpdated:
+		plan := models.SubscriptionPlan(event.Plan)
+		status := models.SubscriptionStatus(event.Status)
+		if !validBillingPlan(plan) || !validBillingStatus(status) || event.CurrentPeriodEnd.IsZero() {
+			return ErrBadRequest
+		}
+		sub.Plan = plan
+		sub.Status = status
+		sub.CurrentPeriodEnd = event.CurrentPeriodEnd
+		sub.CanceledAt = nil
+		if status == models.SubscriptionStatusCanceled {
+			canceledAt := event.OccurredAt
+			sub.CanceledAt = &canceledAt
+		}
+	case billing.EventSubscriptionPaymentFailed:
+		if !exists {
+			return ErrNotFound
+		}
+		sub.Status = models.SubscriptionStatusPastDue
+	case billing.EventSubscriptionCanceled:
+		if !exists {
+			return ErrNotFound
+		}
+		canceledAt := event.OccurredAt
+		sub.Status = models.SubscriptionStatusCanceled
+		sub.CanceledAt = &canceledAt
+	default:
+		// Other events are recorded so they are not redelivered, but change
+		// nothing.
+		return nil
+	}
+
+	occurredAt := event.OccurredAt
+	sub.LastEventAt = &occurredAt
+	if !exists {
+		return tx.Create(&sub).Error
+	}
+	return tx.Save(&sub).Error
+}
+
+func validBillingPlan(plan models.SubscriptionPlan) bool {
+	switch plan {
+	case models.SubscriptionPlanStarter, models.SubscriptionPlanPro:
+		return true
+	}
+	return false
+}
+
+// validBillingStatus reports whether the provider can set status. RESTRICTED
+// is ours: it marks a trial that ended before the provider knew of it.
+func validBillingStatus(status models.SubscriptionStatus) bool {
+	switch status {
+	case models.SubscriptionStatusTrialing,
+		models.SubscriptionStatusActive,
+		models.SubscriptionStatusPastDue,
+		models.SubscriptionStatusCanceled:
+		return true
+	}
+	return false
+}
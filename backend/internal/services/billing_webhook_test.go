@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"blytz.cloud/backend/internal/billing"
+	"blytz.cloud/backend/internal/models"
+)
+
+func TestSubscriptionServiceAppliesBillingWebhooksOnce(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, _, _ := seedBookingTestRecords(t, db)
+	subscriptionService := NewSubscriptionService(db)
+	provider := billing.NewFakeProvider("test-billing-secret")
+	SetBillingProvider(provider)
+	t.Cleanup(func() { SetBillingProvider(nil) })
+	now := time.Now().UTC().Truncate(time.Second)
+	if err := startTrial(db, business.ID, now); err != nil {
+		t.Fatalf("start trial: %v", err)
+	}
+
+	periodEnd := now.AddDate(0, 1, 0)
+	payload, signature, err := provider.Emit(billing.Event{
+		Type:             billing.EventSubscriptionCreated,
+		BusinessID:       business.ID.String(),
+		Plan:             string(models.SubscriptionPlanPro),
+		Status:           string(models.SubscriptionStatusActive),
+		CurrentPeriodEnd: periodEnd,
+		OccurredAt:       now,
+	})
+	if err != nil {
+		t.Fatalf("emit created event: %v", err)
+	}
+	if err := subscriptionService.HandleBillingWebhook([]byte(string(payload)+" "), signature); !errors.Is(err, billing.ErrInvalidSignature) {
+		t.Fatalf("expected a tampered webhook to be rejected, got %v", err)
+	}
+	if err := subscriptionService.HandleBillingWebhook(payload, signature); err != nil {
+		t.Fatalf("deliver created event: %v", err)
+	}
+
+	failed, failedSignature, err := provider.Emit(billing.Event{
+		Type:       billing.EventSubscriptionPaymentFailed,
+		BusinessID: business.ID.String(),
+		OccurredAt: now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("emit payment failed event: %v", err)
+	}
+	if err := subscriptionService.HandleBillingWebhook(failed, failedSignature); err != nil {
+		t.Fatalf("deliver payment failed event: %v", err)
+	}
+	// A redelivered older event must not undo the newer one.
+	if err := subscriptionService.HandleBillingWebhook(payload, signature); err != nil {
+		t.Fatalf("redeliver created event: %v", err)
+	}
+	stale, staleSignature, err := provider.Emit(billing.Event{
+		Type:             billing.EventSubscriptionUpdated,
+		BusinessID:       business.ID.String(),
+		Plan:             string(models.SubscriptionPlanPro),
+		Status:           string(models.SubscriptionStatusActive),
+		CurrentPeriodEnd: periodEnd,
+		OccurredAt:       now.Add(30 * time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("emit stale event: %v", err)
+	}
+	if err := subscriptionService.HandleBillingWebhook(stale, staleSignature); err != nil {
+		t.Fatalf("deliver stale event: %v", err)
+	}
+
+	sub, err := subscriptionService.GetByBusiness(business.ID)
+	if err != nil {
+		t.Fatalf("load subscription: %v", err)
+	}
+	if sub.Plan != models.SubscriptionPlanPro || sub.Status != models.SubscriptionStatusPastDue || !sub.CurrentPeriodEnd.Equal(periodEnd) {
+		t.Fatalf("expected a PAST_DUE PRO subscription ending %s, got %s %s ending %s", periodEnd, sub.Status, sub.Plan, sub.CurrentPeriodEnd)
+	}
+	var events int64
+	if err := db.Model(&models.BillingEvent{}).Where("business_id = ?", business.ID).Count(&events).Error; err != nil {
+		t.Fatalf("count billing events: %v", err)
+	}
+	if events != 3 {
+		t.Fatalf("expected 3 distinct billing events to be recorded, got %d", events)
+	}
+
+	canceled, canceledSignature, err := provider.Emit(billing.Event{
+		Type:       billing.EventSubscriptionCanceled,
+		BusinessID: business.ID.String(),
+		OccurredAt: now.Add(2 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("emit canceled event: %v", err)
+	}
+	if err := subscriptionService.HandleBillingWebhook(canceled, canceledSignature); err != nil {
+		t.Fatalf("deliver canceled event: %v", err)
+	}
+	sub, err = subscriptionService.GetByBusiness(business.ID)
+	if err != nil {
+		t.Fatalf("reload subscription: %v", err)
+	}
+	if sub.Status != models.SubscriptionStatusCanceled || sub.CanceledAt == nil {
+		t.Fatalf("expected a CANCELED subscription with canceled_at, got %s", sub.Status)
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"time"
+
+	"blytz.cloud/backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultSelfServiceCutoffHours   = 24
+	defaultFreeCancelNoticeHours    = 24
+	defaultLateCancelDepositKeepPct = 100
+	defaultNoShowDepositKeepPct     = 100
+)
+
+type BookingPolicyService struct {
+	*BaseService
+}
+
+func NewBookingPolicyService(db *gorm.DB) *BookingPolicyService {
+	return &BookingPolicyService{
+		BaseService: NewBaseService(db),
+	}
+}
+
+// Get returns the business's booking policy, or the defaults when the
+// business has never saved one.
+func (s *BookingPolicyService) Get(businessID uuid.UUID) (*models.BookingPolicy, error) {
+	policy, err := loadBookingPolicy(s.DB, businessID)
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (s *BookingPolicyService) Update(businessID uuid.UUID, policy *models.BookingPolicy) (*models.BookingPolicy, error) {
+	if policy.SelfServiceCutoffHours < 0 || policy.FreeCancelNoticeHours < 0 {
+		return nil, ErrBadRequest
+	}
+	if !validKeepPct(policy.LateCancelDepositKeepPct) || !validKeepPct(policy.NoShowDepositKeepPct) {
+		return nil, ErrBadRequest
+	}
+
+	existing, err := loadBookingPolicy(s.DB, businessID)
+	if err != nil {
+		return nil, err
+	}
+	existing.SelfServiceCutoffHours = policy.SelfServiceCutoffHours
+	existing.FreeCancelNoticeHours = policy.FreeCancelNoticeHours
+	existing.LateCancelDepositKeepPct = policy.LateCancelDepositKeepPct
+	existing.NoShowDepositKeepPct = policy.NoShowDepositKeepPct
+	if err := s.DB.Save(&existing).Error; err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+func loadBookingPolicy(tx *gorm.DB, businessID uuid.UUID) (models.BookingPolicy, error) {
+	var policy models.BookingPolicy
+	err := tx.Where("business_id = ?", businessID).First(&policy).Error
+	if err == gorm.ErrRecordNotFound {
+		return models.BookingPolicy{
+			BusinessID:               businessID,
+			SelfServiceCutoffHours:   defaultSelfServiceCutoffHours,
+			FreeCancelNoticeHours:    defaultFreeCancelNoticeHours,
+			LateCancelDepositKeepPct: defaultLateCancelDepositKeepPct,
+			NoShowDepositKeepPct:     defaultNoShowDepositKeepPct,
+		}, nil
+	}
+	return policy, err
+}
+
+// selfServiceOpen reports whether a customer may still change a booking that
+// starts at slotTime.
+func selfServiceOpen(policy models.BookingPolicy, slotTime, now time.Time) bool {
+	return now.Before(slotTime.Add(-time.Duration(policy.SelfServiceCutoffHours) * time.Hour))
+}
+
+func validKeepPct(pct int) bool {
+	return pct >= 0 && pct <= 100
+}
+
+// cancellationOutcome splits a deposit into the refundable and forfeited
+// parts for a cancellation made at now. Cancelling with at least the free
+// notice refunds everything; later cancellations keep the late-cancel share.
+func cancellationOutcome(policy models.BookingPolicy, deposit int64, slotTime, now time.Time) (int64, int64) {
+	if !now.After(slotTime.Add(-time.Duration(policy.FreeCancelNoticeHours) * time.Hour)) {
+		return deposit, 0
+	}
+	return splitDeposit(deposit, policy.LateCancelDepositKeepPct)
+}
+
+// splitDeposit keeps keepPct percent of deposit, rounding the kept amount
+// down so the customer never loses more than the policy states.
+func splitDeposit(deposit int64, keepPct int) (int64, int64) {
+	forfeited := deposit * int64(keepPct) / 100
+	return deposit - forfeited, forfeited
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"fmt"
+
+	"blytz.cloud/backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LineItemTotals summarizes a booking's line items. Discounts are reported as
+// a positive amount taken off the subtotal.
+type LineItemTotals struct {
+	SubtotalMinor int64
+	DiscountMinor int64
+	TaxMinor      int64
+	TotalMinor    int64
+}
+
+// TotalLineItems derives a booking's totals from its line items. Booking
+// totals are never stored independently of the items that explain them.
+func TotalLineItems(items []models.BookingLineItem) LineItemTotals {
+	var totals LineItemTotals
+	for _, item := range items {
+		switch item.Kind {
+		case models.LineItemKindService, models.LineItemKindAddOn, models.LineItemKindExtra:
+			totals.SubtotalMinor += item.AmountMinor
+		case models.LineItemKindDiscount:
+			totals.DiscountMinor -= item.AmountMinor
+		case models.LineItemKindTax:
+			totals.TaxMinor += item.AmountMinor
+			if item.TaxInclusive {
+				continue
+			}
+		}
+		totals.TotalMinor += item.AmountMinor
+	}
+	return totals
+}
+
+// bookingQuote is the priced breakdown of a service plus its chosen add-ons.
+type bookingQuote struct {
+	LineItems    []models.BookingLineItem
+	CurrencyCode string
+	DepositMinor int64
+	DurationMin  int
+}
+
+// addLine prices item at its quantity and appends it to the quote.
+func (q *bookingQuote) addLine(item models.BookingLineItem) {
+	if item.Quantity == 0 {
+		item.Quantity = 1
+	}
+	item.AmountMinor = int64(item.Quantity) * item.UnitAmountMinor
+	item.CurrencyCode = q.CurrencyCode
+	q.LineItems = append(q.LineItems, item)
+	q.DurationMin += item.DurationMin
+}
+
+func (q *bookingQuote) totals() LineItemTotals {
+	return TotalLineItems(q.LineItems)
+}
+
+// quoteBooking prices service for vehicleClass with the add-ons in addOnIDs.
+// A price variant for the class replaces the service's base price and
+// deposit; without one the base price applies. Every add-on must be an active
+// add-on of that service, and each may be chosen only once.
+func quoteBooking(tx *gorm.DB, service models.Service, vehicleClass models.VehicleClass, addOnIDs []uuid.UUID) (bookingQuote, error) {
+	name := service.Name
+	price := service.TotalPriceMinor
+	deposit := service.DepositAmountMinor
+	if vehicleClass != "" {
+		var variant models.ServicePriceVariant
+		err := tx.Where("service_id = ? AND vehicle_class = ?", service.ID, vehicleClass).First(&variant).Error
+		switch err {
+		case nil:
+			name = fmt.Sprintf("%s (%s)", service.Name, vehicleClass)
+			price = variant.TotalPriceMinor
+			deposit = variant.DepositAmountMinor
+		case gorm.ErrRecordNotFound:
+		default:
+			return bookingQuote{}, err
+		}
+	}
+
+	serviceID := service.ID
+	quote := bookingQuote{CurrencyCode: service.CurrencyCode, DepositMinor: deposit}
+	quote.addLine(models.BookingLineItem{
+		BusinessID:      service.BusinessID,
+		Kind:            models.LineItemKindService,
+		ReferenceID:     &serviceID,
+		Name:            name,
+		UnitAmountMinor: price,
+		DurationMin:     service.DurationMin,
+	})
+	if len(addOnIDs) == 0 {
+		return quote, nil
+	}
+
+	seen := make(map[uuid.UUID]struct{}, len(addOnIDs))
+	for _, id := range addOnIDs {
+		if _, ok := seen[id]; ok {
+			return bookingQuote{}, ErrBadRequest
+		}
+		seen[id] = struct{}{}
+	}
+
+	var addOns []models.ServiceAddOn
+	if err := tx.Where("id IN ? AND service_id = ? AND business_id = ? AND archived_at IS NULL", addOnIDs, service.ID, service.BusinessID).
+		Order("name ASC").
+		Find(&addOns).Error; err != nil {
+		return bookingQuote{}, err
+	}
+	if len(addOns) != len(addOnIDs) {
+		return bookingQuote{}, ErrBadRequest
+	}
+
+	for _, addOn := range addOns {
+		addOnID := addOn.ID
+		quote.addLine(models.BookingLineItem{
+			BusinessID:      service.BusinessID,
+			Kind:            models.LineItemKindAddOn,
+			ReferenceID:     &addOnID,
+			Name:            addOn.Name,
+			UnitAmountMinor: addOn.PriceMinor,
+			DurationMin:     addOn.ExtraDurationMin,
+		})
+	}
+	return quote, nil
+}
+
+// applyTaxes adds a tax line for each of the business's tax rates. Tax is
+// charged on the discounted subtotal. Inclusive rates extract their share of
+// that amount, exclusive rates are charged on top of what remains once the
+// inclusive tax is taken out, and each line is rounded half-up to the minor
+// unit.
+func applyTaxes(tx *gorm.DB, businessID uuid.UUID, quote *bookingQuote) error {
+	var rates []models.TaxRate
+	if err := tx.Where("business_id = ?", businessID).Order("name ASC, id ASC").Find(&rates).Error; err != nil {
+		return err
+	}
+	if len(rates) == 0 {
+		return nil
+	}
+
+	totals := quote.totals()
+	taxable := totals.SubtotalMinor - totals.DiscountMinor
+	if taxable <= 0 {
+		return nil
+	}
+	inclusiveBasisPoints := 0
+	for _, rate := range rates {
+		if rate.Inclusive {
+			inclusiveBasisPoints += rate.RateBasisPoints
+		}
+	}
+
+	taxes := make([]int64, len(rates))
+	net := taxable
+	for i, rate := range rates {
+		if rate.Inclusive {
+			taxes[i] = divRoundHalfUp(taxable*int64(rate.RateBasisPoints), int64(basisPointsPerUnit+inclusiveBasisPoints))
+			net -= taxes[i]
+		}
+	}
+	for i, rate := range rates {
+		if !rate.Inclusive {
+			taxes[i] = divRoundHalfUp(net*int64(rate.RateBasisPoints), basisPointsPerUnit)
+		}
+	}
+
+	for i, rate := range rates {
+		rateID := rate.ID
+		quote.addLine(models.BookingLineItem{
+			BusinessID:      businessID,
+			Kind:            models.LineItemKindTax,
+			ReferenceID:     &rateID,
+			Name:            rate.Name,
+			UnitAmountMinor: taxes[i],
+			TaxInclusive:    rate.Inclusive,
+		})
+	}
+	return nil
+}
+
+// divRoundHalfUp divides two non-negative amounts, rounding halves up.
+func divRoundHalfUp(numerator, denominator int64) int64 {
+	return (numerator + denominator/2) / denominator
+}
@@ -1,6 +1,9 @@
 package services
 
 import (
+	"log"
+	"time"
+
 	"blytz.cloud/backend/internal/models"
 
 	"github.com/google/uuid"
@@ -17,10 +20,47 @@ func NewBookingService(db *gorm.DB) *BookingService {
 	}
 }
 
+// BookingOptions carries checkout choices that are not columns on the booking.
+type BookingOptions struct {
+	// HoldToken hands the slots of a checkout hold over to the booking.
+	HoldToken string
+	// AddOnIDs are extras priced on top of the service.
+	AddOnIDs []uuid.UUID
+	// VehicleClass selects a price variant of the service.
+	VehicleClass models.VehicleClass
+	// VehicleID names a known vehicle whose stored class selects the price.
+	VehicleID *uuid.UUID
+	// PromoCode is redeemed against the booking's total.
+	PromoCode string
+}
+
 func (s *BookingService) Create(booking *models.Booking) error {
-	return s.DB.Transaction(func(tx *gorm.DB) error {
+	return s.CreateWithOptions(booking, BookingOptions{})
+}
+
+// CreateWithHold creates a booking. When holdToken is set the booking takes
+// over the slots reserved by that checkout hold instead of reserving new ones.
+func (s *BookingService) CreateWithHold(booking *models.Booking, holdToken string) error {
+	return s.CreateWithOptions(booking, BookingOptions{HoldToken: holdToken})
+}
+
+// CreateWithOptions creates a booking, snapshotting the service (at its
+// vehicle-class price, when one is set), any add-ons, any promo discount and
+// the business's taxes into line items. The booking's totals are derived from
+// those lines, and enough contiguous slots are reserved to cover the service
+// and add-ons. When online deposits are enabled, a payment intent for the
+// deposit is opened once the booking is saved and the booking stays PENDING
+// until it is paid; if the provider cannot open one, the booking is cancelled
+// and the provider's error returned. It returns ErrPlanLimitReached once the
+// business has taken as many bookings this month as its plan allows.
+func (s *BookingService) CreateWithOptions(booking *models.Booking, opts BookingOptions) error {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkMonthlyBookingLimit(tx, booking.BusinessID, time.Now().UTC()); err != nil {
+			return err
+		}
+
 		var service models.Service
-		if err := tx.Where("id = ? AND business_id = ?", booking.ServiceID, booking.BusinessID).First(&service).Error; err != nil {
+		if err := tx.Where("id = ? AND business_id = ? AND archived_at IS NULL", booking.ServiceID, booking.BusinessID).First(&service).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				return ErrBadRequest
 			}
@@ -35,33 +75,253 @@ func (s *BookingService) Create(booking *models.Booking) error {
 			return err
 		}
 
-		reserveResult := tx.Model(&models.Slot{}).
-			Where("id = ? AND business_id = ? AND is_booked = ?", booking.SlotID, booking.BusinessID, false).
-			Update("is_booked", true)
-		if reserveResult.Error != nil {
-			return reserveResult.Error
+		vehicleClass, err := resolveVehicleClass(tx, booking.BusinessID, opts)
+		if err != nil {
+			return err
 		}
-		if reserveResult.RowsAffected == 0 {
-			return ErrConflict
+		quote, err := quoteBooking(tx, service, vehicleClass, opts.AddOnIDs)
+		if err != nil {
+			return err
+		}
+
+		run, err := findSlotRun(tx, slot, quote.DurationMin)
+		if err != nil {
+			return err
+		}
+		toReserve := run
+		if opts.HoldToken != "" {
+			held, err := consumeSlotHold(tx, booking, opts.HoldToken, time.Now().UTC())
+			if err != nil {
+				return err
+			}
+			// The hold covers the base service; add-ons may need more slots.
+			toReserve = slotsNotIn(run, held)
+		}
+		if err := reserveSlots(tx, booking.BusinessID, toReserve); err != nil {
+			return err
 		}
 
 		booking.ServiceName = service.Name
 		booking.SlotTime = slot.StartTime
-		booking.DepositPaidMinor = service.DepositAmountMinor
-		booking.TotalPriceMinor = service.TotalPriceMinor
-		booking.CurrencyCode = service.CurrencyCode
+		booking.DurationMin = quote.DurationMin
+		booking.VehicleID = opts.VehicleID
+		booking.VehicleClass = vehicleClass
+
+		var redemption *models.PromoRedemption
+		if opts.PromoCode != "" {
+			redemption, err = redeemPromoCode(tx, booking, opts.PromoCode, quote.totals().SubtotalMinor, time.Now().UTC())
+			if err != nil {
+				return err
+			}
+			promoID := redemption.PromoCodeID
+			booking.PromoCode = NormalizePromoCode(opts.PromoCode)
+			quote.addLine(models.BookingLineItem{
+				BusinessID:      booking.BusinessID,
+				Kind:            models.LineItemKindDiscount,
+				ReferenceID:     &promoID,
+				Name:            "Promo " + booking.PromoCode,
+				UnitAmountMinor: -redemption.DiscountMinor,
+			})
+		}
+
+		if err := applyTaxes(tx, booking.BusinessID, &quote); err != nil {
+			return err
+		}
+
+		totals := quote.totals()
+		booking.TotalPriceMinor = totals.TotalMinor
+		booking.DiscountMinor = totals.DiscountMinor
+		booking.TaxMinor = totals.TaxMinor
+		// Nothing is paid until the workshop records it in the payments ledger.
+		booking.DepositDueMinor = quote.DepositMinor
+		if booking.DepositDueMinor > booking.TotalPriceMinor {
+			booking.DepositDueMinor = booking.TotalPriceMinor
+		}
+		booking.DepositPaidMinor = 0
+		booking.CurrencyCode = quote.CurrencyCode
+
+		if err := tx.Omit("LineItems").Create(booking).Error; err != nil {
+			return err
+		}
+		for i := range quote.LineItems {
+			quote.LineItems[i].BookingID = booking.ID
+		}
+		if err := tx.Create(&quote.LineItems).Error; err != nil {
+			return err
+		}
+		booking.LineItems = quote.LineItems
+
+		if redemption != nil {
+			redemption.BookingID = booking.ID
+			if err := tx.Create(redemption).Error; err != nil {
+				return err
+			}
+		}
+
+		return linkBookingSlots(tx, booking, run)
+	})
+	if err != nil {
+		return err
+	}
+
+	if paymentProvider != nil && booking.DepositDueMinor > 0 {
+		return s.startDepositPayment(booking, time.Now().UTC())
+	}
+	return nil
+}
+
+// Reschedule moves a booking to another slot of the same business. The old
+// slots are released and the new run reserved in one transaction, so the
+// customer keeps their original time if any new slot has been taken. A slot
+// that has already started returns ErrBadRequest.
+func (s *BookingService) Reschedule(businessID, id, newSlotID uuid.UUID) (*models.Booking, error) {
+	var booking models.Booking
+	var freedSlotIDs []uuid.UUID
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND business_id = ?", id, businessID).First(&booking).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrNotFound
+			}
+			return err
+		}
+		if booking.Status != models.BookingStatusPending && booking.Status != models.BookingStatusConfirmed {
+			return ErrInvalidTransition
+		}
+		if booking.SlotID == newSlotID {
+			return ErrBadRequest
+		}
+
+		var newSlot models.Slot
+		if err := tx.Where("id = ? AND business_id = ?", newSlotID, businessID).First(&newSlot).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrBadRequest
+			}
+			return err
+		}
+		// A booking cannot be moved to a time that has already started.
+		if newSlot.StartTime.Before(time.Now().UTC()) {
+			return ErrBadRequest
+		}
 
-		if err := tx.Create(booking).Error; err != nil {
+		// Release first so a run overlapping the booking's own slots can be reserved.
+		previousSlotIDs, err := releaseBookingSlots(tx, &booking)
+		if err != nil {
 			return err
 		}
+		run, err := findSlotRun(tx, newSlot, booking.DurationMin)
+		if err != nil {
+			return err
+		}
+		if err := reserveSlots(tx, businessID, run); err != nil {
+			return err
+		}
+		if err := linkBookingSlots(tx, &booking, run); err != nil {
+			return err
+		}
+		freedSlotIDs = slotsOutsideRun(previousSlotIDs, run)
+
+		previousSlotID := booking.SlotID
+		previousSlotTime := booking.SlotTime
+		result := tx.Model(&models.Booking{}).
+			Where("id = ? AND business_id = ? AND slot_id = ?", booking.ID, businessID, previousSlotID).
+			Updates(map[string]interface{}{"slot_id": newSlot.ID, "slot_time": newSlot.StartTime})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrConflict
+		}
 
-		return nil
+		history := models.BookingHistory{
+			BookingID:        booking.ID,
+			BusinessID:       businessID,
+			Action:           models.BookingHistoryActionRescheduled,
+			PreviousSlotID:   &previousSlotID,
+			PreviousSlotTime: &previousSlotTime,
+		}
+		if err := tx.Create(&history).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("id = ?", booking.ID).Preload("LineItems").First(&booking).Error
 	})
+	if err != nil {
+		return nil, err
+	}
+	s.offerFreedSlots(businessID, freedSlotIDs)
+	return &booking, nil
+}
+
+// SelfServiceCancel cancels a booking on behalf of its customer while the
+// business's self-service window is still open.
+func (s *BookingService) SelfServiceCancel(id uuid.UUID, now time.Time) (*models.Booking, error) {
+	booking, _, err := s.selfServiceBooking(id, now)
+	if err != nil {
+		return nil, err
+	}
+	return s.UpdateStatus(booking.BusinessID, booking.ID, models.BookingStatusCancelled)
+}
+
+// SelfServiceReschedule moves a booking on behalf of its customer. Both the
+// current and the new slot must lie outside the self-service cutoff.
+func (s *BookingService) SelfServiceReschedule(id, newSlotID uuid.UUID, now time.Time) (*models.Booking, error) {
+	booking, policy, err := s.selfServiceBooking(id, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var newSlot models.Slot
+	if err := s.DB.Where("id = ? AND business_id = ?", newSlotID, booking.BusinessID).First(&newSlot).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrBadRequest
+		}
+		return nil, err
+	}
+	if !selfServiceOpen(policy, newSlot.StartTime, now) {
+		return nil, ErrBadRequest
+	}
+
+	return s.Reschedule(booking.BusinessID, booking.ID, newSlotID)
+}
+
+func (s *BookingService) selfServiceBooking(id uuid.UUID, now time.Time) (*models.Booking, models.BookingPolicy, error) {
+	var booking models.Booking
+	if err := s.DB.Where("id = ?", id).First(&booking).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, models.BookingPolicy{}, ErrNotFound
+		}
+		return nil, models.BookingPolicy{}, err
+	}
+
+	policy, err := loadBookingPolicy(s.DB, booking.BusinessID)
+	if err != nil {
+		return nil, models.BookingPolicy{}, err
+	}
+	if !selfServiceOpen(policy, booking.SlotTime, now) {
+		return nil, models.BookingPolicy{}, ErrForbidden
+	}
+	return &booking, policy, nil
+}
+
+func (s *BookingService) GetHistory(businessID, id uuid.UUID) ([]models.BookingHistory, error) {
+	var count int64
+	if err := s.DB.Model(&models.Booking{}).Where("id = ? AND business_id = ?", id, businessID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrNotFound
+	}
+
+	var history []models.BookingHistory
+	if err := s.DB.Where("booking_id = ? AND business_id = ?", id, businessID).Order("created_at ASC").Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
 }
 
 func (s *BookingService) GetByBusiness(businessID uuid.UUID) ([]models.Booking, error) {
 	var bookings []models.Booking
-	if err := s.DB.Where("business_id = ?", businessID).Order("created_at DESC").Find(&bookings).Error; err != nil {
+	if err := s.DB.Where("business_id = ?", businessID).Preload("LineItems").Order("created_at DESC").Find(&bookings).Error; err != nil {
 		return nil, err
 	}
 	return bookings, nil
@@ -69,7 +329,7 @@ func (s *BookingService) GetByBusiness(businessID uuid.UUID) ([]models.Booking,
 
 func (s *BookingService) GetByID(id uuid.UUID) (*models.Booking, error) {
 	var booking models.Booking
-	if err := s.DB.Where("id = ?", id).Preload("Service").Preload("Slot").First(&booking).Error; err != nil {
+	if err := s.DB.Where("id = ?", id).Preload("Service").Preload("Slot").Preload("LineItems").First(&booking).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, ErrNotFound
 		}
@@ -78,46 +338,177 @@ func (s *BookingService) GetByID(id uuid.UUID) (*models.Booking, error) {
 	return &booking, nil
 }
 
-func (s *BookingService) UpdateStatus(id uuid.UUID, status models.BookingStatus) error {
-	result := s.DB.Model(&models.Booking{}).Where("id = ?", id).Update("status", status)
-	if result.Error != nil {
-		return result.Error
+// bookingStatusTransitions lists the statuses each booking status may move to.
+// Terminal statuses have no entry.
+var bookingStatusTransitions = map[models.BookingStatus][]models.BookingStatus{
+	models.BookingStatusPending:   {models.BookingStatusConfirmed, models.BookingStatusCancelled},
+	models.BookingStatusConfirmed: {models.BookingStatusCompleted, models.BookingStatusCancelled, models.BookingStatusNoShow},
+}
+
+func canTransitionBooking(from, to models.BookingStatus) bool {
+	for _, allowed := range bookingStatusTransitions[from] {
+		if allowed == to {
+			return true
+		}
 	}
-	if result.RowsAffected == 0 {
-		return ErrNotFound
+	return false
+}
+
+func (s *BookingService) UpdateStatus(businessID, id uuid.UUID, status models.BookingStatus) (*models.Booking, error) {
+	return s.updateStatus(businessID, id, "", status)
+}
+
+// updateStatus moves a booking to status. A non-empty from restricts the move
+// to bookings currently in that status.
+func (s *BookingService) updateStatus(businessID, id uuid.UUID, from, status models.BookingStatus) (*models.Booking, error) {
+	var booking *models.Booking
+	var freedSlotIDs []uuid.UUID
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		booking, freedSlotIDs, err = transitionBooking(tx, businessID, id, from, status)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	s.offerFreedSlots(businessID, freedSlotIDs)
+	return booking, nil
 }
 
-func (s *BookingService) Cancel(id uuid.UUID) error {
-	// Get booking
+// transitionBooking applies a status change inside tx and returns the slots a
+// cancellation released. Callers offer those slots once tx has committed.
+func transitionBooking(tx *gorm.DB, businessID, id uuid.UUID, from, status models.BookingStatus) (*models.Booking, []uuid.UUID, error) {
 	var booking models.Booking
-	if err := s.DB.Where("id = ?", id).First(&booking).Error; err != nil {
+	if err := tx.Where("id = ? AND business_id = ?", id, businessID).First(&booking).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return ErrNotFound
+			return nil, nil, ErrNotFound
 		}
-		return err
+		return nil, nil, err
 	}
 
-	// Start transaction
-	tx := s.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+	if from != "" && booking.Status != from {
+		return nil, nil, ErrInvalidTransition
+	}
+	if !canTransitionBooking(booking.Status, status) {
+		return nil, nil, ErrInvalidTransition
+	}
+	// A customer cannot have missed an appointment that has not ended yet.
+	if status == models.BookingStatusNoShow {
+		end := booking.SlotTime.Add(time.Duration(booking.DurationMin) * time.Minute)
+		if time.Now().UTC().Before(end) {
+			return nil, nil, ErrInvalidTransition
 		}
-	}()
+	}
 
-	// Update booking status
-	if err := tx.Model(&booking).Update("status", models.BookingStatusCancelled).Error; err != nil {
-		tx.Rollback()
-		return err
+	updates := map[string]interface{}{"status": status}
+	if status == models.BookingStatusCancelled || status == models.BookingStatusNoShow {
+		policy, err := loadBookingPolicy(tx, businessID)
+		if err != nil {
+			return nil, nil, err
+		}
+		refundable, forfeited := splitDeposit(booking.DepositPaidMinor, policy.NoShowDepositKeepPct)
+		if status == models.BookingStatusCancelled {
+			refundable, forfeited = cancellationOutcome(policy, booking.DepositPaidMinor, booking.SlotTime, time.Now().UTC())
+		}
+		updates["refundable_minor"] = refundable
+		updates["forfeited_minor"] = forfeited
+	}
+
+	// Guard on the current status so a concurrent transition cannot be overwritten.
+	result := tx.Model(&models.Booking{}).
+		Where("id = ? AND business_id = ? AND status = ?", booking.ID, businessID, booking.Status).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil, ErrInvalidTransition
 	}
 
-	// Mark slot as available again
-	if err := tx.Model(&models.Slot{}).Where("id = ?", booking.SlotID).Update("is_booked", false).Error; err != nil {
-		tx.Rollback()
+	var freedSlotIDs []uuid.UUID
+	if status == models.BookingStatusCancelled {
+		var err error
+		if freedSlotIDs, err = releaseBookingSlots(tx, &booking); err != nil {
+			return nil, nil, err
+		}
+	}
+	if status == models.BookingStatusNoShow {
+		if err := recordCustomerNoShow(tx, &booking); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.Where("id = ?", booking.ID).Preload("LineItems").First(&booking).Error; err != nil {
+		return nil, nil, err
+	}
+	return &booking, freedSlotIDs, nil
+}
+
+// Cancel cancels a booking, releases its slots and records how much of the
+// deposit is refundable under the business's cancellation policy.
+func (s *BookingService) Cancel(businessID, id uuid.UUID) (*models.Booking, error) {
+	return s.UpdateStatus(businessID, id, models.BookingStatusCancelled)
+}
+
+// MarkNoShows flags CONFIRMED bookings whose slot ended more than grace ago
+// and that never had a job opened against them. It returns how many bookings
+// were marked.
+func (s *BookingService) MarkNoShows(now time.Time, grace time.Duration) (int, error) {
+	var candidates []models.Booking
+	if err := s.DB.
+		Where("status = ? AND slot_time < ?", models.BookingStatusConfirmed, now.Add(-grace)).
+		Where("NOT EXISTS (SELECT 1 FROM jobs WHERE jobs.booking_id = bookings.id)").
+		Find(&candidates).Error; err != nil {
+		return 0, err
+	}
+
+	marked := 0
+	for _, booking := range candidates {
+		end := booking.SlotTime.Add(time.Duration(booking.DurationMin) * time.Minute)
+		if !now.After(end.Add(grace)) {
+			continue
+		}
+		if _, err := s.UpdateStatus(booking.BusinessID, booking.ID, models.BookingStatusNoShow); err != nil {
+			// Another request moved the booking on since it was loaded.
+			if err == ErrInvalidTransition {
+				continue
+			}
+			return marked, err
+		}
+		marked++
+	}
+	return marked, nil
+}
+
+// recordCustomerNoShow bumps the no-show counter of the customer record that
+// matches the booking's email, creating the record for first-time customers.
+func recordCustomerNoShow(tx *gorm.DB, booking *models.Booking) error {
+	var customer models.Customer
+	err := tx.Where("business_id = ? AND LOWER(email) = LOWER(?)", booking.BusinessID, booking.Customer.Email).First(&customer).Error
+	if err == gorm.ErrRecordNotFound {
+		customer = models.Customer{
+			BusinessID:  booking.BusinessID,
+			Name:        booking.Customer.Name,
+			Email:       booking.Customer.Email,
+			Phone:       booking.Customer.Phone,
+			NoShowCount: 1,
+		}
+		return tx.Create(&customer).Error
+	}
+	if err != nil {
 		return err
 	}
+	return tx.Model(&models.Customer{}).Where("id = ?", customer.ID).
+		Update("no_show_count", gorm.Expr("no_show_count + 1")).Error
+}
 
-	return tx.Commit().Error
+// offerFreedSlots passes each slot released by a booking on to the waitlist.
+// The booking change has already committed, so a failure here is only logged.
+func (s *BookingService) offerFreedSlots(businessID uuid.UUID, slotIDs []uuid.UUID) {
+	waitlist := NewWaitlistService(s.DB)
+	for _, slotID := range slotIDs {
+		if _, err := waitlist.OfferSlot(businessID, slotID, time.Now().UTC()); err != nil {
+			log.Printf("Failed to offer slot %s to waitlist: %v", slotID, err)
+		}
+	}
 }
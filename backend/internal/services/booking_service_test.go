@@ -33,6 +33,57 @@ func setupBookingTestDB(t *testing.T) *gorm.DB {
 			created_at datetime,
 			updated_at datetime
 		)`,
+		`CREATE TABLE subscriptions (
+			id text PRIMARY KEY,
+			business_id text NOT NULL UNIQUE,
+			plan text NOT NULL,
+			status text NOT NULL,
+			current_period_end datetime NOT NULL,
+			canceled_at datetime,
+			last_event_at datetime,
+			created_at datetime,
+			updated_at datetime
+		)`,
+		`CREATE TABLE billing_events (
+			id text PRIMARY KEY,
+			provider text NOT NULL,
+			event_id text NOT NULL,
+			type text NOT NULL,
+			business_id text NOT NULL,
+			occurred_at datetime NOT NULL,
+			created_at datetime,
+			UNIQUE (provider, event_id)
+		)`,
+		`CREATE TABLE users (
+			id text PRIMARY KEY,
+			email text NOT NULL,
+			name text,
+			password_hash text NOT NULL,
+			token_version integer NOT NULL DEFAULT 1,
+			created_at datetime,
+			updated_at datetime
+		)`,
+		`CREATE TABLE invitations (
+			id text PRIMARY KEY,
+			business_id text NOT NULL,
+			email text NOT NULL,
+			role text NOT NULL,
+			invited_by_id text NOT NULL,
+			expires_at datetime NOT NULL,
+			accepted_at datetime,
+			revoked_at datetime,
+			created_at datetime,
+			updated_at datetime
+		)`,
+		`CREATE TABLE memberships (
+			id text PRIMARY KEY,
+			user_id text NOT NULL,
+			business_id text NOT NULL,
+			role text NOT NULL,
+			created_at datetime,
+			updated_at datetime,
+			UNIQUE (user_id, business_id)
+		)`,
 		`CREATE TABLE services (
 			id text PRIMARY KEY,
 			business_id text NOT NULL,
@@ -42,6 +93,7 @@ func setupBookingTestDB(t *testing.T) *gorm.DB {
 			total_price_minor integer NOT NULL,
 			deposit_amount_minor integer NOT NULL,
 			currency_code text NOT NULL,
+			archived_at datetime,
 			created_at datetime,
 			updated_at datetime
 		)`,
@@ -50,7 +102,8 @@ func setupBookingTestDB(t *testing.T) *gorm.DB {
 			business_id text NOT NULL,
 			start_time datetime NOT NULL,
 			end_time datetime NOT NULL,
-			is_booked numeric NOT NULL DEFAULT 0,
+			capacity integer NOT NULL DEFAULT 1,
+			booked_count integer NOT NULL DEFAULT 0,
 			created_at datetime,
 			updated_at datetime
 		)`,
@@ -61,13 +114,270 @@ func setupBookingTestDB(t *testing.T) *gorm.DB {
 			slot_id text NOT NULL,
 			service_name text NOT NULL,
 			slot_time datetime NOT NULL,
+			duration_min integer NOT NULL DEFAULT 0,
+			vehicle_id text,
+			vehicle_class text,
 			name text NOT NULL,
 			email text NOT NULL,
 			phone text NOT NULL,
 			status text NOT NULL,
+			deposit_due_minor integer NOT NULL DEFAULT 0,
 			deposit_paid_minor integer NOT NULL,
 			total_price_minor integer NOT NULL,
+			discount_minor integer NOT NULL DEFAULT 0,
+			tax_minor integer NOT NULL DEFAULT 0,
+			promo_code text,
+			currency_code text NOT NULL,
+			refundable_minor integer NOT NULL DEFAULT 0,
+			forfeited_minor integer NOT NULL DEFAULT 0,
+			created_at datetime,
+			updated_at datetime
+		)`,
+		`CREATE TABLE service_add_ons (
+			id text PRIMARY KEY,
+			business_id text NOT NULL,
+			service_id text NOT NULL,
+			name text NOT NULL,
+			price_minor integer NOT NULL DEFAULT 0,
+			extra_duration_min integer NOT NULL DEFAULT 0,
+			archived_at datetime,
+			created_at datetime,
+			updated_at datetime
+		)`,
+		`CREATE TABLE service_price_variants (
+			id text PRIMARY KEY,
+			business_id text NOT NULL,
+			service_id text NOT NULL,
+			vehicle_class text NOT NULL,
+			total_price_minor integer NOT NULL DEFAULT 0,
+			deposit_amount_minor integer NOT NULL DEFAULT 0,
+			created_at datetime,
+			updated_at datetime,
+			UNIQUE (service_id, vehicle_class)
+		)`,
+		`CREATE TABLE invoice_sequences (
+			business_id text PRIMARY KEY,
+			last_number integer NOT NULL DEFAULT 0
+		)`,
+		`CREATE TABLE invoices (
+			id text PRIMARY KEY,
+			business_id text NOT NULL,
+			number integer,
+			booking_id text NOT NULL,
+			job_id text,
+			status text NOT NULL DEFAULT 'DRAFT',
+			reference text,
+			customer_name text NOT NULL,
+			customer_email text NOT NULL,
+			currency_code text NOT NULL,
+			subtotal_minor integer NOT NULL DEFAULT 0,
+			discount_minor integer NOT NULL DEFAULT 0,
+			tax_minor integer NOT NULL DEFAULT 0,
+			total_minor integer NOT NULL DEFAULT 0,
+			deposit_credit_minor integer NOT NULL DEFAULT 0,
+			amount_due_minor integer NOT NULL DEFAULT 0,
+			issued_at datetime,
+			paid_at datetime,
+			voided_at datetime,
+			created_at datetime,
+			updated_at datetime,
+			UNIQUE (business_id, number)
+		)`,
+		`CREATE TABLE invoice_lines (
+			id text PRIMARY KEY,
+			invoice_id text NOT NULL,
+			position integer NOT NULL,
+			kind text NOT NULL,
+			description text NOT NULL,
+			quantity integer NOT NULL DEFAULT 1,
+			unit_amount_minor integer NOT NULL DEFAULT 0,
+			amount_minor integer NOT NULL DEFAULT 0,
+			tax_inclusive boolean NOT NULL DEFAULT false
+		)`,
+		`CREATE TABLE payment_records (
+			id text PRIMARY KEY,
+			business_id text NOT NULL,
+			booking_id text NOT NULL,
+			invoice_id text,
+			kind text NOT NULL,
+			method text NOT NULL,
+			amount_minor integer NOT NULL,
+			currency_code text NOT NULL,
+			note text,
+			recorded_at datetime NOT NULL,
+			recorded_by_id text,
+			created_at datetime
+		)`,
+		`CREATE TABLE payment_intents (
+			id text PRIMARY KEY,
+			business_id text NOT NULL,
+			booking_id text NOT NULL,
+			provider text NOT NULL,
+			provider_intent_id text NOT NULL UNIQUE,
+			client_secret text NOT NULL,
+			amount_minor integer NOT NULL,
 			currency_code text NOT NULL,
+			status text NOT NULL DEFAULT 'PENDING',
+			expires_at datetime NOT NULL,
+			created_at datetime,
+			updated_at datetime
+		)`,
+		`CREATE TABLE tax_rates (
+			id text PRIMARY KEY,
+			business_id text NOT NULL,
+			name text NOT NULL,
+			rate_basis_points integer NOT NULL,
+			inclusive boolean NOT NULL DEFAULT false,
+			created_at datetime,
+			updated_at datetime
+		)`,
+		`CREATE TABLE promo_codes (
+			id text PRIMARY KEY,
+			business_id text NOT NULL,
+			code text NOT NULL,
+			discount_type text NOT NULL,
+			percent_off integer NOT NULL DEFAULT 0,
+			amount_off_minor integer NOT NULL DEFAULT 0,
+			valid_from datetime,
+			valid_until datetime,
+			max_redemptions integer NOT NULL DEFAULT 0,
+			per_customer_limit integer NOT NULL DEFAULT 0,
+			redemption_count integer NOT NULL DEFAULT 0,
+			archived_at datetime,
+			created_at datetime,
+			updated_at datetime,
+			UNIQUE (business_id, code)
+		)`,
+		`CREATE TABLE promo_code_restrictions (
+			id text PRIMARY KEY,
+			promo_code_id text NOT NULL,
+			service_id text NOT NULL
+		)`,
+		`CREATE TABLE promo_redemptions (
+			id text PRIMARY KEY,
+			promo_code_id text NOT NULL,
+			business_id text NOT NULL,
+			booking_id text NOT NULL UNIQUE,
+			customer_email text NOT NULL,
+			discount_minor integer NOT NULL DEFAULT 0,
+			created_at datetime
+		)`,
+		`CREATE TABLE vehicles (
+			id text PRIMARY KEY,
+			business_id text NOT NULL,
+			customer_id text NOT NULL,
+			year integer,
+			make text NOT NULL,
+			model text NOT NULL,
+			color text,
+			license_plate text,
+			class text,
+			created_at datetime,
+			updated_at datetime
+		)`,
+		`CREATE TABLE booking_line_items (
+			id text PRIMARY KEY,
+			booking_id text NOT NULL,
+			business_id text NOT NULL,
+			kind text NOT NULL,
+			reference_id text,
+			name text NOT NULL,
+			quantity integer NOT NULL DEFAULT 1,
+			unit_amount_minor integer NOT NULL DEFAULT 0,
+			amount_minor integer NOT NULL DEFAULT 0,
+			currency_code text NOT NULL DEFAULT 'USD',
+			tax_inclusive boolean NOT NULL DEFAULT false,
+			duration_min integer NOT NULL DEFAULT 0,
+			created_at datetime
+		)`,
+		`CREATE TABLE booking_slots (
+			id text PRIMARY KEY,
+			booking_id text NOT NULL,
+			slot_id text NOT NULL,
+			business_id text NOT NULL,
+			created_at datetime
+		)`,
+		`CREATE TABLE slot_holds (
+			id text PRIMARY KEY,
+			business_id text NOT NULL,
+			service_id text NOT NULL,
+			slot_id text NOT NULL,
+			token_hash text NOT NULL UNIQUE,
+			status text NOT NULL,
+			expires_at datetime NOT NULL,
+			created_at datetime,
+			updated_at datetime
+		)`,
+		`CREATE TABLE slot_hold_slots (
+			id text PRIMARY KEY,
+			hold_id text NOT NULL,
+			slot_id text NOT NULL
+		)`,
+		`CREATE TABLE waitlist_entries (
+			id text PRIMARY KEY,
+			business_id text NOT NULL,
+			service_id text NOT NULL,
+			preferred_from datetime NOT NULL,
+			preferred_to datetime NOT NULL,
+			name text NOT NULL,
+			email text NOT NULL,
+			phone text NOT NULL,
+			status text NOT NULL,
+			offer_hold_id text,
+			offer_expires_at datetime,
+			created_at datetime,
+			updated_at datetime
+		)`,
+		`CREATE TABLE booking_histories (
+			id text PRIMARY KEY,
+			booking_id text NOT NULL,
+			business_id text NOT NULL,
+			action text NOT NULL,
+			previous_slot_id text,
+			previous_slot_time datetime,
+			created_at datetime
+		)`,
+		`CREATE TABLE customers (
+			id text PRIMARY KEY,
+			business_id text NOT NULL,
+			name text NOT NULL,
+			email text NOT NULL,
+			phone text NOT NULL,
+			notes text,
+			no_show_count integer NOT NULL DEFAULT 0,
+			created_at datetime,
+			updated_at datetime
+		)`,
+		`CREATE TABLE jobs (
+			id text PRIMARY KEY,
+			business_id text NOT NULL,
+			customer_id text NOT NULL,
+			vehicle_id text NOT NULL,
+			booking_id text,
+			title text NOT NULL,
+			status text NOT NULL,
+			scheduled_at datetime NOT NULL,
+			notes text,
+			created_at datetime,
+			updated_at datetime
+		)`,
+		`CREATE TABLE job_line_items (
+			id text PRIMARY KEY,
+			job_id text NOT NULL,
+			business_id text NOT NULL,
+			name text NOT NULL,
+			quantity integer NOT NULL DEFAULT 1,
+			unit_amount_minor integer NOT NULL DEFAULT 0,
+			amount_minor integer NOT NULL DEFAULT 0,
+			created_at datetime
+		)`,
+		`CREATE TABLE booking_policies (
+			id text PRIMARY KEY,
+			business_id text NOT NULL UNIQUE,
+			self_service_cutoff_hours integer NOT NULL DEFAULT 24,
+			free_cancel_notice_hours integer NOT NULL DEFAULT 24,
+			late_cancel_deposit_keep_pct integer NOT NULL DEFAULT 100,
+			no_show_deposit_keep_pct integer NOT NULL DEFAULT 100,
 			created_at datetime,
 			updated_at datetime
 		)`,
@@ -117,7 +427,7 @@ func seedBookingTestRecords(t *testing.T, db *gorm.DB) (models.Business, models.
 		BusinessID: business.ID,
 		StartTime:  now,
 		EndTime:    now.Add(2 * time.Hour),
-		IsBooked:   false,
+		Capacity:   1,
 	}
 	if err := db.Create(&slot).Error; err != nil {
 		t.Fatalf("create slot: %v", err)
@@ -146,8 +456,11 @@ func TestBookingServiceCreateMarksSlotBookedAndCopiesMoneyFields(t *testing.T) {
 		t.Fatalf("create booking: %v", err)
 	}
 
-	if booking.DepositPaidMinor != service.DepositAmountMinor {
-		t.Fatalf("expected deposit_paid_minor %d, got %d", service.DepositAmountMinor, booking.DepositPaidMinor)
+	if booking.DepositDueMinor != service.DepositAmountMinor {
+		t.Fatalf("expected deposit_due_minor %d, got %d", service.DepositAmountMinor, booking.DepositDueMinor)
+	}
+	if booking.DepositPaidMinor != 0 {
+		t.Fatalf("expected no deposit paid before a payment is recorded, got %d", booking.DepositPaidMinor)
 	}
 	if booking.TotalPriceMinor != service.TotalPriceMinor {
 		t.Fatalf("expected total_price_minor %d, got %d", service.TotalPriceMinor, booking.TotalPriceMinor)
@@ -160,8 +473,8 @@ func TestBookingServiceCreateMarksSlotBookedAndCopiesMoneyFields(t *testing.T) {
 	if err := db.First(&persistedSlot, "id = ?", slot.ID).Error; err != nil {
 		t.Fatalf("reload slot: %v", err)
 	}
-	if !persistedSlot.IsBooked {
-		t.Fatal("expected slot to be marked booked")
+	if persistedSlot.BookedCount != 1 {
+		t.Fatalf("expected slot booked_count 1, got %d", persistedSlot.BookedCount)
 	}
 }
 
@@ -200,3 +513,870 @@ func TestBookingServiceCreateReturnsConflictWhenSlotAlreadyBooked(t *testing.T)
 		t.Fatalf("expected 1 persisted booking, got %d", bookingCount)
 	}
 }
+
+func TestBookingServiceUpdateStatusEnforcesTransitions(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, slot := seedBookingTestRecords(t, db)
+	bookingService := NewBookingService(db)
+
+	booking := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slot.ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.Create(booking); err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+
+	if _, err := bookingService.UpdateStatus(business.ID, booking.ID, models.BookingStatusCompleted); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition completing pending booking, got %v", err)
+	}
+	if _, err := bookingService.UpdateStatus(uuid.New(), booking.ID, models.BookingStatusConfirmed); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for foreign business, got %v", err)
+	}
+
+	updated, err := bookingService.UpdateStatus(business.ID, booking.ID, models.BookingStatusConfirmed)
+	if err != nil {
+		t.Fatalf("confirm booking: %v", err)
+	}
+	if updated.Status != models.BookingStatusConfirmed {
+		t.Fatalf("expected status CONFIRMED, got %s", updated.Status)
+	}
+
+	if _, err := bookingService.UpdateStatus(business.ID, booking.ID, models.BookingStatusPending); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition moving back to pending, got %v", err)
+	}
+}
+
+func TestBookingServiceCancelReleasesSlot(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, slot := seedBookingTestRecords(t, db)
+	bookingService := NewBookingService(db)
+
+	booking := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slot.ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.Create(booking); err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+
+	cancelled, err := bookingService.Cancel(business.ID, booking.ID)
+	if err != nil {
+		t.Fatalf("cancel booking: %v", err)
+	}
+	if cancelled.Status != models.BookingStatusCancelled {
+		t.Fatalf("expected status CANCELLED, got %s", cancelled.Status)
+	}
+
+	var persistedSlot models.Slot
+	if err := db.First(&persistedSlot, "id = ?", slot.ID).Error; err != nil {
+		t.Fatalf("reload slot: %v", err)
+	}
+	if persistedSlot.BookedCount != 0 {
+		t.Fatal("expected slot to be released")
+	}
+
+	if _, err := bookingService.Cancel(business.ID, booking.ID); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition cancelling twice, got %v", err)
+	}
+}
+
+func TestBookingServiceRescheduleSwapsSlotsAndRecordsHistory(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, slot := seedBookingTestRecords(t, db)
+	bookingService := NewBookingService(db)
+
+	newSlot := models.Slot{
+		ID:         uuid.New(),
+		BusinessID: business.ID,
+		StartTime:  slot.StartTime.Add(24 * time.Hour),
+		EndTime:    slot.EndTime.Add(24 * time.Hour),
+	}
+	if err := db.Create(&newSlot).Error; err != nil {
+		t.Fatalf("create new slot: %v", err)
+	}
+
+	booking := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slot.ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.Create(booking); err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+
+	pastSlot := models.Slot{
+		ID:         uuid.New(),
+		BusinessID: business.ID,
+		StartTime:  slot.StartTime.Add(-24 * time.Hour),
+		EndTime:    slot.EndTime.Add(-24 * time.Hour),
+	}
+	if err := db.Create(&pastSlot).Error; err != nil {
+		t.Fatalf("create past slot: %v", err)
+	}
+	if _, err := bookingService.Reschedule(business.ID, booking.ID, pastSlot.ID); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("expected moving into a past slot to be rejected, got %v", err)
+	}
+
+	rescheduled, err := bookingService.Reschedule(business.ID, booking.ID, newSlot.ID)
+	if err != nil {
+		t.Fatalf("reschedule booking: %v", err)
+	}
+	if rescheduled.SlotID != newSlot.ID {
+		t.Fatalf("expected slot %s, got %s", newSlot.ID, rescheduled.SlotID)
+	}
+	if !rescheduled.SlotTime.Equal(newSlot.StartTime) {
+		t.Fatalf("expected slot time %s, got %s", newSlot.StartTime, rescheduled.SlotTime)
+	}
+
+	var oldSlot, reservedSlot models.Slot
+	if err := db.First(&oldSlot, "id = ?", slot.ID).Error; err != nil {
+		t.Fatalf("reload old slot: %v", err)
+	}
+	if err := db.First(&reservedSlot, "id = ?", newSlot.ID).Error; err != nil {
+		t.Fatalf("reload new slot: %v", err)
+	}
+	if oldSlot.BookedCount != 0 {
+		t.Fatal("expected old slot to be released")
+	}
+	if reservedSlot.BookedCount != 1 {
+		t.Fatal("expected new slot to be booked")
+	}
+
+	history, err := bookingService.GetHistory(business.ID, booking.ID)
+	if err != nil {
+		t.Fatalf("load history: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("expected 1 history entry, got %d", len(history))
+	}
+	if history[0].PreviousSlotTime == nil || !history[0].PreviousSlotTime.Equal(slot.StartTime) {
+		t.Fatalf("expected previous slot time %s, got %v", slot.StartTime, history[0].PreviousSlotTime)
+	}
+}
+
+func TestBookingServiceRescheduleReturnsConflictWhenNewSlotTaken(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, slot := seedBookingTestRecords(t, db)
+	bookingService := NewBookingService(db)
+
+	takenSlot := models.Slot{
+		ID:         uuid.New(),
+		BusinessID: business.ID,
+		StartTime:  slot.StartTime.Add(24 * time.Hour),
+		EndTime:    slot.EndTime.Add(24 * time.Hour),
+	}
+	if err := db.Create(&takenSlot).Error; err != nil {
+		t.Fatalf("create taken slot: %v", err)
+	}
+
+	booking := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slot.ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.Create(booking); err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+	other := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     takenSlot.ID,
+		Customer:   models.CustomerDetails{Name: "Bob", Email: "bob@example.com", Phone: "555-0102"},
+	}
+	if err := bookingService.Create(other); err != nil {
+		t.Fatalf("create other booking: %v", err)
+	}
+
+	if _, err := bookingService.Reschedule(business.ID, booking.ID, takenSlot.ID); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+
+	var originalSlot models.Slot
+	if err := db.First(&originalSlot, "id = ?", slot.ID).Error; err != nil {
+		t.Fatalf("reload original slot: %v", err)
+	}
+	if originalSlot.BookedCount != 1 {
+		t.Fatal("expected original slot to stay booked after failed reschedule")
+	}
+}
+
+func seedConsecutiveSlots(t *testing.T, db *gorm.DB, business models.Business, start time.Time, count int, length time.Duration) []models.Slot {
+	t.Helper()
+
+	slots := make([]models.Slot, count)
+	for i := range slots {
+		slots[i] = models.Slot{
+			ID:         uuid.New(),
+			BusinessID: business.ID,
+			StartTime:  start.Add(time.Duration(i) * length),
+			EndTime:    start.Add(time.Duration(i+1) * length),
+			Capacity:   1,
+		}
+		if err := db.Create(&slots[i]).Error; err != nil {
+			t.Fatalf("create slot: %v", err)
+		}
+	}
+	return slots
+}
+
+// recordDepositPayment records the booking's deposit in the payments ledger.
+func recordDepositPayment(t *testing.T, db *gorm.DB, booking *models.Booking) {
+	t.Helper()
+	staffID := uuid.New()
+	if _, err := NewPaymentService(db).Record(&models.PaymentRecord{
+		BusinessID:   booking.BusinessID,
+		BookingID:    booking.ID,
+		Kind:         models.PaymentKindPayment,
+		Method:       models.PaymentMethodCard,
+		AmountMinor:  booking.DepositDueMinor,
+		RecordedByID: &staffID,
+	}, time.Now().UTC()); err != nil {
+		t.Fatalf("record deposit payment: %v", err)
+	}
+}
+
+func TestBookingServiceCreateReservesEverySlotCoveringDuration(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, _ := seedBookingTestRecords(t, db)
+	if err := db.Model(&models.Service{}).Where("id = ?", service.ID).Update("duration_min", 360).Error; err != nil {
+		t.Fatalf("update service duration: %v", err)
+	}
+	slots := seedConsecutiveSlots(t, db, business, time.Date(2030, time.January, 7, 9, 0, 0, 0, time.UTC), 4, 2*time.Hour)
+	bookingService := NewBookingService(db)
+
+	booking := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slots[0].ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.Create(booking); err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+	if booking.DurationMin != 360 {
+		t.Fatalf("expected duration 360, got %d", booking.DurationMin)
+	}
+
+	for i, slot := range slots {
+		var persisted models.Slot
+		if err := db.First(&persisted, "id = ?", slot.ID).Error; err != nil {
+			t.Fatalf("reload slot: %v", err)
+		}
+		if expected := i < 3; (persisted.BookedCount == 1) != expected {
+			t.Fatalf("slot %d: expected booked=%t, got booked_count %d", i, expected, persisted.BookedCount)
+		}
+	}
+
+	if _, err := bookingService.Cancel(business.ID, booking.ID); err != nil {
+		t.Fatalf("cancel booking: %v", err)
+	}
+	var stillBooked int64
+	if err := db.Model(&models.Slot{}).Where("business_id = ? AND booked_count > 0", business.ID).Count(&stillBooked).Error; err != nil {
+		t.Fatalf("count booked slots: %v", err)
+	}
+	if stillBooked != 0 {
+		t.Fatalf("expected cancel to release every slot, %d still booked", stillBooked)
+	}
+}
+
+func TestBookingServiceCreateIsAllOrNothingAcrossSlots(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, _ := seedBookingTestRecords(t, db)
+	if err := db.Model(&models.Service{}).Where("id = ?", service.ID).Update("duration_min", 360).Error; err != nil {
+		t.Fatalf("update service duration: %v", err)
+	}
+	slots := seedConsecutiveSlots(t, db, business, time.Date(2030, time.January, 7, 9, 0, 0, 0, time.UTC), 3, 2*time.Hour)
+	if err := db.Model(&models.Slot{}).Where("id = ?", slots[1].ID).Update("booked_count", 1).Error; err != nil {
+		t.Fatalf("book middle slot: %v", err)
+	}
+	bookingService := NewBookingService(db)
+
+	booking := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slots[0].ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.Create(booking); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+
+	var first models.Slot
+	if err := db.First(&first, "id = ?", slots[0].ID).Error; err != nil {
+		t.Fatalf("reload first slot: %v", err)
+	}
+	if first.BookedCount != 0 {
+		t.Fatal("expected first slot to be rolled back")
+	}
+
+	available, err := NewSlotService(db).GetAvailableForDuration(business.ID, 360)
+	if err != nil {
+		t.Fatalf("list fitting slots: %v", err)
+	}
+	for _, slot := range available {
+		if slot.ID == slots[0].ID {
+			t.Fatal("expected slot without room for the full service to be filtered out")
+		}
+	}
+}
+
+func TestBookingServiceCreateFillsSlotCapacityBeforeConflicting(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, slot := seedBookingTestRecords(t, db)
+	if err := db.Model(&models.Slot{}).Where("id = ?", slot.ID).Update("capacity", 3).Error; err != nil {
+		t.Fatalf("update slot capacity: %v", err)
+	}
+	bookingService := NewBookingService(db)
+
+	for i := 0; i < 3; i++ {
+		booking := &models.Booking{
+			BusinessID: business.ID,
+			ServiceID:  service.ID,
+			SlotID:     slot.ID,
+			Customer:   models.CustomerDetails{Name: fmt.Sprintf("Customer %d", i), Email: "bay@example.com", Phone: "555-0101"},
+		}
+		if err := bookingService.Create(booking); err != nil {
+			t.Fatalf("create booking %d: %v", i, err)
+		}
+	}
+
+	overflow := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slot.ID,
+		Customer:   models.CustomerDetails{Name: "Overflow", Email: "overflow@example.com", Phone: "555-0199"},
+	}
+	if err := bookingService.Create(overflow); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict once every bay is taken, got %v", err)
+	}
+
+	available, err := NewSlotService(db).GetAvailableByBusiness(business.ID)
+	if err != nil {
+		t.Fatalf("list available slots: %v", err)
+	}
+	if len(available) != 0 {
+		t.Fatalf("expected full slot to be hidden from availability, got %d slots", len(available))
+	}
+}
+
+func TestBookingServiceSelfServiceCancelRespectsPolicyCutoff(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, slot := seedBookingTestRecords(t, db)
+	bookingService := NewBookingService(db)
+
+	booking := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slot.ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.Create(booking); err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+
+	insideDefaultCutoff := slot.StartTime.Add(-23 * time.Hour)
+	if _, err := bookingService.SelfServiceCancel(booking.ID, insideDefaultCutoff); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected ErrForbidden inside the default 24h cutoff, got %v", err)
+	}
+
+	if _, err := NewBookingPolicyService(db).Update(business.ID, &models.BookingPolicy{SelfServiceCutoffHours: 2}); err != nil {
+		t.Fatalf("update booking policy: %v", err)
+	}
+
+	cancelled, err := bookingService.SelfServiceCancel(booking.ID, insideDefaultCutoff)
+	if err != nil {
+		t.Fatalf("self-service cancel: %v", err)
+	}
+	if cancelled.Status != models.BookingStatusCancelled {
+		t.Fatalf("expected status CANCELLED, got %s", cancelled.Status)
+	}
+}
+
+func TestBookingServiceCancelAppliesDepositPolicy(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, slot := seedBookingTestRecords(t, db)
+	bookingService := NewBookingService(db)
+
+	if _, err := NewBookingPolicyService(db).Update(business.ID, &models.BookingPolicy{
+		SelfServiceCutoffHours:   24,
+		FreeCancelNoticeHours:    24,
+		LateCancelDepositKeepPct: 33,
+		NoShowDepositKeepPct:     100,
+	}); err != nil {
+		t.Fatalf("update booking policy: %v", err)
+	}
+
+	late := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slot.ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.Create(late); err != nil {
+		t.Fatalf("create late booking: %v", err)
+	}
+	recordDepositPayment(t, db, late)
+	cancelled, err := bookingService.Cancel(business.ID, late.ID)
+	if err != nil {
+		t.Fatalf("cancel late booking: %v", err)
+	}
+	// 33% of 5000 is 1650 kept; the remainder is refundable.
+	if cancelled.ForfeitedMinor != 1650 || cancelled.RefundableMinor != 3350 {
+		t.Fatalf("expected 1650 forfeited and 3350 refundable, got %d and %d", cancelled.ForfeitedMinor, cancelled.RefundableMinor)
+	}
+
+	future := seedConsecutiveSlots(t, db, business, slot.StartTime.Add(72*time.Hour), 1, 2*time.Hour)
+	early := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     future[0].ID,
+		Customer:   models.CustomerDetails{Name: "Bob", Email: "bob@example.com", Phone: "555-0202"},
+	}
+	if err := bookingService.Create(early); err != nil {
+		t.Fatalf("create early booking: %v", err)
+	}
+	recordDepositPayment(t, db, early)
+	cancelled, err = bookingService.Cancel(business.ID, early.ID)
+	if err != nil {
+		t.Fatalf("cancel early booking: %v", err)
+	}
+	if cancelled.ForfeitedMinor != 0 || cancelled.RefundableMinor != 5000 {
+		t.Fatalf("expected full refund with enough notice, got %d forfeited and %d refundable", cancelled.ForfeitedMinor, cancelled.RefundableMinor)
+	}
+}
+
+func TestBookingServiceRejectsNoShowBeforeSlotEnds(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, slot := seedBookingTestRecords(t, db)
+	bookingService := NewBookingService(db)
+
+	booking := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slot.ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.Create(booking); err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+	if _, err := bookingService.UpdateStatus(business.ID, booking.ID, models.BookingStatusConfirmed); err != nil {
+		t.Fatalf("confirm booking: %v", err)
+	}
+	if _, err := bookingService.UpdateStatus(business.ID, booking.ID, models.BookingStatusNoShow); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected NO_SHOW before the slot ends to be rejected, got %v", err)
+	}
+
+	if err := db.Model(&models.Booking{}).Where("id = ?", booking.ID).
+		Update("slot_time", time.Now().UTC().Add(-3*time.Hour)).Error; err != nil {
+		t.Fatalf("move booking into the past: %v", err)
+	}
+	updated, err := bookingService.UpdateStatus(business.ID, booking.ID, models.BookingStatusNoShow)
+	if err != nil {
+		t.Fatalf("mark no-show: %v", err)
+	}
+	if updated.Status != models.BookingStatusNoShow {
+		t.Fatalf("expected status NO_SHOW, got %s", updated.Status)
+	}
+}
+
+func TestBookingServiceMarkNoShowsSkipsBookingsWithJobs(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, _ := seedBookingTestRecords(t, db)
+	bookingService := NewBookingService(db)
+
+	past := time.Now().UTC().Add(-6 * time.Hour).Truncate(time.Second)
+	slots := seedConsecutiveSlots(t, db, business, past, 4, time.Hour)
+
+	existing := models.Customer{BusinessID: business.ID, Name: "Alice", Email: "alice@example.com", Phone: "555-0101", NoShowCount: 2}
+	if err := db.Create(&existing).Error; err != nil {
+		t.Fatalf("create customer: %v", err)
+	}
+
+	create := func(slot models.Slot, email string) *models.Booking {
+		booking := &models.Booking{
+			BusinessID: business.ID,
+			ServiceID:  service.ID,
+			SlotID:     slot.ID,
+			Customer:   models.CustomerDetails{Name: "Customer", Email: email, Phone: "555-0000"},
+		}
+		if err := bookingService.Create(booking); err != nil {
+			t.Fatalf("create booking: %v", err)
+		}
+		recordDepositPayment(t, db, booking)
+		if _, err := bookingService.UpdateStatus(business.ID, booking.ID, models.BookingStatusConfirmed); err != nil {
+			t.Fatalf("confirm booking: %v", err)
+		}
+		return booking
+	}
+	missed := create(slots[0], "ALICE@example.com")
+	attended := create(slots[2], "bob@example.com")
+
+	job := models.Job{BusinessID: business.ID, CustomerID: existing.ID, VehicleID: uuid.New(), BookingID: &attended.ID, Title: "Detail", ScheduledAt: past}
+	if err := db.Create(&job).Error; err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+
+	marked, err := bookingService.MarkNoShows(time.Now().UTC(), time.Hour)
+	if err != nil {
+		t.Fatalf("mark no-shows: %v", err)
+	}
+	if marked != 1 {
+		t.Fatalf("expected 1 booking marked, got %d", marked)
+	}
+
+	var reloaded models.Booking
+	if err := db.First(&reloaded, "id = ?", missed.ID).Error; err != nil {
+		t.Fatalf("reload booking: %v", err)
+	}
+	if reloaded.Status != models.BookingStatusNoShow {
+		t.Fatalf("expected NO_SHOW, got %s", reloaded.Status)
+	}
+	if reloaded.ForfeitedMinor != service.DepositAmountMinor {
+		t.Fatalf("expected the whole deposit forfeited, got %d", reloaded.ForfeitedMinor)
+	}
+
+	if err := db.First(&existing, "id = ?", existing.ID).Error; err != nil {
+		t.Fatalf("reload customer: %v", err)
+	}
+	if existing.NoShowCount != 3 {
+		t.Fatalf("expected no-show count 3, got %d", existing.NoShowCount)
+	}
+}
+
+func TestBookingServiceCreateSnapshotsAddOnsIntoLineItems(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, _ := seedBookingTestRecords(t, db)
+	bookingService := NewBookingService(db)
+	addOnService := NewAddOnService(db)
+
+	petHair := models.ServiceAddOn{BusinessID: business.ID, ServiceID: service.ID, Name: "Pet hair removal", PriceMinor: 3000, ExtraDurationMin: 60}
+	if err := addOnService.Create(&petHair); err != nil {
+		t.Fatalf("create add-on: %v", err)
+	}
+
+	otherService := models.Service{ID: uuid.New(), BusinessID: business.ID, Name: "Window Tint", DurationMin: 60, TotalPriceMinor: 10000, CurrencyCode: "USD"}
+	if err := db.Create(&otherService).Error; err != nil {
+		t.Fatalf("create other service: %v", err)
+	}
+	foreign := models.ServiceAddOn{BusinessID: business.ID, ServiceID: otherService.ID, Name: "Headlight film", PriceMinor: 2000}
+	if err := addOnService.Create(&foreign); err != nil {
+		t.Fatalf("create foreign add-on: %v", err)
+	}
+
+	slots := seedConsecutiveSlots(t, db, business, time.Now().UTC().Add(24*time.Hour).Truncate(time.Hour), 3, time.Hour)
+
+	rejected := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slots[0].ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.CreateWithOptions(rejected, BookingOptions{AddOnIDs: []uuid.UUID{foreign.ID}}); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("expected add-on of another service to be rejected, got %v", err)
+	}
+
+	booking := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slots[0].ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.CreateWithOptions(booking, BookingOptions{AddOnIDs: []uuid.UUID{petHair.ID}}); err != nil {
+		t.Fatalf("create booking with add-on: %v", err)
+	}
+
+	if booking.TotalPriceMinor != 23000 {
+		t.Fatalf("expected total 23000, got %d", booking.TotalPriceMinor)
+	}
+	if booking.DurationMin != 180 {
+		t.Fatalf("expected duration 180, got %d", booking.DurationMin)
+	}
+	if booking.DepositDueMinor != service.DepositAmountMinor {
+		t.Fatalf("expected deposit to stay %d, got %d", service.DepositAmountMinor, booking.DepositDueMinor)
+	}
+
+	var items []models.BookingLineItem
+	if err := db.Where("booking_id = ?", booking.ID).Order("kind DESC").Find(&items).Error; err != nil {
+		t.Fatalf("load line items: %v", err)
+	}
+	if len(items) != 2 || items[0].Kind != models.LineItemKindService || items[1].Name != "Pet hair removal" || items[1].AmountMinor != 3000 {
+		t.Fatalf("unexpected line items: %+v", items)
+	}
+
+	for _, slot := range slots {
+		var persisted models.Slot
+		if err := db.First(&persisted, "id = ?", slot.ID).Error; err != nil {
+			t.Fatalf("reload slot: %v", err)
+		}
+		if persisted.BookedCount != 1 {
+			t.Fatalf("expected slot at %s to be reserved for the longer booking", slot.StartTime)
+		}
+	}
+}
+
+func TestBookingServiceCreatePricesByVehicleClass(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, slot := seedBookingTestRecords(t, db)
+	bookingService := NewBookingService(db)
+	variantService := NewPriceVariantService(db)
+
+	if _, err := variantService.Upsert(&models.ServicePriceVariant{BusinessID: business.ID, ServiceID: service.ID, VehicleClass: models.VehicleClassSUV, TotalPriceMinor: 26000, DepositAmountMinor: 30000}); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("expected deposit above the variant price to be rejected, got %v", err)
+	}
+	if _, err := variantService.Upsert(&models.ServicePriceVariant{BusinessID: business.ID, ServiceID: service.ID, VehicleClass: models.VehicleClassSUV, TotalPriceMinor: 25000, DepositAmountMinor: 6000}); err != nil {
+		t.Fatalf("create SUV variant: %v", err)
+	}
+	variant, err := variantService.Upsert(&models.ServicePriceVariant{BusinessID: business.ID, ServiceID: service.ID, VehicleClass: models.VehicleClassSUV, TotalPriceMinor: 26000, DepositAmountMinor: 7000})
+	if err != nil {
+		t.Fatalf("update SUV variant: %v", err)
+	}
+	if variant.TotalPriceMinor != 26000 {
+		t.Fatalf("expected upsert to replace the SUV price, got %d", variant.TotalPriceMinor)
+	}
+
+	vehicle := models.Vehicle{ID: uuid.New(), BusinessID: business.ID, CustomerID: uuid.New(), Year: 2022, Make: "Toyota", Model: "RAV4", Class: models.VehicleClassSUV}
+	if err := db.Create(&vehicle).Error; err != nil {
+		t.Fatalf("create vehicle: %v", err)
+	}
+
+	mismatched := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slot.ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.CreateWithOptions(mismatched, BookingOptions{VehicleID: &vehicle.ID, VehicleClass: models.VehicleClassSedan}); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("expected a class contradicting the vehicle to be rejected, got %v", err)
+	}
+
+	booking := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slot.ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.CreateWithOptions(booking, BookingOptions{VehicleID: &vehicle.ID}); err != nil {
+		t.Fatalf("create booking for SUV: %v", err)
+	}
+	if booking.VehicleClass != models.VehicleClassSUV || booking.TotalPriceMinor != 26000 || booking.DepositDueMinor != 7000 {
+		t.Fatalf("expected SUV price snapshot, got class %q total %d deposit %d", booking.VehicleClass, booking.TotalPriceMinor, booking.DepositDueMinor)
+	}
+	if booking.LineItems[0].AmountMinor != 26000 || booking.LineItems[0].Name != "Full Interior Detail (SUV)" {
+		t.Fatalf("unexpected service line item: %+v", booking.LineItems[0])
+	}
+
+	// Changing the variant later must not reprice the booking.
+	if _, err := variantService.Upsert(&models.ServicePriceVariant{BusinessID: business.ID, ServiceID: service.ID, VehicleClass: models.VehicleClassSUV, TotalPriceMinor: 40000, DepositAmountMinor: 7000}); err != nil {
+		t.Fatalf("reprice SUV variant: %v", err)
+	}
+	var persisted models.Booking
+	if err := db.First(&persisted, "id = ?", booking.ID).Error; err != nil {
+		t.Fatalf("reload booking: %v", err)
+	}
+	if persisted.TotalPriceMinor != 26000 {
+		t.Fatalf("expected booking to keep its snapshot price, got %d", persisted.TotalPriceMinor)
+	}
+
+	secondSlot := seedConsecutiveSlots(t, db, business, slot.StartTime.Add(48*time.Hour), 2, time.Hour)
+	fallback := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     secondSlot[0].ID,
+		Customer:   models.CustomerDetails{Name: "Bob", Email: "bob@example.com", Phone: "555-0102"},
+	}
+	if err := bookingService.CreateWithOptions(fallback, BookingOptions{VehicleClass: models.VehicleClassVan}); err != nil {
+		t.Fatalf("create booking for van: %v", err)
+	}
+	if fallback.TotalPriceMinor != service.TotalPriceMinor || fallback.DepositDueMinor != service.DepositAmountMinor || fallback.VehicleClass != models.VehicleClassVan {
+		t.Fatalf("expected base price for a class without a variant, got total %d deposit %d", fallback.TotalPriceMinor, fallback.DepositDueMinor)
+	}
+}
+
+func TestBookingServiceCreateRedeemsPromoCodeWithinLimits(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, _ := seedBookingTestRecords(t, db)
+	bookingService := NewBookingService(db)
+	promoService := NewPromoCodeService(db)
+
+	otherService := models.Service{ID: uuid.New(), BusinessID: business.ID, Name: "Window Tint", DurationMin: 60, TotalPriceMinor: 10000, CurrencyCode: "USD"}
+	if err := db.Create(&otherService).Error; err != nil {
+		t.Fatalf("create other service: %v", err)
+	}
+
+	promo := models.PromoCode{
+		BusinessID:       business.ID,
+		Code:             "spring25",
+		DiscountType:     models.PromoDiscountPercent,
+		PercentOff:       25,
+		MaxRedemptions:   2,
+		PerCustomerLimit: 1,
+		Restrictions:     []models.PromoCodeRestriction{{ServiceID: service.ID}},
+	}
+	if err := promoService.Create(&promo); err != nil {
+		t.Fatalf("create promo code: %v", err)
+	}
+	if promo.Code != "SPRING25" {
+		t.Fatalf("expected code to be normalized, got %q", promo.Code)
+	}
+	duplicate := models.PromoCode{BusinessID: business.ID, Code: "Spring25", DiscountType: models.PromoDiscountFixed, AmountOffMinor: 500}
+	if err := promoService.Create(&duplicate); err != ErrConflict {
+		t.Fatalf("expected a duplicate code to conflict, got %v", err)
+	}
+
+	slots := seedConsecutiveSlots(t, db, business, time.Now().UTC().Add(24*time.Hour).Truncate(time.Hour), 8, time.Hour)
+	book := func(serviceID uuid.UUID, slot models.Slot, email string) (*models.Booking, error) {
+		booking := &models.Booking{
+			BusinessID: business.ID,
+			ServiceID:  serviceID,
+			SlotID:     slot.ID,
+			Customer:   models.CustomerDetails{Name: "Customer", Email: email, Phone: "555-0101"},
+		}
+		return booking, bookingService.CreateWithOptions(booking, BookingOptions{PromoCode: "Spring25"})
+	}
+
+	if _, err := book(otherService.ID, slots[0], "alice@example.com"); !errors.Is(err, ErrInvalidPromoCode) {
+		t.Fatalf("expected code to be rejected for an unlisted service, got %v", err)
+	}
+
+	first, err := book(service.ID, slots[0], "alice@example.com")
+	if err != nil {
+		t.Fatalf("redeem promo code: %v", err)
+	}
+	if first.DiscountMinor != 5000 || first.TotalPriceMinor != 15000 || first.PromoCode != "SPRING25" {
+		t.Fatalf("expected 25%% off 20000, got discount %d total %d code %q", first.DiscountMinor, first.TotalPriceMinor, first.PromoCode)
+	}
+	if len(first.LineItems) != 2 || first.LineItems[1].Kind != models.LineItemKindDiscount || first.LineItems[1].AmountMinor != -5000 || first.LineItems[1].CurrencyCode != "USD" {
+		t.Fatalf("expected a discount line item, got %+v", first.LineItems)
+	}
+	if totals := TotalLineItems(first.LineItems); totals.SubtotalMinor != 20000 || totals.DiscountMinor != 5000 || totals.TotalMinor != first.TotalPriceMinor {
+		t.Fatalf("expected totals derived from line items, got %+v", totals)
+	}
+
+	if _, err := book(service.ID, slots[2], "ALICE@example.com"); !errors.Is(err, ErrInvalidPromoCode) {
+		t.Fatalf("expected per-customer limit to apply, got %v", err)
+	}
+	if _, err := book(service.ID, slots[4], "bob@example.com"); err != nil {
+		t.Fatalf("redeem promo code for second customer: %v", err)
+	}
+	if _, err := book(service.ID, slots[6], "carol@example.com"); !errors.Is(err, ErrInvalidPromoCode) {
+		t.Fatalf("expected max redemptions to apply, got %v", err)
+	}
+
+	var persisted models.PromoCode
+	if err := db.First(&persisted, "id = ?", promo.ID).Error; err != nil {
+		t.Fatalf("reload promo code: %v", err)
+	}
+	if persisted.RedemptionCount != 2 {
+		t.Fatalf("expected rejected bookings to roll back their redemption, got count %d", persisted.RedemptionCount)
+	}
+	var redemptions int64
+	if err := db.Model(&models.PromoRedemption{}).Where("promo_code_id = ?", promo.ID).Count(&redemptions).Error; err != nil {
+		t.Fatalf("count redemptions: %v", err)
+	}
+	if redemptions != 2 {
+		t.Fatalf("expected 2 redemptions, got %d", redemptions)
+	}
+}
+
+func TestBookingServiceCreateAppliesExclusiveAndInclusiveTax(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, _ := seedBookingTestRecords(t, db)
+	bookingService := NewBookingService(db)
+	taxService := NewTaxRateService(db)
+
+	if err := db.Model(&models.Service{}).Where("id = ?", service.ID).Update("total_price_minor", 10010).Error; err != nil {
+		t.Fatalf("reprice service: %v", err)
+	}
+	salesTax := models.TaxRate{BusinessID: business.ID, Name: "Sales tax", RateBasisPoints: 500}
+	if err := taxService.Create(&salesTax); err != nil {
+		t.Fatalf("create tax rate: %v", err)
+	}
+
+	slots := seedConsecutiveSlots(t, db, business, time.Now().UTC().Add(24*time.Hour).Truncate(time.Hour), 4, time.Hour)
+	exclusive := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slots[0].ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.Create(exclusive); err != nil {
+		t.Fatalf("create booking with exclusive tax: %v", err)
+	}
+	// 5% of 10010 is 500.5, which rounds half-up to 501.
+	if exclusive.TaxMinor != 501 || exclusive.TotalPriceMinor != 10511 {
+		t.Fatalf("expected tax 501 on top of 10010, got tax %d total %d", exclusive.TaxMinor, exclusive.TotalPriceMinor)
+	}
+
+	if _, err := taxService.Update(business.ID, salesTax.ID, &models.TaxRate{Name: "GST", RateBasisPoints: 1000, Inclusive: true}); err != nil {
+		t.Fatalf("update tax rate: %v", err)
+	}
+	if err := db.Model(&models.Service{}).Where("id = ?", service.ID).Update("total_price_minor", 11000).Error; err != nil {
+		t.Fatalf("reprice service: %v", err)
+	}
+	inclusive := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slots[2].ID,
+		Customer:   models.CustomerDetails{Name: "Bob", Email: "bob@example.com", Phone: "555-0102"},
+	}
+	if err := bookingService.Create(inclusive); err != nil {
+		t.Fatalf("create booking with inclusive tax: %v", err)
+	}
+	if inclusive.TaxMinor != 1000 || inclusive.TotalPriceMinor != 11000 {
+		t.Fatalf("expected 1000 tax contained in 11000, got tax %d total %d", inclusive.TaxMinor, inclusive.TotalPriceMinor)
+	}
+	taxLine := inclusive.LineItems[len(inclusive.LineItems)-1]
+	if taxLine.Kind != models.LineItemKindTax || !taxLine.TaxInclusive || taxLine.Name != "GST" {
+		t.Fatalf("expected an inclusive GST line, got %+v", taxLine)
+	}
+
+	var persisted models.Booking
+	if err := db.First(&persisted, "id = ?", exclusive.ID).Error; err != nil {
+		t.Fatalf("reload booking: %v", err)
+	}
+	if persisted.TaxMinor != 501 {
+		t.Fatalf("expected earlier booking to keep its tax, got %d", persisted.TaxMinor)
+	}
+}
+
+func TestBookingServiceCreateAppliesExclusiveTaxNetOfInclusiveTax(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, _ := seedBookingTestRecords(t, db)
+	bookingService := NewBookingService(db)
+	taxService := NewTaxRateService(db)
+
+	if err := db.Model(&models.Service{}).Where("id = ?", service.ID).Update("total_price_minor", 11000).Error; err != nil {
+		t.Fatalf("reprice service: %v", err)
+	}
+	for _, rate := range []*models.TaxRate{
+		{BusinessID: business.ID, Name: "GST", RateBasisPoints: 1000, Inclusive: true},
+		{BusinessID: business.ID, Name: "Levy", RateBasisPoints: 500},
+	} {
+		if err := taxService.Create(rate); err != nil {
+			t.Fatalf("create tax rate: %v", err)
+		}
+	}
+
+	slots := seedConsecutiveSlots(t, db, business, time.Now().UTC().Add(24*time.Hour).Truncate(time.Hour), 2, time.Hour)
+	booking := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slots[0].ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.Create(booking); err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+	// 11000 contains 1000 GST; the 5% levy applies to the 10000 left, not
+	// to the 11000 that still includes GST.
+	if booking.TaxMinor != 1500 || booking.TotalPriceMinor != 11500 {
+		t.Fatalf("expected tax 1500 and total 11500, got tax %d total %d", booking.TaxMinor, booking.TotalPriceMinor)
+	}
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"blytz.cloud/backend/internal/models"
+	"blytz.cloud/backend/internal/payments"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var paymentProvider payments.Provider
+
+// SetPaymentProvider enables online deposits. Without a provider, bookings are
+// created PENDING and confirmed by the workshop as before.
+func SetPaymentProvider(provider payments.Provider) {
+	paymentProvider = provider
+}
+
+var depositPaymentTimeout = 15 * time.Minute
+
+// SetDepositPaymentTimeout sets how long a customer has to pay the deposit
+// before the booking is cancelled and its slots are released.
+func SetDepositPaymentTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		depositPaymentTimeout = timeout
+	}
+}
+
+// startDepositPayment asks the provider to collect a newly created booking's
+// deposit. The provider is called after the booking transaction has
+// committed, so no locks are held while it responds. If the intent cannot be
+// opened the booking is cancelled and its slots released; an intent the
+// customer never sees simply goes unpaid.
+func (s *BookingService) startDepositPayment(booking *models.Booking, now time.Time) error {
+	intent, err := paymentProvider.CreateIntent(payments.IntentRequest{
+		AmountMinor:  booking.DepositDueMinor,
+		CurrencyCode: booking.CurrencyCode,
+		Reference:    booking.ID.String(),
+	})
+	if err == nil {
+		err = s.DB.Create(&models.PaymentIntent{
+			BusinessID:       booking.BusinessID,
+			BookingID:        booking.ID,
+			Provider:         paymentProvider.Name(),
+			ProviderIntentID: intent.ID,
+			ClientSecret:     intent.ClientSecret,
+			AmountMinor:      intent.AmountMinor,
+			CurrencyCode:     intent.CurrencyCode,
+			Status:           models.PaymentIntentStatusPending,
+			ExpiresAt:        now.Add(depositPaymentTimeout),
+		}).Error
+	}
+	if err != nil {
+		if _, cancelErr := s.updateStatus(booking.BusinessID, booking.ID, models.BookingStatusPending, models.BookingStatusCancelled); cancelErr != nil {
+			log.Printf("Failed to cancel booking %s after its deposit payment could not start: %v", booking.ID, cancelErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// GetPendingDepositPayment returns the deposit the customer still has to pay
+// online for a booking, or ErrNotFound when there is none.
+func (s *BookingService) GetPendingDepositPayment(bookingID uuid.UUID) (*models.PaymentIntent, error) {
+	var intent models.PaymentIntent
+	if err := s.DB.Where("booking_id = ? AND status = ?", bookingID, models.PaymentIntentStatusPending).
+		Order("created_at DESC").
+		First(&intent).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &intent, nil
+}
+
+// HandlePaymentWebhook verifies and applies a provider webhook. A successful
+// payment confirms a PENDING booking and records the deposit in the payments
+// ledger; a payment that arrives after the booking was cancelled is refunded.
+// A failed payment cancels the booking and releases its slots. Events are
+// idempotent, so provider retries are harmless.
+func (s *BookingService) HandlePaymentWebhook(payload []byte, signature string, now time.Time) error {
+	if paymentProvider == nil {
+		return ErrNotFound
+	}
+	event, err := paymentProvider.VerifyWebhook(payload, signature)
+	if err != nil {
+		return err
+	}
+
+	var intent models.PaymentIntent
+	if err := s.DB.Where("provider_intent_id = ?", event.IntentID).First(&intent).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	switch event.Type {
+	case payments.EventPaymentSucceeded:
+		if err := s.DB.Transaction(func(tx *gorm.DB) error {
+			return settleDepositPayment(tx, intent, now)
+		}); err != nil {
+			return err
+		}
+		// Retried deliveries finish a refund an earlier one could not.
+		return s.refundDepositPayment(intent)
+	case payments.EventPaymentFailed:
+		return s.abandonDepositPayment(intent, models.PaymentIntentStatusFailed)
+	}
+	return nil
+}
+
+// ExpireUnpaidDeposits cancels bookings whose online deposit was not paid
+// before its intent expired, and retries refunds the provider has not yet
+// accepted. An intent that fails is logged and skipped so the rest are still
+// handled; the failures are returned together. It returns how many intents
+// were expired.
+func (s *BookingService) ExpireUnpaidDeposits(now time.Time) (int, error) {
+	var expired []models.PaymentIntent
+	if err := s.DB.Where("status = ? AND expires_at <= ?", models.PaymentIntentStatusPending, now).
+		Find(&expired).Error; err != nil {
+		return 0, err
+	}
+
+	count := 0
+	var errs []error
+	for _, intent := range expired {
+		if err := s.abandonDepositPayment(intent, models.PaymentIntentStatusExpired); err != nil {
+			log.Printf("Failed to expire deposit payment %s: %v", intent.ID, err)
+			errs = append(errs, fmt.Errorf("payment intent %s: %w", intent.ID, err))
+			continue
+		}
+		count++
+	}
+
+	var refunding []models.PaymentIntent
+	if err := s.DB.Where("status = ?", models.PaymentIntentStatusRefundPending).Find(&refunding).Error; err != nil {
+		return count, errors.Join(append(errs, err)...)
+	}
+	for _, intent := range refunding {
+		if err := s.refundDepositPayment(intent); err != nil {
+			log.Printf("Failed to refund deposit payment %s: %v", intent.ID, err)
+			errs = append(errs, fmt.Errorf("payment intent %s: %w", intent.ID, err))
+		}
+	}
+	return count, errors.Join(errs...)
+}
+
+func settleDepositPayment(tx *gorm.DB, intent models.PaymentIntent, now time.Time) error {
+	// Only an intent still waiting on the customer can be settled. Guarding
+	// on its status makes repeated webhooks a no-op.
+	result := tx.Model(&models.PaymentIntent{}).
+		Where("id = ? AND status IN ?", intent.ID, []models.PaymentIntentStatus{
+			models.PaymentIntentStatusPending,
+			models.PaymentIntentStatusFailed,
+			models.PaymentIntentStatusExpired,
+		}).
+		Update("status", models.PaymentIntentStatusSucceeded)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	// Lock the booking so a concurrent timeout cannot cancel it while the
+	// payment is being applied.
+	booking, err := lockLedgerBooking(tx, intent.BusinessID, intent.BookingID)
+	if err != nil {
+		return err
+	}
+	if booking.Status == models.BookingStatusCancelled || booking.Status == models.BookingStatusNoShow {
+		// The slot has already been released, so the money goes back. The
+		// refund is recorded as owed here and sent once this transaction has
+		// committed, so a rollback can never leave a refund unrecorded.
+		return tx.Model(&models.PaymentIntent{}).
+			Where("id = ?", intent.ID).
+			Update("status", models.PaymentIntentStatusRefundPending).Error
+	}
+
+	if booking.Status == models.BookingStatusPending {
+		if err := tx.Model(&models.Booking{}).
+			Where("id = ? AND status = ?", booking.ID, models.BookingStatusPending).
+			Update("status", models.BookingStatusConfirmed).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Create(&models.PaymentRecord{
+		BusinessID:   booking.BusinessID,
+		BookingID:    booking.ID,
+		Kind:         models.PaymentKindPayment,
+		Method:       models.PaymentMethodCard,
+		AmountMinor:  intent.AmountMinor,
+		CurrencyCode: intent.CurrencyCode,
+		Note:         "Online deposit " + intent.ProviderIntentID,
+		RecordedAt:   now,
+	}).Error; err != nil {
+		return err
+	}
+	_, err = syncDepositPaid(tx, booking)
+	return err
+}
+
+// refundDepositPayment sends the refund owed on an intent paid after its
+// booking was cancelled. Only an intent marked REFUND_PENDING is refunded, and
+// it is marked REFUNDED once the provider accepts, so a refund is sent again
+// only if that last update fails.
+func (s *BookingService) refundDepositPayment(intent models.PaymentIntent) error {
+	if err := s.DB.Where("id = ?", intent.ID).First(&intent).Error; err != nil {
+		return err
+	}
+	if intent.Status != models.PaymentIntentStatusRefundPending {
+		return nil
+	}
+	if err := paymentProvider.Refund(intent.ProviderIntentID, intent.AmountMinor); err != nil {
+		return err
+	}
+	return s.DB.Model(&models.PaymentIntent{}).
+		Where("id = ? AND status = ?", intent.ID, models.PaymentIntentStatusRefundPending).
+		Update("status", models.PaymentIntentStatusRefunded).Error
+}
+
+// abandonDepositPayment closes an unpaid intent and cancels its booking if it
+// is still waiting on the payment, in one transaction. A booking the workshop
+// already confirmed by hand is left alone.
+func (s *BookingService) abandonDepositPayment(intent models.PaymentIntent, status models.PaymentIntentStatus) error {
+	var freedSlotIDs []uuid.UUID
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.PaymentIntent{}).
+			Where("id = ? AND status = ?", intent.ID, models.PaymentIntentStatusPending).
+			Update("status", status)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		var err error
+		_, freedSlotIDs, err = transitionBooking(tx, intent.BusinessID, intent.BookingID, models.BookingStatusPending, models.BookingStatusCancelled)
+		if err == ErrInvalidTransition {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.offerFreedSlots(intent.BusinessID, freedSlotIDs)
+	return nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"blytz.cloud/backend/internal/models"
+	"blytz.cloud/backend/internal/payments"
+)
+
+func TestBookingServiceOnlineDepositConfirmsOnWebhookAndReleasesOnTimeout(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, slot := seedBookingTestRecords(t, db)
+	bookingService := NewBookingService(db)
+	provider := payments.NewFakeProvider("test-webhook-secret")
+	SetPaymentProvider(provider)
+	t.Cleanup(func() { SetPaymentProvider(nil) })
+	now := time.Now().UTC()
+
+	paid := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slot.ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.Create(paid); err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+	intent, err := bookingService.GetPendingDepositPayment(paid.ID)
+	if err != nil {
+		t.Fatalf("expected a pending deposit payment: %v", err)
+	}
+	if paid.Status != models.BookingStatusPending || intent.AmountMinor != service.DepositAmountMinor {
+		t.Fatalf("expected a PENDING booking awaiting a %d deposit, got %s awaiting %d", service.DepositAmountMinor, paid.Status, intent.AmountMinor)
+	}
+
+	payload, signature, err := provider.Complete(intent.ProviderIntentID, true)
+	if err != nil {
+		t.Fatalf("complete fake payment: %v", err)
+	}
+	if err := bookingService.HandlePaymentWebhook([]byte(string(payload)+" "), signature, now); !errors.Is(err, payments.ErrInvalidSignature) {
+		t.Fatalf("expected a tampered webhook to be rejected, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := bookingService.HandlePaymentWebhook(payload, signature, now); err != nil {
+			t.Fatalf("deliver webhook %d: %v", i, err)
+		}
+	}
+
+	confirmed, err := bookingService.GetByID(paid.ID)
+	if err != nil {
+		t.Fatalf("reload booking: %v", err)
+	}
+	if confirmed.Status != models.BookingStatusConfirmed || confirmed.DepositPaidMinor != service.DepositAmountMinor {
+		t.Fatalf("expected a CONFIRMED booking with its deposit paid, got %s with %d", confirmed.Status, confirmed.DepositPaidMinor)
+	}
+	var entries int64
+	if err := db.Model(&models.PaymentRecord{}).Where("booking_id = ?", paid.ID).Count(&entries).Error; err != nil {
+		t.Fatalf("count ledger entries: %v", err)
+	}
+	if entries != 1 {
+		t.Fatalf("expected a repeated webhook to record the deposit once, got %d entries", entries)
+	}
+
+	future := seedConsecutiveSlots(t, db, business, slot.StartTime.Add(48*time.Hour), 1, 2*time.Hour)
+	unpaid := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     future[0].ID,
+		Customer:   models.CustomerDetails{Name: "Bob", Email: "bob@example.com", Phone: "555-0202"},
+	}
+	if err := bookingService.Create(unpaid); err != nil {
+		t.Fatalf("create unpaid booking: %v", err)
+	}
+	late, err := bookingService.GetPendingDepositPayment(unpaid.ID)
+	if err != nil {
+		t.Fatalf("expected a pending deposit payment: %v", err)
+	}
+
+	if expired, err := bookingService.ExpireUnpaidDeposits(now); err != nil || expired != 0 {
+		t.Fatalf("expected nothing to expire yet, got %d (%v)", expired, err)
+	}
+	expired, err := bookingService.ExpireUnpaidDeposits(now.Add(depositPaymentTimeout + time.Minute))
+	if err != nil {
+		t.Fatalf("expire unpaid deposits: %v", err)
+	}
+	if expired != 1 {
+		t.Fatalf("expected 1 unpaid deposit to expire, got %d", expired)
+	}
+	cancelled, err := bookingService.GetByID(unpaid.ID)
+	if err != nil {
+		t.Fatalf("reload unpaid booking: %v", err)
+	}
+	if cancelled.Status != models.BookingStatusCancelled {
+		t.Fatalf("expected the unpaid booking to be cancelled, got %s", cancelled.Status)
+	}
+	var released models.Slot
+	if err := db.First(&released, "id = ?", future[0].ID).Error; err != nil {
+		t.Fatalf("reload slot: %v", err)
+	}
+	if released.BookedCount != 0 {
+		t.Fatalf("expected the slot to be released, got booked_count %d", released.BookedCount)
+	}
+
+	payload, signature, err = provider.Complete(late.ProviderIntentID, true)
+	if err != nil {
+		t.Fatalf("complete late payment: %v", err)
+	}
+	if err := bookingService.HandlePaymentWebhook(payload, signature, now); err != nil {
+		t.Fatalf("deliver late webhook: %v", err)
+	}
+	if err := db.First(late, "id = ?", late.ID).Error; err != nil {
+		t.Fatalf("reload intent: %v", err)
+	}
+	if late.Status != models.PaymentIntentStatusRefunded {
+		t.Fatalf("expected a payment after the timeout to be refunded, got %s", late.Status)
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"time"
+
+	"blytz.cloud/backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyService struct {
+	*BaseService
+}
+
+func NewIdempotencyService(db *gorm.DB) *IdempotencyService {
+	return &IdempotencyService{
+		BaseService: NewBaseService(db),
+	}
+}
+
+// Begin claims key within scope for a request with the given body hash. It
+// returns replay=true with the stored record when the original request has
+// already completed, ErrKeyReused when the key was used for a different
+// body, and ErrConflict while the original request is still in flight.
+func (s *IdempotencyService) Begin(scope, key, requestHash string, ttl time.Duration) (*models.IdempotencyKey, bool, error) {
+	now := time.Now().UTC()
+	if err := s.DB.Where("scope = ? AND key = ? AND expires_at <= ?", scope, key, now).Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return nil, false, err
+	}
+
+	record := models.IdempotencyKey{
+		Scope:       scope,
+		Key:         key,
+		RequestHash: requestHash,
+		ExpiresAt:   now.Add(ttl),
+	}
+	result := s.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return &record, false, nil
+	}
+
+	var existing models.IdempotencyKey
+	if err := s.DB.Where("scope = ? AND key = ?", scope, key).First(&existing).Error; err != nil {
+		return nil, false, err
+	}
+	if existing.RequestHash != requestHash {
+		return nil, false, ErrKeyReused
+	}
+	if existing.StatusCode == 0 {
+		return nil, false, ErrConflict
+	}
+	return &existing, true, nil
+}
+
+func (s *IdempotencyService) Complete(id uuid.UUID, statusCode int, responseBody string) error {
+	return s.DB.Model(&models.IdempotencyKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status_code":   statusCode,
+		"response_body": responseBody,
+	}).Error
+}
+
+// Abandon forgets a claimed key so the client can retry after a server error.
+func (s *IdempotencyService) Abandon(id uuid.UUID) error {
+	return s.DB.Where("id = ?", id).Delete(&models.IdempotencyKey{}).Error
+}
+
+func (s *IdempotencyService) DeleteExpired(now time.Time) (int64, error) {
+	result := s.DB.Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"blytz.cloud/backend/internal/auth"
+	"blytz.cloud/backend/internal/models"
+	"blytz.cloud/backend/internal/notify"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// InvitationTTL is how long an invite link works.
+const InvitationTTL = 7 * 24 * time.Hour
+
+type InvitationService struct {
+	*BaseService
+}
+
+func NewInvitationService(db *gorm.DB) *InvitationService {
+	return &InvitationService{
+		BaseService: NewBaseService(db),
+	}
+}
+
+// ListPending returns the business's invitations that can still be accepted.
+func (s *InvitationService) ListPending(businessID uuid.UUID, now time.Time) ([]models.Invitation, error) {
+	var invitations []models.Invitation
+	if err := s.DB.Where("business_id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", businessID, now).
+		Order("created_at DESC").
+		Find(&invitations).Error; err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// Create invites email to join the business with role and sends the invite
+// link. Inviting an address again replaces its pending invitation. It returns
+// ErrConflict when the address already belongs to a member and
+// ErrPlanLimitReached when the plan has no free staff seat.
+func (s *InvitationService) Create(businessID, invitedByID uuid.UUID, email string, role models.MembershipRole, now time.Time) (*models.Invitation, error) {
+	if role != models.MembershipRoleOwner && role != models.MembershipRoleStaff {
+		return nil, ErrBadRequest
+	}
+	invitation := models.Invitation{
+		BusinessID:  businessID,
+		Email:       normalizeInviteEmail(email),
+		Role:        role,
+		InvitedByID: invitedByID,
+		ExpiresAt:   now.Add(InvitationTTL),
+	}
+
+	var token string
+	var business models.Business
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkStaffSeatLimit(tx, businessID); err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", businessID).First(&business).Error; err != nil {
+			return err
+		}
+
+		var members int64
+		if err := tx.Model(&models.Membership{}).
+			Joins("JOIN users ON users.id = memberships.user_id").
+			Where("memberships.business_id = ? AND LOWER(users.email) = ?", businessID, invitation.Email).
+			Count(&members).Error; err != nil {
+			return err
+		}
+		if members > 0 {
+			return ErrConflict
+		}
+
+		if err := tx.Model(&models.Invitation{}).
+			Where("business_id = ? AND email = ? AND accepted_at IS NULL AND revoked_at IS NULL", businessID, invitation.Email).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&invitation).Error; err != nil {
+			return err
+		}
+
+		var err error
+		token, err = auth.GenerateInviteToken(invitation.ID.String(), invitation.ExpiresAt)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sendInvitation(&invitation, business, token)
+	return &invitation, nil
+}
+
+// Revoke stops a pending invitation from being accepted.
+func (s *InvitationService) Revoke(businessID, id uuid.UUID, now time.Time) error {
+	result := s.DB.Model(&models.Invitation{}).
+		Where("id = ? AND business_id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id, businessID).
+		Update("revoked_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Accept redeems an invite token and returns the member and a session token
+// for them. When no user has the invited email one is created with name and
+// password; an existing user must confirm with their password and is attached
+// to the business. It returns ErrNotFound for a token that is invalid,
+// expired, revoked or already used, and ErrUnauthorized for a wrong password.
+func (s *InvitationService) Accept(token, name, password string, now time.Time) (*models.User, string, error) {
+	invitationID, err := auth.ValidateInviteToken(token)
+	if err != nil {
+		return nil, "", ErrNotFound
+	}
+
+	var user models.User
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		// Claiming the invitation first makes concurrent accepts of the same
+		// token race on this row; the loser finds it already accepted.
+		result := tx.Model(&models.Invitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", invitationID, now).
+			Update("accepted_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		var invitation models.Invitation
+		if err := tx.Where("id = ?", invitationID).First(&invitation).Error; err != nil {
+			return err
+		}
+
+		err := tx.Where("LOWER(email) = ?", invitation.Email).First(&user).Error
+		switch {
+		case err == nil:
+			if !auth.CheckPassword(password, user.PasswordHash) {
+				return ErrUnauthorized
+			}
+		case err == gorm.ErrRecordNotFound:
+			if strings.TrimSpace(name) == "" {
+				return ErrBadRequest
+			}
+			hashedPassword, err := auth.HashPassword(password)
+			if err != nil {
+				return err
+			}
+			user = models.User{Email: invitation.Email, Name: strings.TrimSpace(name), PasswordHash: hashedPassword}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		return addMembership(tx, &models.Membership{UserID: user.ID, BusinessID: invitation.BusinessID, Role: invitation.Role})
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	sessionToken, err := auth.GenerateToken(user.ID.String(), user.Email, user.TokenVersion)
+	if err != nil {
+		return nil, "", err
+	}
+	return &user, sessionToken, nil
+}
+
+func normalizeInviteEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func sendInvitation(invitation *models.Invitation, business models.Business, token string) {
+	msg := notify.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("You're invited to join %s", business.Name),
+		Body: fmt.Sprintf("You have been invited to join %s as %s. Accept before %s with invite_token=%s",
+			business.Name, strings.ToLower(string(invitation.Role)), invitation.ExpiresAt.Format(time.RFC3339), token),
+		// The token signs its holder in to the business, possibly as an owner.
+		Secrets: []string{token},
+	}
+	if err := notify.Send(msg); err != nil {
+		log.Printf("Failed to send invitation %s: %v", invitation.ID, err)
+	}
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"blytz.cloud/backend/internal/auth"
+	"blytz.cloud/backend/internal/models"
+	"blytz.cloud/backend/internal/notify"
+)
+
+func TestInvitationServiceAttachesExistingUserWithTheirPassword(t *testing.T) {
+	db := setupBookingTestDB(t)
+	auth.SetJWTSecret("test-secret")
+	notifier := &recordingNotifier{}
+	notify.SetNotifier(notifier)
+	t.Cleanup(func() { notify.SetNotifier(notify.LogNotifier{}) })
+	now := time.Now().UTC()
+
+	owner, _, err := NewAuthService(db).Register("owner@example.com", "Owner", "owner-password")
+	if err != nil {
+		t.Fatalf("register owner: %v", err)
+	}
+	var business models.Business
+	if err := db.Joins("JOIN memberships ON memberships.business_id = businesses.id").
+		Where("memberships.user_id = ?", owner.ID).First(&business).Error; err != nil {
+		t.Fatalf("load owner's business: %v", err)
+	}
+
+	hash, err := auth.HashPassword("existing-password")
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	existing := models.User{Email: "Mechanic@Example.com", Name: "Mechanic", PasswordHash: hash}
+	if err := db.Create(&existing).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	invitationService := NewInvitationService(db)
+	if _, err := invitationService.Create(business.ID, owner.ID, "owner@example.com", models.MembershipRoleStaff, now); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected inviting a member to conflict, got %v", err)
+	}
+	if _, err := invitationService.Create(business.ID, owner.ID, "mechanic@example.com", models.MembershipRoleStaff, now); err != nil {
+		t.Fatalf("create invitation: %v", err)
+	}
+	msg := notifier.messages[len(notifier.messages)-1]
+	token := msg.Body[strings.Index(msg.Body, "invite_token=")+len("invite_token="):]
+	if strings.Contains(notify.Redact(msg), token) {
+		t.Fatalf("expected the invite token to be kept out of logs, got %q", notify.Redact(msg))
+	}
+
+	if _, _, err := invitationService.Accept(token, "", "wrong-password", now); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected a wrong password to be rejected, got %v", err)
+	}
+	user, session, err := invitationService.Accept(token, "", "existing-password", now)
+	if err != nil {
+		t.Fatalf("accept invitation: %v", err)
+	}
+	if user.ID != existing.ID || session == "" {
+		t.Fatalf("expected the existing user to be signed in, got %s", user.ID)
+	}
+	role, err := NewAuthService(db).MembershipRole(existing.ID, business.ID)
+	if err != nil || role != models.MembershipRoleStaff {
+		t.Fatalf("expected a STAFF membership, got %q (%v)", role, err)
+	}
+	if _, _, err := invitationService.Accept(token, "", "existing-password", now.Add(time.Minute)); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected a used invitation to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,331 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"blytz.cloud/backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InvoiceService struct {
+	*BaseService
+}
+
+func NewInvoiceService(db *gorm.DB) *InvoiceService {
+	return &InvoiceService{
+		BaseService: NewBaseService(db),
+	}
+}
+
+var invoiceStatusTransitions = map[models.InvoiceStatus][]models.InvoiceStatus{
+	models.InvoiceStatusDraft:  {models.InvoiceStatusIssued, models.InvoiceStatusVoid},
+	models.InvoiceStatusIssued: {models.InvoiceStatusPaid, models.InvoiceStatusVoid},
+}
+
+func canTransitionInvoice(from, to models.InvoiceStatus) bool {
+	for _, allowed := range invoiceStatusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatInvoiceNumber is the printed form of an issued invoice's number.
+func FormatInvoiceNumber(number int) string {
+	return fmt.Sprintf("INV-%06d", number)
+}
+
+func (s *InvoiceService) GetByBusiness(businessID uuid.UUID) ([]models.Invoice, error) {
+	var invoices []models.Invoice
+	if err := s.DB.Where("business_id = ?", businessID).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Order("created_at DESC").
+		Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+func (s *InvoiceService) GetByID(businessID, id uuid.UUID) (*models.Invoice, error) {
+	var invoice models.Invoice
+	if err := s.DB.Where("id = ? AND business_id = ?", id, businessID).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		First(&invoice).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// CreateFromBooking drafts an invoice from a completed booking.
+func (s *InvoiceService) CreateFromBooking(businessID, bookingID uuid.UUID) (*models.Invoice, error) {
+	var invoice *models.Invoice
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		invoice, err = draftInvoice(tx, businessID, bookingID, nil, "")
+		return err
+	})
+	return invoice, err
+}
+
+// CreateFromJob drafts an invoice for the booking a delivered job was carried
+// out for, adding the job's line items to the booking's. The job and its
+// vehicle are noted as the invoice's reference. A missing job returns
+// ErrNotFound and a job without a booking returns ErrBadRequest.
+func (s *InvoiceService) CreateFromJob(businessID, jobID uuid.UUID) (*models.Invoice, error) {
+	var invoice *models.Invoice
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var job models.Job
+		if err := tx.Where("id = ? AND business_id = ?", jobID, businessID).
+			Preload("Vehicle", "business_id = ?", businessID).
+			Preload("LineItems").
+			First(&job).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrNotFound
+			}
+			return err
+		}
+		if job.BookingID == nil {
+			return ErrBadRequest
+		}
+		if job.Status != models.JobStatusDelivered {
+			return ErrInvalidTransition
+		}
+
+		reference := job.Title
+		vehicle := strings.TrimSpace(fmt.Sprintf("%d %s %s", job.Vehicle.Year, job.Vehicle.Make, job.Vehicle.Model))
+		if job.Vehicle.LicensePlate != "" {
+			vehicle += " (" + job.Vehicle.LicensePlate + ")"
+		}
+		if vehicle != "" {
+			reference += " - " + vehicle
+		}
+
+		var err error
+		invoice, err = draftInvoice(tx, businessID, *job.BookingID, &job, reference)
+		if err == ErrNotFound {
+			// The job was found; it is its booking that is missing.
+			return ErrBadRequest
+		}
+		return err
+	})
+	return invoice, err
+}
+
+// Issue assigns the next invoice number and makes the invoice final.
+func (s *InvoiceService) Issue(businessID, id uuid.UUID, now time.Time) (*models.Invoice, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		number, err := nextInvoiceNumber(tx, businessID)
+		if err != nil {
+			return err
+		}
+		return transitionInvoice(tx, businessID, id, models.InvoiceStatusIssued, map[string]interface{}{
+			"number":    number,
+			"issued_at": now,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetByID(businessID, id)
+}
+
+func (s *InvoiceService) MarkPaid(businessID, id uuid.UUID, now time.Time) (*models.Invoice, error) {
+	if err := transitionInvoice(s.DB, businessID, id, models.InvoiceStatusPaid, map[string]interface{}{"paid_at": now}); err != nil {
+		return nil, err
+	}
+	return s.GetByID(businessID, id)
+}
+
+// Void cancels a draft or issued invoice. An issued invoice keeps its number
+// so the sequence stays gapless.
+func (s *InvoiceService) Void(businessID, id uuid.UUID, now time.Time) (*models.Invoice, error) {
+	if err := transitionInvoice(s.DB, businessID, id, models.InvoiceStatusVoid, map[string]interface{}{"voided_at": now}); err != nil {
+		return nil, err
+	}
+	return s.GetByID(businessID, id)
+}
+
+// draftInvoice drafts an invoice for a booking, through job when it is set.
+// Invoicing a booking directly waits until it is completed. A delivered job
+// already shows the work was done, so it may be invoiced while its booking is
+// still confirmed.
+func draftInvoice(tx *gorm.DB, businessID, bookingID uuid.UUID, job *models.Job, reference string) (*models.Invoice, error) {
+	// Lock the booking so concurrent drafts for it check for an open invoice
+	// in turn.
+	var booking models.Booking
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND business_id = ?", bookingID, businessID).
+		Preload("LineItems").
+		First(&booking).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	invoiceable := booking.Status == models.BookingStatusCompleted ||
+		(job != nil && booking.Status == models.BookingStatusConfirmed)
+	if !invoiceable {
+		return nil, ErrInvalidTransition
+	}
+
+	var open int64
+	if err := tx.Model(&models.Invoice{}).
+		Where("booking_id = ? AND status <> ?", booking.ID, models.InvoiceStatusVoid).
+		Count(&open).Error; err != nil {
+		return nil, err
+	}
+	if open > 0 {
+		return nil, ErrConflict
+	}
+
+	items := booking.LineItems
+	if len(items) == 0 {
+		// Bookings made before line items were recorded only have a total.
+		items = []models.BookingLineItem{{
+			Kind:            models.LineItemKindService,
+			Name:            booking.ServiceName,
+			Quantity:        1,
+			UnitAmountMinor: booking.TotalPriceMinor,
+			AmountMinor:     booking.TotalPriceMinor,
+		}}
+	}
+	var jobID *uuid.UUID
+	if job != nil {
+		jobID = &job.ID
+		if len(job.LineItems) > 0 {
+			var err error
+			if items, err = addJobLineItems(tx, &booking, items, job.LineItems); err != nil {
+				return nil, err
+			}
+		}
+	}
+	totals := TotalLineItems(items)
+
+	invoice := &models.Invoice{
+		BusinessID:    businessID,
+		BookingID:     booking.ID,
+		JobID:         jobID,
+		Status:        models.InvoiceStatusDraft,
+		Reference:     reference,
+		CustomerName:  booking.Customer.Name,
+		CustomerEmail: booking.Customer.Email,
+		CurrencyCode:  booking.CurrencyCode,
+		SubtotalMinor: totals.SubtotalMinor,
+		DiscountMinor: totals.DiscountMinor,
+		TaxMinor:      totals.TaxMinor,
+		TotalMinor:    totals.TotalMinor,
+	}
+	balance, err := bookingBalance(tx, &booking)
+	if err != nil {
+		return nil, err
+	}
+	invoice.DepositCreditMinor = balance.NetPaidMinor()
+	if invoice.DepositCreditMinor > invoice.TotalMinor {
+		invoice.DepositCreditMinor = invoice.TotalMinor
+	}
+	invoice.AmountDueMinor = invoice.TotalMinor - invoice.DepositCreditMinor
+
+	for i, item := range items {
+		invoice.Lines = append(invoice.Lines, models.InvoiceLine{
+			Position:        i + 1,
+			Kind:            item.Kind,
+			Description:     item.Name,
+			Quantity:        item.Quantity,
+			UnitAmountMinor: item.UnitAmountMinor,
+			AmountMinor:     item.AmountMinor,
+			TaxInclusive:    item.TaxInclusive,
+		})
+	}
+
+	if err := tx.Omit("Lines").Create(invoice).Error; err != nil {
+		return nil, err
+	}
+	for i := range invoice.Lines {
+		invoice.Lines[i].InvoiceID = invoice.ID
+	}
+	if err := tx.Create(&invoice.Lines).Error; err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// addJobLineItems returns the booking's items with a job's extra work added.
+// The booking's tax lines are dropped and tax is worked out again over the
+// combined subtotal at the business's current rates.
+func addJobLineItems(tx *gorm.DB, booking *models.Booking, items []models.BookingLineItem, extras []models.JobLineItem) ([]models.BookingLineItem, error) {
+	quote := bookingQuote{CurrencyCode: booking.CurrencyCode}
+	for _, item := range items {
+		if item.Kind != models.LineItemKindTax {
+			quote.LineItems = append(quote.LineItems, item)
+		}
+	}
+	for _, extra := range extras {
+		extraID := extra.ID
+		quote.addLine(models.BookingLineItem{
+			BusinessID:      booking.BusinessID,
+			Kind:            models.LineItemKindExtra,
+			ReferenceID:     &extraID,
+			Name:            extra.Name,
+			Quantity:        extra.Quantity,
+			UnitAmountMinor: extra.UnitAmountMinor,
+		})
+	}
+	if err := applyTaxes(tx, booking.BusinessID, &quote); err != nil {
+		return nil, err
+	}
+	return quote.LineItems, nil
+}
+
+// nextInvoiceNumber reserves the business's next invoice number. The update
+// locks the sequence row until the caller's transaction ends, so concurrent
+// issues are numbered one after another.
+func nextInvoiceNumber(tx *gorm.DB, businessID uuid.UUID) (int, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.InvoiceSequence{BusinessID: businessID}).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&models.InvoiceSequence{}).
+		Where("business_id = ?", businessID).
+		Update("last_number", gorm.Expr("last_number + 1")).Error; err != nil {
+		return 0, err
+	}
+	var sequence models.InvoiceSequence
+	if err := tx.Where("business_id = ?", businessID).First(&sequence).Error; err != nil {
+		return 0, err
+	}
+	return sequence.LastNumber, nil
+}
+
+func transitionInvoice(tx *gorm.DB, businessID, id uuid.UUID, status models.InvoiceStatus, updates map[string]interface{}) error {
+	var invoice models.Invoice
+	if err := tx.Where("id = ? AND business_id = ?", id, businessID).First(&invoice).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrNotFound
+		}
+		return err
+	}
+	if !canTransitionInvoice(invoice.Status, status) {
+		return ErrInvalidTransition
+	}
+
+	updates["status"] = status
+	result := tx.Model(&models.Invoice{}).
+		Where("id = ? AND business_id = ? AND status = ?", invoice.ID, businessID, invoice.Status).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
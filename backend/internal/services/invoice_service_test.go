@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"blytz.cloud/backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestInvoiceServiceNumbersIssuedInvoicesWithoutGaps(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, _ := seedBookingTestRecords(t, db)
+	bookingService := NewBookingService(db)
+	invoiceService := NewInvoiceService(db)
+	now := time.Now().UTC()
+
+	slots := seedConsecutiveSlots(t, db, business, now.Add(24*time.Hour).Truncate(time.Hour), 6, time.Hour)
+	bookings := make([]*models.Booking, 3)
+	for i := range bookings {
+		bookings[i] = &models.Booking{
+			BusinessID: business.ID,
+			ServiceID:  service.ID,
+			SlotID:     slots[i*2].ID,
+			Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+		}
+		if err := bookingService.Create(bookings[i]); err != nil {
+			t.Fatalf("create booking %d: %v", i, err)
+		}
+	}
+
+	if _, err := invoiceService.CreateFromBooking(business.ID, uuid.New()); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected an unknown booking to be not found, got %v", err)
+	}
+	if _, err := invoiceService.CreateFromJob(business.ID, uuid.New()); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected an unknown job to be not found, got %v", err)
+	}
+	if _, err := invoiceService.CreateFromBooking(business.ID, bookings[0].ID); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected a pending booking to be rejected, got %v", err)
+	}
+	for _, booking := range bookings {
+		if _, err := bookingService.UpdateStatus(business.ID, booking.ID, models.BookingStatusConfirmed); err != nil {
+			t.Fatalf("confirm booking: %v", err)
+		}
+	}
+
+	if _, err := invoiceService.CreateFromBooking(business.ID, bookings[0].ID); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected a booking that is not completed to be rejected, got %v", err)
+	}
+	for _, booking := range []*models.Booking{bookings[0], bookings[2]} {
+		if _, err := bookingService.UpdateStatus(business.ID, booking.ID, models.BookingStatusCompleted); err != nil {
+			t.Fatalf("complete booking: %v", err)
+		}
+	}
+
+	recordDepositPayment(t, db, bookings[0])
+	first, err := invoiceService.CreateFromBooking(business.ID, bookings[0].ID)
+	if err != nil {
+		t.Fatalf("create invoice: %v", err)
+	}
+	if first.Number != nil || first.Status != models.InvoiceStatusDraft {
+		t.Fatalf("expected an unnumbered draft, got %+v", first)
+	}
+	if first.TotalMinor != 20000 || first.DepositCreditMinor != 5000 || first.AmountDueMinor != 15000 || len(first.Lines) != 1 {
+		t.Fatalf("expected booking lines and deposit credit, got total %d credit %d due %d lines %d", first.TotalMinor, first.DepositCreditMinor, first.AmountDueMinor, len(first.Lines))
+	}
+	if _, err := invoiceService.CreateFromBooking(business.ID, bookings[0].ID); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected a second open invoice to be rejected, got %v", err)
+	}
+
+	vehicle := models.Vehicle{ID: uuid.New(), BusinessID: business.ID, CustomerID: uuid.New(), Year: 2021, Make: "Honda", Model: "Civic", LicensePlate: "ABC123"}
+	if err := db.Create(&vehicle).Error; err != nil {
+		t.Fatalf("create vehicle: %v", err)
+	}
+	job := models.Job{
+		ID:          uuid.New(),
+		BusinessID:  business.ID,
+		CustomerID:  vehicle.CustomerID,
+		VehicleID:   vehicle.ID,
+		BookingID:   &bookings[1].ID,
+		Title:       "Interior detail",
+		Status:      models.JobStatusReady,
+		ScheduledAt: now,
+		LineItems:   []models.JobLineItem{{BusinessID: business.ID, Name: "Odour treatment", Quantity: 2, UnitAmountMinor: 1500, AmountMinor: 3000}},
+	}
+	if err := db.Create(&job).Error; err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	if _, err := invoiceService.CreateFromJob(business.ID, job.ID); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected a job that is not delivered to be rejected, got %v", err)
+	}
+	if err := db.Model(&job).Update("status", models.JobStatusDelivered).Error; err != nil {
+		t.Fatalf("deliver job: %v", err)
+	}
+	second, err := invoiceService.CreateFromJob(business.ID, job.ID)
+	if err != nil {
+		t.Fatalf("create invoice from job: %v", err)
+	}
+	if second.JobID == nil || second.Reference != "Interior detail - 2021 Honda Civic (ABC123)" {
+		t.Fatalf("expected job reference on invoice, got %+v", second)
+	}
+	if len(second.Lines) != 2 || second.Lines[1].Kind != models.LineItemKindExtra || second.TotalMinor != 23000 {
+		t.Fatalf("expected the job's line item on the invoice, got total %d lines %+v", second.TotalMinor, second.Lines)
+	}
+	third, err := invoiceService.CreateFromBooking(business.ID, bookings[2].ID)
+	if err != nil {
+		t.Fatalf("create third invoice: %v", err)
+	}
+
+	issued, err := invoiceService.Issue(business.ID, first.ID, now)
+	if err != nil {
+		t.Fatalf("issue first invoice: %v", err)
+	}
+	if issued.Number == nil || *issued.Number != 1 {
+		t.Fatalf("expected invoice number 1, got %v", issued.Number)
+	}
+	if _, err := invoiceService.Issue(business.ID, first.ID, now); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected re-issue to be rejected, got %v", err)
+	}
+	if _, err := invoiceService.Void(business.ID, second.ID, now); err != nil {
+		t.Fatalf("void draft invoice: %v", err)
+	}
+	if _, err := invoiceService.Issue(business.ID, second.ID, now); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("expected voided draft to stay unissued, got %v", err)
+	}
+
+	issued, err = invoiceService.Issue(business.ID, third.ID, now)
+	if err != nil {
+		t.Fatalf("issue third invoice: %v", err)
+	}
+	if *issued.Number != 2 {
+		t.Fatalf("expected failed issues to leave no gap, got number %d", *issued.Number)
+	}
+
+	voided, err := invoiceService.Void(business.ID, first.ID, now)
+	if err != nil {
+		t.Fatalf("void issued invoice: %v", err)
+	}
+	if voided.Number == nil || *voided.Number != 1 {
+		t.Fatalf("expected voided invoice to keep its number, got %v", voided.Number)
+	}
+	if _, err := invoiceService.CreateFromBooking(business.ID, bookings[0].ID); err != nil {
+		t.Fatalf("expected a voided invoice to allow a new draft, got %v", err)
+	}
+}
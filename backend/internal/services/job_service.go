@@ -20,6 +20,7 @@ func (s *JobService) GetByBusiness(businessID uuid.UUID) ([]models.Job, error) {
 	if err := s.DB.Where("business_id = ?", businessID).
 		Preload("Customer", "business_id = ?", businessID).
 		Preload("Vehicle", "business_id = ?", businessID).
+		Preload("LineItems").
 		Order("scheduled_at ASC").
 		Find(&jobs).Error; err != nil {
 		return nil, err
@@ -27,7 +28,22 @@ func (s *JobService) GetByBusiness(businessID uuid.UUID) ([]models.Job, error) {
 	return jobs, nil
 }
 
+// Create opens a job for a customer's vehicle. Its line items are extra work
+// or parts billed on top of the booking when the job is invoiced; each needs a
+// name, and a quantity of zero counts as one.
 func (s *JobService) Create(job *models.Job) error {
+	for i := range job.LineItems {
+		item := &job.LineItems[i]
+		if item.Quantity == 0 {
+			item.Quantity = 1
+		}
+		if item.Name == "" || item.Quantity < 0 || item.UnitAmountMinor < 0 {
+			return ErrBadRequest
+		}
+		item.BusinessID = job.BusinessID
+		item.AmountMinor = int64(item.Quantity) * item.UnitAmountMinor
+	}
+
 	return s.DB.Transaction(func(tx *gorm.DB) error {
 		var customer models.Customer
 		if err := tx.Where("id = ? AND business_id = ?", job.CustomerID, job.BusinessID).First(&customer).Error; err != nil {
@@ -58,6 +74,15 @@ func (s *JobService) Create(job *models.Job) error {
 			}
 		}
 
-		return tx.Create(job).Error
+		if err := tx.Omit("LineItems").Create(job).Error; err != nil {
+			return err
+		}
+		for i := range job.LineItems {
+			job.LineItems[i].JobID = job.ID
+		}
+		if len(job.LineItems) == 0 {
+			return nil
+		}
+		return tx.Create(&job.LineItems).Error
 	})
 }
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"blytz.cloud/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// addMembership gives a user access to a business inside tx once the staff
+// seat limit has been checked under the plan lock. Registration and accepted
+// invitations are the only ways in. It returns ErrConflict when the user is
+// already a member and ErrPlanLimitReached when every seat is taken.
+func addMembership(tx *gorm.DB, membership *models.Membership) error {
+	if err := checkStaffSeatLimit(tx, membership.BusinessID); err != nil {
+		return err
+	}
+	var existing int64
+	if err := tx.Model(&models.Membership{}).
+		Where("user_id = ? AND business_id = ?", membership.UserID, membership.BusinessID).
+		Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return ErrConflict
+	}
+	return tx.Create(membership).Error
+}
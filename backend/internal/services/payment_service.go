@@ -0,0 +1,204 @@
+package services
+
+import (
+	"time"
+
+	"blytz.cloud/backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PaymentService struct {
+	*BaseService
+}
+
+func NewPaymentService(db *gorm.DB) *PaymentService {
+	return &PaymentService{
+		BaseService: NewBaseService(db),
+	}
+}
+
+// BookingBalance is a booking's position in the payments ledger. PaidMinor
+// and RefundedMinor are gross amounts; BalanceMinor is what the customer
+// still owes after refunds are taken back off what they paid.
+type BookingBalance struct {
+	CurrencyCode  string
+	TotalMinor    int64
+	PaidMinor     int64
+	RefundedMinor int64
+	BalanceMinor  int64
+}
+
+// NetPaidMinor is what the workshop has kept of the customer's payments.
+func (b BookingBalance) NetPaidMinor() int64 {
+	return b.PaidMinor - b.RefundedMinor
+}
+
+func ValidPaymentMethod(method models.PaymentMethod) bool {
+	switch method {
+	case models.PaymentMethodCash, models.PaymentMethodCard, models.PaymentMethodTransfer:
+		return true
+	}
+	return false
+}
+
+// GetByBooking returns a booking's ledger, oldest entry first, with the
+// balance it adds up to.
+func (s *PaymentService) GetByBooking(businessID, bookingID uuid.UUID) ([]models.PaymentRecord, BookingBalance, error) {
+	booking, err := loadLedgerBooking(s.DB, businessID, bookingID)
+	if err != nil {
+		return nil, BookingBalance{}, err
+	}
+
+	var payments []models.PaymentRecord
+	if err := s.DB.Where("booking_id = ? AND business_id = ?", booking.ID, businessID).
+		Order("recorded_at ASC, created_at ASC").
+		Find(&payments).Error; err != nil {
+		return nil, BookingBalance{}, err
+	}
+	balance, err := bookingBalance(s.DB, booking)
+	if err != nil {
+		return nil, BookingBalance{}, err
+	}
+	return payments, balance, nil
+}
+
+// Record adds a payment or refund to a booking's ledger. Payments may not
+// exceed the outstanding balance and refunds may not exceed what was paid;
+// either returns ErrConflict. A payment made against an issued invoice that
+// settles the booking marks that invoice paid.
+func (s *PaymentService) Record(payment *models.PaymentRecord, now time.Time) (BookingBalance, error) {
+	if payment.AmountMinor <= 0 || !ValidPaymentMethod(payment.Method) {
+		return BookingBalance{}, ErrBadRequest
+	}
+	if payment.Kind != models.PaymentKindPayment && payment.Kind != models.PaymentKindRefund {
+		return BookingBalance{}, ErrBadRequest
+	}
+	if payment.RecordedAt.IsZero() {
+		payment.RecordedAt = now
+	}
+	if payment.RecordedAt.After(now) {
+		return BookingBalance{}, ErrBadRequest
+	}
+
+	var balance BookingBalance
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		// Concurrent entries for the same booking are checked one at a time.
+		booking, err := lockLedgerBooking(tx, payment.BusinessID, payment.BookingID)
+		if err != nil {
+			return err
+		}
+
+		var invoice *models.Invoice
+		if payment.InvoiceID != nil {
+			invoice = &models.Invoice{}
+			if err := tx.Where("id = ? AND business_id = ? AND booking_id = ?", *payment.InvoiceID, payment.BusinessID, booking.ID).
+				First(invoice).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					return ErrBadRequest
+				}
+				return err
+			}
+			if invoice.Status == models.InvoiceStatusVoid {
+				return ErrBadRequest
+			}
+		}
+
+		current, err := bookingBalance(tx, booking)
+		if err != nil {
+			return err
+		}
+		if payment.Kind == models.PaymentKindPayment && payment.AmountMinor > current.BalanceMinor {
+			return ErrConflict
+		}
+		if payment.Kind == models.PaymentKindRefund && payment.AmountMinor > current.NetPaidMinor() {
+			return ErrConflict
+		}
+
+		payment.CurrencyCode = booking.CurrencyCode
+		if err := tx.Create(payment).Error; err != nil {
+			return err
+		}
+
+		balance, err = syncDepositPaid(tx, booking)
+		if err != nil {
+			return err
+		}
+
+		if invoice != nil && invoice.Status == models.InvoiceStatusIssued &&
+			payment.Kind == models.PaymentKindPayment && balance.BalanceMinor == 0 {
+			return transitionInvoice(tx, payment.BusinessID, invoice.ID, models.InvoiceStatusPaid, map[string]interface{}{"paid_at": payment.RecordedAt})
+		}
+		return nil
+	})
+	return balance, err
+}
+
+func loadLedgerBooking(tx *gorm.DB, businessID, bookingID uuid.UUID) (*models.Booking, error) {
+	var booking models.Booking
+	if err := tx.Where("id = ? AND business_id = ?", bookingID, businessID).First(&booking).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &booking, nil
+}
+
+// lockLedgerBooking loads a booking like loadLedgerBooking and locks its row
+// until tx ends.
+func lockLedgerBooking(tx *gorm.DB, businessID, bookingID uuid.UUID) (*models.Booking, error) {
+	return loadLedgerBooking(tx.Clauses(clause.Locking{Strength: "UPDATE"}), businessID, bookingID)
+}
+
+// syncDepositPaid sets the booking's deposit_paid_minor to how much of its
+// deposit the ledger shows as received, and returns the updated balance.
+func syncDepositPaid(tx *gorm.DB, booking *models.Booking) (BookingBalance, error) {
+	balance, err := bookingBalance(tx, booking)
+	if err != nil {
+		return BookingBalance{}, err
+	}
+	depositPaid := balance.NetPaidMinor()
+	if depositPaid > booking.DepositDueMinor {
+		depositPaid = booking.DepositDueMinor
+	}
+	if depositPaid < 0 {
+		depositPaid = 0
+	}
+	if err := tx.Model(&models.Booking{}).
+		Where("id = ?", booking.ID).
+		Update("deposit_paid_minor", depositPaid).Error; err != nil {
+		return BookingBalance{}, err
+	}
+	booking.DepositPaidMinor = depositPaid
+	return balance, nil
+}
+
+// bookingBalance adds up a booking's ledger against its total.
+func bookingBalance(tx *gorm.DB, booking *models.Booking) (BookingBalance, error) {
+	var sums []struct {
+		Kind  models.PaymentKind
+		Total int64
+	}
+	if err := tx.Model(&models.PaymentRecord{}).
+		Select("kind, COALESCE(SUM(amount_minor), 0) AS total").
+		Where("booking_id = ?", booking.ID).
+		Group("kind").
+		Scan(&sums).Error; err != nil {
+		return BookingBalance{}, err
+	}
+
+	balance := BookingBalance{CurrencyCode: booking.CurrencyCode, TotalMinor: booking.TotalPriceMinor}
+	for _, sum := range sums {
+		switch sum.Kind {
+		case models.PaymentKindPayment:
+			balance.PaidMinor = sum.Total
+		case models.PaymentKindRefund:
+			balance.RefundedMinor = sum.Total
+		}
+	}
+	balance.BalanceMinor = balance.TotalMinor - balance.NetPaidMinor()
+	return balance, nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"blytz.cloud/backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestPaymentServiceLedgerDerivesBalanceAndSettlesInvoice(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, slot := seedBookingTestRecords(t, db)
+	bookingService := NewBookingService(db)
+	paymentService := NewPaymentService(db)
+	now := time.Now().UTC()
+	staffID := uuid.New()
+
+	booking := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slot.ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.Create(booking); err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+
+	record := func(kind models.PaymentKind, amount int64, invoiceID *uuid.UUID) (BookingBalance, error) {
+		return paymentService.Record(&models.PaymentRecord{
+			BusinessID:   business.ID,
+			BookingID:    booking.ID,
+			InvoiceID:    invoiceID,
+			Kind:         kind,
+			Method:       models.PaymentMethodCash,
+			AmountMinor:  amount,
+			RecordedByID: &staffID,
+		}, now)
+	}
+
+	balance, err := record(models.PaymentKindPayment, 6000, nil)
+	if err != nil {
+		t.Fatalf("record payment: %v", err)
+	}
+	if balance.PaidMinor != 6000 || balance.BalanceMinor != 14000 {
+		t.Fatalf("expected 6000 paid and 14000 outstanding, got %+v", balance)
+	}
+	var reloaded models.Booking
+	if err := db.First(&reloaded, "id = ?", booking.ID).Error; err != nil {
+		t.Fatalf("reload booking: %v", err)
+	}
+	if reloaded.DepositPaidMinor != 5000 {
+		t.Fatalf("expected deposit paid capped at the 5000 due, got %d", reloaded.DepositPaidMinor)
+	}
+
+	if _, err := record(models.PaymentKindRefund, 7000, nil); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected a refund above the amount paid to be rejected, got %v", err)
+	}
+	balance, err = record(models.PaymentKindRefund, 2000, nil)
+	if err != nil {
+		t.Fatalf("record refund: %v", err)
+	}
+	if balance.RefundedMinor != 2000 || balance.BalanceMinor != 16000 {
+		t.Fatalf("expected refund to reopen the balance, got %+v", balance)
+	}
+	if err := db.First(&reloaded, "id = ?", booking.ID).Error; err != nil {
+		t.Fatalf("reload booking: %v", err)
+	}
+	if reloaded.DepositPaidMinor != 4000 {
+		t.Fatalf("expected deposit paid to follow the ledger, got %d", reloaded.DepositPaidMinor)
+	}
+	if _, err := record(models.PaymentKindPayment, 16001, nil); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected an overpayment to be rejected, got %v", err)
+	}
+
+	for _, status := range []models.BookingStatus{models.BookingStatusConfirmed, models.BookingStatusCompleted} {
+		if _, err := bookingService.UpdateStatus(business.ID, booking.ID, status); err != nil {
+			t.Fatalf("move booking to %s: %v", status, err)
+		}
+	}
+	invoiceService := NewInvoiceService(db)
+	invoice, err := invoiceService.CreateFromBooking(business.ID, booking.ID)
+	if err != nil {
+		t.Fatalf("create invoice: %v", err)
+	}
+	if invoice.DepositCreditMinor != 4000 || invoice.AmountDueMinor != 16000 {
+		t.Fatalf("expected invoice to credit the net amount paid, got credit %d due %d", invoice.DepositCreditMinor, invoice.AmountDueMinor)
+	}
+	if _, err := invoiceService.Issue(business.ID, invoice.ID, now); err != nil {
+		t.Fatalf("issue invoice: %v", err)
+	}
+
+	balance, err = record(models.PaymentKindPayment, 16000, &invoice.ID)
+	if err != nil {
+		t.Fatalf("settle invoice: %v", err)
+	}
+	if balance.BalanceMinor != 0 {
+		t.Fatalf("expected booking to be settled, got %+v", balance)
+	}
+	settled, err := invoiceService.GetByID(business.ID, invoice.ID)
+	if err != nil {
+		t.Fatalf("reload invoice: %v", err)
+	}
+	if settled.Status != models.InvoiceStatusPaid || settled.PaidAt == nil {
+		t.Fatalf("expected settling payment to mark the invoice paid, got %s", settled.Status)
+	}
+
+	payments, balance, err := paymentService.GetByBooking(business.ID, booking.ID)
+	if err != nil {
+		t.Fatalf("list payments: %v", err)
+	}
+	if len(payments) != 3 || balance.NetPaidMinor() != 20000 {
+		t.Fatalf("expected 3 ledger entries netting 20000, got %d netting %d", len(payments), balance.NetPaidMinor())
+	}
+	if payments[0].RecordedByID == nil || *payments[0].RecordedByID != staffID || payments[0].CurrencyCode != "USD" {
+		t.Fatalf("expected entries to record who took them and the booking currency, got %+v", payments[0])
+	}
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"time"
+
+	"blytz.cloud/backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PlanLimits caps what a workshop can use on its plan. Zero means unlimited.
+type PlanLimits struct {
+	StaffSeats      int
+	MonthlyBookings int
+	Services        int
+}
+
+var planLimits = map[models.SubscriptionPlan]PlanLimits{
+	models.SubscriptionPlanStarter: {StaffSeats: 2, MonthlyBookings: 150, Services: 10},
+	models.SubscriptionPlanPro:     {StaffSeats: 10},
+}
+
+// LimitsForPlan returns the limits of plan. Unknown plans get the starter
+// limits.
+func LimitsForPlan(plan models.SubscriptionPlan) PlanLimits {
+	if limits, ok := planLimits[plan]; ok {
+		return limits
+	}
+	return planLimits[models.SubscriptionPlanStarter]
+}
+
+// PlanUsage is a workshop's current usage against its plan's limits.
+type PlanUsage struct {
+	Plan            models.SubscriptionPlan
+	Limits          PlanLimits
+	StaffSeats      int64
+	MonthlyBookings int64
+	Services        int64
+	// PeriodStart is when the monthly booking count started.
+	PeriodStart time.Time
+}
+
+// Usage reports the business's usage against its plan at now.
+func (s *SubscriptionService) Usage(businessID uuid.UUID, now time.Time) (*PlanUsage, error) {
+	plan := models.SubscriptionPlanStarter
+	sub, err := s.GetByBusiness(businessID)
+	if err != nil && err != ErrNotFound {
+		return nil, err
+	}
+	if sub != nil {
+		plan = sub.Plan
+	}
+
+	usage := &PlanUsage{Plan: plan, Limits: LimitsForPlan(plan), PeriodStart: monthStart(now)}
+	if usage.StaffSeats, err = countStaffSeats(s.DB, businessID); err != nil {
+		return nil, err
+	}
+	if usage.MonthlyBookings, err = countMonthlyBookings(s.DB, businessID, now); err != nil {
+		return nil, err
+	}
+	if usage.Services, err = countServices(s.DB, businessID); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// loadPlanLimits returns the limits of the business's plan, locking its
+// subscription row for the rest of tx when lock is set. A business without a
+// subscription gets the starter limits.
+func loadPlanLimits(tx *gorm.DB, businessID uuid.UUID, lock bool) (PlanLimits, error) {
+	query := tx
+	if lock {
+		query = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var sub models.Subscription
+	if err := query.Where("business_id = ?", businessID).First(&sub).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return LimitsForPlan(models.SubscriptionPlanStarter), nil
+		}
+		return PlanLimits{}, err
+	}
+	return LimitsForPlan(sub.Plan), nil
+}
+
+// checkPlanLimit returns ErrPlanLimitReached when adding one more unit would
+// go over the limit that limit picks from the business's plan. When that
+// limit is set, the subscription row is locked before counting, so two
+// transactions cannot both take the last free unit; unlimited plans skip the
+// lock.
+func checkPlanLimit(tx *gorm.DB, businessID uuid.UUID, limit func(PlanLimits) int, count func() (int64, error)) error {
+	limits, err := loadPlanLimits(tx, businessID, false)
+	if err != nil {
+		return err
+	}
+	if limit(limits) == 0 {
+		return nil
+	}
+	// The plan may have changed before the lock was taken.
+	if limits, err = loadPlanLimits(tx, businessID, true); err != nil {
+		return err
+	}
+	allowed := limit(limits)
+	if allowed == 0 {
+		return nil
+	}
+	used, err := count()
+	if err != nil {
+		return err
+	}
+	if used >= int64(allowed) {
+		return ErrPlanLimitReached
+	}
+	return nil
+}
+
+func checkStaffSeatLimit(tx *gorm.DB, businessID uuid.UUID) error {
+	return checkPlanLimit(tx, businessID, func(l PlanLimits) int { return l.StaffSeats }, func() (int64, error) {
+		return countStaffSeats(tx, businessID)
+	})
+}
+
+func checkMonthlyBookingLimit(tx *gorm.DB, businessID uuid.UUID, now time.Time) error {
+	return checkPlanLimit(tx, businessID, func(l PlanLimits) int { return l.MonthlyBookings }, func() (int64, error) {
+		return countMonthlyBookings(tx, businessID, now)
+	})
+}
+
+func checkServiceLimit(tx *gorm.DB, businessID uuid.UUID) error {
+	return checkPlanLimit(tx, businessID, func(l PlanLimits) int { return l.Services }, func() (int64, error) {
+		return countServices(tx, businessID)
+	})
+}
+
+func countStaffSeats(db *gorm.DB, businessID uuid.UUID) (int64, error) {
+	var count int64
+	err := db.Model(&models.Membership{}).Where("business_id = ?", businessID).Count(&count).Error
+	return count, err
+}
+
+// countMonthlyBookings counts bookings created since the start of now's
+// calendar month (UTC), whatever became of them since.
+func countMonthlyBookings(db *gorm.DB, businessID uuid.UUID, now time.Time) (int64, error) {
+	var count int64
+	err := db.Model(&models.Booking{}).
+		Where("business_id = ? AND created_at >= ?", businessID, monthStart(now)).
+		Count(&count).Error
+	return count, err
+}
+
+// countServices counts the services a business offers; archived services do
+// not use up the limit.
+func countServices(db *gorm.DB, businessID uuid.UUID) (int64, error) {
+	var count int64
+	err := db.Model(&models.Service{}).Where("business_id = ? AND archived_at IS NULL", businessID).Count(&count).Error
+	return count, err
+}
+
+func monthStart(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
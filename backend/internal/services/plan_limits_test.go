@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"blytz.cloud/backend/internal/auth"
+	"blytz.cloud/backend/internal/models"
+	"blytz.cloud/backend/internal/notify"
+
+	"github.com/google/uuid"
+)
+
+func TestPlanLimitsCapServicesBookingsAndStaffSeats(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, slot := seedBookingTestRecords(t, db)
+	now := time.Now().UTC()
+	if err := db.Create(&models.Subscription{
+		BusinessID:       business.ID,
+		Plan:             models.SubscriptionPlanStarter,
+		Status:           models.SubscriptionStatusActive,
+		CurrentPeriodEnd: now.AddDate(0, 1, 0),
+	}).Error; err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+	starter := planLimits[models.SubscriptionPlanStarter]
+	planLimits[models.SubscriptionPlanStarter] = PlanLimits{StaffSeats: 1, MonthlyBookings: 1, Services: 2}
+	t.Cleanup(func() { planLimits[models.SubscriptionPlanStarter] = starter })
+
+	serviceService := NewServiceService(db)
+	extra := models.Service{BusinessID: business.ID, Name: "Ceramic Coat", DurationMin: 60, TotalPriceMinor: 1000, CurrencyCode: "USD"}
+	if err := serviceService.Create(&extra); err != nil {
+		t.Fatalf("create second service: %v", err)
+	}
+	third := models.Service{BusinessID: business.ID, Name: "Headlight Restore", DurationMin: 60, TotalPriceMinor: 1000, CurrencyCode: "USD"}
+	if err := serviceService.Create(&third); !errors.Is(err, ErrPlanLimitReached) {
+		t.Fatalf("expected a third service to reach the plan limit, got %v", err)
+	}
+	if _, err := serviceService.Archive(business.ID, extra.ID); err != nil {
+		t.Fatalf("archive service: %v", err)
+	}
+	if err := serviceService.Create(&third); err != nil {
+		t.Fatalf("expected archiving to free a service, got %v", err)
+	}
+
+	bookingService := NewBookingService(db)
+	first := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slot.ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.Create(first); err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+	next := seedConsecutiveSlots(t, db, business, slot.StartTime.Add(48*time.Hour), 1, 2*time.Hour)
+	second := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     next[0].ID,
+		Customer:   models.CustomerDetails{Name: "Bob", Email: "bob@example.com", Phone: "555-0202"},
+	}
+	if err := bookingService.Create(second); !errors.Is(err, ErrPlanLimitReached) {
+		t.Fatalf("expected a second booking this month to reach the plan limit, got %v", err)
+	}
+
+	// Both invitations go out while the single seat is free; whoever accepts
+	// second finds it taken.
+	auth.SetJWTSecret("test-secret")
+	notifier := &recordingNotifier{}
+	notify.SetNotifier(notifier)
+	t.Cleanup(func() { notify.SetNotifier(notify.LogNotifier{}) })
+	invitationService := NewInvitationService(db)
+	invite := func(email string, role models.MembershipRole) (string, error) {
+		if _, err := invitationService.Create(business.ID, uuid.New(), email, role, now); err != nil {
+			return "", err
+		}
+		body := notifier.messages[len(notifier.messages)-1].Body
+		return body[strings.Index(body, "invite_token=")+len("invite_token="):], nil
+	}
+	ownerToken, err := invite("owner@example.com", models.MembershipRoleOwner)
+	if err != nil {
+		t.Fatalf("invite owner: %v", err)
+	}
+	staffToken, err := invite("staff@example.com", models.MembershipRoleStaff)
+	if err != nil {
+		t.Fatalf("invite staff: %v", err)
+	}
+	if _, _, err := invitationService.Accept(ownerToken, "Owner", "owner-password", now); err != nil {
+		t.Fatalf("accept owner invitation: %v", err)
+	}
+	if _, _, err := invitationService.Accept(staffToken, "Staff", "staff-password", now); !errors.Is(err, ErrPlanLimitReached) {
+		t.Fatalf("expected accepting into a second seat to reach the plan limit, got %v", err)
+	}
+	if _, err := invite("mechanic@example.com", models.MembershipRoleStaff); !errors.Is(err, ErrPlanLimitReached) {
+		t.Fatalf("expected inviting with no free seat to reach the plan limit, got %v", err)
+	}
+
+	usage, err := NewSubscriptionService(db).Usage(business.ID, now)
+	if err != nil {
+		t.Fatalf("usage: %v", err)
+	}
+	if usage.StaffSeats != 1 || usage.MonthlyBookings != 1 || usage.Services != 2 {
+		t.Fatalf("expected 1 seat, 1 booking and 2 services in use, got %d, %d and %d", usage.StaffSeats, usage.MonthlyBookings, usage.Services)
+	}
+	if err := checkStaffSeatLimit(db, uuid.New()); err != nil {
+		t.Fatalf("expected a business without a subscription to use starter limits, got %v", err)
+	}
+}
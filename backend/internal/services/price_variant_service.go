@@ -0,0 +1,125 @@
+package services
+
+import (
+	"blytz.cloud/backend/internal/models"
+	"blytz.cloud/backend/internal/validator"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PriceVariantService struct {
+	*BaseService
+}
+
+func NewPriceVariantService(db *gorm.DB) *PriceVariantService {
+	return &PriceVariantService{
+		BaseService: NewBaseService(db),
+	}
+}
+
+// ValidVehicleClass reports whether class is one of the known vehicle classes.
+func ValidVehicleClass(class models.VehicleClass) bool {
+	switch class {
+	case models.VehicleClassSedan, models.VehicleClassHatchback, models.VehicleClassSUV,
+		models.VehicleClassTruck, models.VehicleClassVan:
+		return true
+	}
+	return false
+}
+
+// GetByService lists the vehicle-class prices configured for a service.
+func (s *PriceVariantService) GetByService(businessID, serviceID uuid.UUID) ([]models.ServicePriceVariant, error) {
+	var variants []models.ServicePriceVariant
+	if err := s.DB.Where("business_id = ? AND service_id = ?", businessID, serviceID).
+		Order("vehicle_class ASC").
+		Find(&variants).Error; err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+// Upsert sets the price of a service for one vehicle class, replacing any
+// earlier price for that class.
+func (s *PriceVariantService) Upsert(variant *models.ServicePriceVariant) (*models.ServicePriceVariant, error) {
+	if err := validatePriceVariant(variant); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.DB.Model(&models.Service{}).
+		Where("id = ? AND business_id = ? AND archived_at IS NULL", variant.ServiceID, variant.BusinessID).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrNotFound
+	}
+
+	if err := s.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "service_id"}, {Name: "vehicle_class"}},
+		DoUpdates: clause.AssignmentColumns([]string{"total_price_minor", "deposit_amount_minor", "updated_at"}),
+	}).Create(variant).Error; err != nil {
+		return nil, err
+	}
+
+	var saved models.ServicePriceVariant
+	if err := s.DB.Where("service_id = ? AND vehicle_class = ?", variant.ServiceID, variant.VehicleClass).First(&saved).Error; err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+func (s *PriceVariantService) Delete(businessID, serviceID uuid.UUID, class models.VehicleClass) error {
+	result := s.DB.Where("business_id = ? AND service_id = ? AND vehicle_class = ?", businessID, serviceID, class).
+		Delete(&models.ServicePriceVariant{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func validatePriceVariant(variant *models.ServicePriceVariant) error {
+	if !ValidVehicleClass(variant.VehicleClass) {
+		return ErrBadRequest
+	}
+	if !validator.ValidatePrice(variant.TotalPriceMinor) {
+		return ErrBadRequest
+	}
+	if variant.DepositAmountMinor < 0 || variant.DepositAmountMinor > variant.TotalPriceMinor {
+		return ErrBadRequest
+	}
+	return nil
+}
+
+// resolveVehicleClass picks the vehicle class a booking is priced for. A
+// known vehicle must belong to the business; its stored class wins, and an
+// explicit class that contradicts it is rejected.
+func resolveVehicleClass(tx *gorm.DB, businessID uuid.UUID, opts BookingOptions) (models.VehicleClass, error) {
+	class := opts.VehicleClass
+	if class != "" && !ValidVehicleClass(class) {
+		return "", ErrBadRequest
+	}
+	if opts.VehicleID == nil {
+		return class, nil
+	}
+
+	var vehicle models.Vehicle
+	if err := tx.Where("id = ? AND business_id = ?", *opts.VehicleID, businessID).First(&vehicle).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", ErrBadRequest
+		}
+		return "", err
+	}
+	if vehicle.Class == "" {
+		return class, nil
+	}
+	if class != "" && class != vehicle.Class {
+		return "", ErrBadRequest
+	}
+	return vehicle.Class, nil
+}
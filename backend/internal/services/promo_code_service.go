@@ -0,0 +1,211 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"blytz.cloud/backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var promoCodePattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9_-]{2,31}$`)
+
+type PromoCodeService struct {
+	*BaseService
+}
+
+func NewPromoCodeService(db *gorm.DB) *PromoCodeService {
+	return &PromoCodeService{
+		BaseService: NewBaseService(db),
+	}
+}
+
+// NormalizePromoCode is the form codes are stored and matched in.
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (s *PromoCodeService) GetByBusiness(businessID uuid.UUID) ([]models.PromoCode, error) {
+	var promos []models.PromoCode
+	if err := s.DB.Where("business_id = ?", businessID).
+		Preload("Restrictions").
+		Order("created_at DESC").
+		Find(&promos).Error; err != nil {
+		return nil, err
+	}
+	return promos, nil
+}
+
+// Create adds a promo code. Every restricted service must be an active
+// service of the business, and codes are unique within a business: a code
+// that already exists, even one created concurrently, returns ErrConflict.
+func (s *PromoCodeService) Create(promo *models.PromoCode) error {
+	if err := validatePromoCode(promo); err != nil {
+		return err
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if len(promo.Restrictions) > 0 {
+			var count int64
+			serviceIDs := make([]uuid.UUID, len(promo.Restrictions))
+			for i, restriction := range promo.Restrictions {
+				serviceIDs[i] = restriction.ServiceID
+			}
+			if err := tx.Model(&models.Service{}).
+				Where("id IN ? AND business_id = ? AND archived_at IS NULL", serviceIDs, promo.BusinessID).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if int(count) != len(serviceIDs) {
+				return ErrBadRequest
+			}
+		}
+
+		// The unique index on (business_id, code) decides between racing
+		// creates; the loser inserts nothing.
+		result := tx.Omit("Restrictions").Clauses(clause.OnConflict{DoNothing: true}).Create(promo)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrConflict
+		}
+		for i := range promo.Restrictions {
+			promo.Restrictions[i].PromoCodeID = promo.ID
+		}
+		if len(promo.Restrictions) == 0 {
+			return nil
+		}
+		return tx.Create(&promo.Restrictions).Error
+	})
+}
+
+// Archive stops a promo code from being redeemed. Past redemptions are kept.
+func (s *PromoCodeService) Archive(businessID, id uuid.UUID) (*models.PromoCode, error) {
+	var promo models.PromoCode
+	if err := s.DB.Where("id = ? AND business_id = ?", id, businessID).Preload("Restrictions").First(&promo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if promo.ArchivedAt != nil {
+		return &promo, nil
+	}
+
+	now := time.Now().UTC()
+	if err := s.DB.Model(&models.PromoCode{}).Where("id = ? AND archived_at IS NULL", promo.ID).Update("archived_at", now).Error; err != nil {
+		return nil, err
+	}
+	promo.ArchivedAt = &now
+	return &promo, nil
+}
+
+func validatePromoCode(promo *models.PromoCode) error {
+	promo.Code = NormalizePromoCode(promo.Code)
+	if !promoCodePattern.MatchString(promo.Code) {
+		return ErrBadRequest
+	}
+	switch promo.DiscountType {
+	case models.PromoDiscountPercent:
+		if promo.PercentOff < 1 || promo.PercentOff > 100 || promo.AmountOffMinor != 0 {
+			return ErrBadRequest
+		}
+	case models.PromoDiscountFixed:
+		if promo.AmountOffMinor <= 0 || promo.PercentOff != 0 {
+			return ErrBadRequest
+		}
+	default:
+		return ErrBadRequest
+	}
+	if promo.ValidFrom != nil && promo.ValidUntil != nil && !promo.ValidUntil.After(*promo.ValidFrom) {
+		return ErrBadRequest
+	}
+	if promo.MaxRedemptions < 0 || promo.PerCustomerLimit < 0 {
+		return ErrBadRequest
+	}
+	seen := make(map[uuid.UUID]struct{}, len(promo.Restrictions))
+	for _, restriction := range promo.Restrictions {
+		if _, ok := seen[restriction.ServiceID]; ok {
+			return ErrBadRequest
+		}
+		seen[restriction.ServiceID] = struct{}{}
+	}
+	return nil
+}
+
+// redeemPromoCode applies code to a booking being created in tx and returns
+// the redemption to record once the booking row exists. The discount is taken
+// off subtotalMinor. The redemption counter is bumped with a conditional
+// update before the per-customer count is taken, so concurrent redemptions of
+// one code queue on its row and cannot exceed either limit.
+func redeemPromoCode(tx *gorm.DB, booking *models.Booking, code string, subtotalMinor int64, now time.Time) (*models.PromoRedemption, error) {
+	var promo models.PromoCode
+	if err := tx.Where("business_id = ? AND code = ? AND archived_at IS NULL", booking.BusinessID, NormalizePromoCode(code)).
+		Preload("Restrictions").
+		First(&promo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrInvalidPromoCode
+		}
+		return nil, err
+	}
+	if promo.ValidFrom != nil && now.Before(*promo.ValidFrom) {
+		return nil, ErrInvalidPromoCode
+	}
+	if promo.ValidUntil != nil && !now.Before(*promo.ValidUntil) {
+		return nil, ErrInvalidPromoCode
+	}
+	if len(promo.Restrictions) > 0 {
+		allowed := false
+		for _, restriction := range promo.Restrictions {
+			if restriction.ServiceID == booking.ServiceID {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, ErrInvalidPromoCode
+		}
+	}
+
+	result := tx.Model(&models.PromoCode{}).
+		Where("id = ? AND (max_redemptions = 0 OR redemption_count < max_redemptions)", promo.ID).
+		Update("redemption_count", gorm.Expr("redemption_count + 1"))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidPromoCode
+	}
+
+	email := strings.ToLower(strings.TrimSpace(booking.Customer.Email))
+	if promo.PerCustomerLimit > 0 {
+		var used int64
+		if err := tx.Model(&models.PromoRedemption{}).
+			Where("promo_code_id = ? AND customer_email = ?", promo.ID, email).
+			Count(&used).Error; err != nil {
+			return nil, err
+		}
+		if int(used) >= promo.PerCustomerLimit {
+			return nil, ErrInvalidPromoCode
+		}
+	}
+
+	discount := promo.AmountOffMinor
+	if promo.DiscountType == models.PromoDiscountPercent {
+		discount = subtotalMinor * int64(promo.PercentOff) / 100
+	}
+	if discount > subtotalMinor {
+		discount = subtotalMinor
+	}
+	return &models.PromoRedemption{
+		PromoCodeID:   promo.ID,
+		BusinessID:    booking.BusinessID,
+		CustomerEmail: email,
+		DiscountMinor: discount,
+	}, nil
+}
@@ -1,7 +1,11 @@
 package services
 
 import (
+	"strings"
+	"time"
+
 	"blytz.cloud/backend/internal/models"
+	"blytz.cloud/backend/internal/validator"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -17,14 +21,18 @@ func NewServiceService(db *gorm.DB) *ServiceService {
 	}
 }
 
+// GetByBusiness lists the services a business currently offers. Archived
+// services are left out.
 func (s *ServiceService) GetByBusiness(businessID uuid.UUID) ([]models.Service, error) {
 	var services []models.Service
-	if err := s.DB.Where("business_id = ?", businessID).Find(&services).Error; err != nil {
+	if err := s.DB.Where("business_id = ? AND archived_at IS NULL", businessID).Find(&services).Error; err != nil {
 		return nil, err
 	}
 	return services, nil
 }
 
+// GetByID returns a service even when archived, so historical bookings can
+// still resolve it.
 func (s *ServiceService) GetByID(id uuid.UUID) (*models.Service, error) {
 	var service models.Service
 	if err := s.DB.Where("id = ?", id).First(&service).Error; err != nil {
@@ -35,3 +43,85 @@ func (s *ServiceService) GetByID(id uuid.UUID) (*models.Service, error) {
 	}
 	return &service, nil
 }
+
+// Create adds a service to the catalog. It returns ErrPlanLimitReached when
+// the business already offers as many services as its plan allows.
+func (s *ServiceService) Create(service *models.Service) error {
+	if err := validateService(service); err != nil {
+		return err
+	}
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkServiceLimit(tx, service.BusinessID); err != nil {
+			return err
+		}
+		return tx.Create(service).Error
+	})
+}
+
+func (s *ServiceService) Update(businessID, id uuid.UUID, service *models.Service) (*models.Service, error) {
+	if err := validateService(service); err != nil {
+		return nil, err
+	}
+
+	var existing models.Service
+	if err := s.DB.Where("id = ? AND business_id = ? AND archived_at IS NULL", id, businessID).First(&existing).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	existing.Name = service.Name
+	existing.Description = service.Description
+	existing.DurationMin = service.DurationMin
+	existing.TotalPriceMinor = service.TotalPriceMinor
+	existing.DepositAmountMinor = service.DepositAmountMinor
+	existing.CurrencyCode = service.CurrencyCode
+	if err := s.DB.Save(&existing).Error; err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// Archive hides a service from the catalog and from new bookings. The row is
+// kept because bookings reference it. Archiving twice is a no-op.
+func (s *ServiceService) Archive(businessID, id uuid.UUID) (*models.Service, error) {
+	var service models.Service
+	if err := s.DB.Where("id = ? AND business_id = ?", id, businessID).First(&service).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if service.ArchivedAt != nil {
+		return &service, nil
+	}
+
+	now := time.Now().UTC()
+	if err := s.DB.Model(&models.Service{}).Where("id = ? AND archived_at IS NULL", service.ID).Update("archived_at", now).Error; err != nil {
+		return nil, err
+	}
+	service.ArchivedAt = &now
+	return &service, nil
+}
+
+func validateService(service *models.Service) error {
+	service.Name = strings.TrimSpace(service.Name)
+	service.CurrencyCode = strings.ToUpper(strings.TrimSpace(service.CurrencyCode))
+	if !validator.ValidateServiceName(service.Name) {
+		return ErrBadRequest
+	}
+	if service.DurationMin <= 0 {
+		return ErrBadRequest
+	}
+	if !validator.ValidatePrice(service.TotalPriceMinor) {
+		return ErrBadRequest
+	}
+	if service.DepositAmountMinor < 0 || service.DepositAmountMinor > service.TotalPriceMinor {
+		return ErrBadRequest
+	}
+	if !validator.ValidateCurrencyCode(service.CurrencyCode) {
+		return ErrBadRequest
+	}
+	return nil
+}
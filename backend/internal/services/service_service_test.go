@@ -0,0 +1,84 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"blytz.cloud/backend/internal/models"
+)
+
+func TestServiceServiceCreateValidatesPricing(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, _, _ := seedBookingTestRecords(t, db)
+	serviceService := NewServiceService(db)
+
+	cases := map[string]models.Service{
+		"deposit above total": {Name: "Ceramic Coat", DurationMin: 60, TotalPriceMinor: 1000, DepositAmountMinor: 2000, CurrencyCode: "USD"},
+		"unknown currency":    {Name: "Ceramic Coat", DurationMin: 60, TotalPriceMinor: 1000, DepositAmountMinor: 0, CurrencyCode: "XYZ"},
+		"short name":          {Name: "Co", DurationMin: 60, TotalPriceMinor: 1000, DepositAmountMinor: 0, CurrencyCode: "USD"},
+	}
+	for name, service := range cases {
+		service.BusinessID = business.ID
+		if err := serviceService.Create(&service); !errors.Is(err, ErrBadRequest) {
+			t.Fatalf("%s: expected ErrBadRequest, got %v", name, err)
+		}
+	}
+
+	valid := models.Service{BusinessID: business.ID, Name: "Ceramic Coat", DurationMin: 60, TotalPriceMinor: 1000, DepositAmountMinor: 1000, CurrencyCode: "eur"}
+	if err := serviceService.Create(&valid); err != nil {
+		t.Fatalf("create service: %v", err)
+	}
+	if valid.CurrencyCode != "EUR" {
+		t.Fatalf("expected currency to be normalised to EUR, got %s", valid.CurrencyCode)
+	}
+}
+
+func TestServiceServiceArchiveHidesServiceButKeepsBookings(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, slot := seedBookingTestRecords(t, db)
+	serviceService := NewServiceService(db)
+	bookingService := NewBookingService(db)
+
+	booking := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slot.ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.Create(booking); err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+
+	if _, err := serviceService.Archive(business.ID, service.ID); err != nil {
+		t.Fatalf("archive service: %v", err)
+	}
+
+	listed, err := serviceService.GetByBusiness(business.ID)
+	if err != nil {
+		t.Fatalf("list services: %v", err)
+	}
+	if len(listed) != 0 {
+		t.Fatalf("expected archived service to be hidden, got %d services", len(listed))
+	}
+
+	historical, err := bookingService.GetByID(booking.ID)
+	if err != nil {
+		t.Fatalf("load booking: %v", err)
+	}
+	if historical.Service.ID != service.ID {
+		t.Fatal("expected booking to still resolve its archived service")
+	}
+
+	if _, err := bookingService.Cancel(business.ID, booking.ID); err != nil {
+		t.Fatalf("cancel booking: %v", err)
+	}
+	rebook := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slot.ID,
+		Customer:   models.CustomerDetails{Name: "Bob", Email: "bob@example.com", Phone: "555-0102"},
+	}
+	if err := bookingService.Create(rebook); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("expected archived service to reject new bookings, got %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"time"
+
+	"blytz.cloud/backend/internal/auth"
+	"blytz.cloud/backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SlotHoldService struct {
+	*BaseService
+}
+
+func NewSlotHoldService(db *gorm.DB) *SlotHoldService {
+	return &SlotHoldService{
+		BaseService: NewBaseService(db),
+	}
+}
+
+// Create reserves the slots a service needs starting at slotID for ttl and
+// returns the hold together with its plaintext token.
+func (s *SlotHoldService) Create(businessID, serviceID, slotID uuid.UUID, ttl time.Duration) (*models.SlotHold, string, error) {
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var hold models.SlotHold
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		created, err := createSlotHold(tx, businessID, serviceID, slotID, token, time.Now().UTC().Add(ttl))
+		if err != nil {
+			return err
+		}
+		hold = created
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return &hold, token, nil
+}
+
+// Release gives up an active hold before it expires.
+func (s *SlotHoldService) Release(token string) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		var hold models.SlotHold
+		if err := tx.Where("token_hash = ? AND status = ?", auth.HashOpaqueToken(token), models.SlotHoldStatusActive).First(&hold).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrNotFound
+			}
+			return err
+		}
+		return releaseSlotHold(tx, &hold)
+	})
+}
+
+// ReleaseExpired returns the capacity of every hold that expired before now.
+func (s *SlotHoldService) ReleaseExpired(now time.Time) (int, error) {
+	var holds []models.SlotHold
+	if err := s.DB.Where("status = ? AND expires_at <= ?", models.SlotHoldStatusActive, now).Find(&holds).Error; err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for i := range holds {
+		err := s.DB.Transaction(func(tx *gorm.DB) error {
+			return releaseSlotHold(tx, &holds[i])
+		})
+		if err == ErrConflict {
+			// Consumed or released by another request since it was loaded.
+			continue
+		}
+		if err != nil {
+			return released, err
+		}
+		released++
+	}
+	return released, nil
+}
+
+func createSlotHold(tx *gorm.DB, businessID, serviceID, slotID uuid.UUID, token string, expiresAt time.Time) (models.SlotHold, error) {
+	var service models.Service
+	if err := tx.Where("id = ? AND business_id = ? AND archived_at IS NULL", serviceID, businessID).First(&service).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.SlotHold{}, ErrBadRequest
+		}
+		return models.SlotHold{}, err
+	}
+
+	var slot models.Slot
+	if err := tx.Where("id = ? AND business_id = ?", slotID, businessID).First(&slot).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.SlotHold{}, ErrBadRequest
+		}
+		return models.SlotHold{}, err
+	}
+
+	run, err := findSlotRun(tx, slot, service.DurationMin)
+	if err != nil {
+		return models.SlotHold{}, err
+	}
+	if err := reserveSlots(tx, businessID, run); err != nil {
+		return models.SlotHold{}, err
+	}
+
+	hold := models.SlotHold{
+		BusinessID: businessID,
+		ServiceID:  serviceID,
+		SlotID:     slotID,
+		TokenHash:  auth.HashOpaqueToken(token),
+		Status:     models.SlotHoldStatusActive,
+		ExpiresAt:  expiresAt,
+	}
+	if err := tx.Create(&hold).Error; err != nil {
+		return models.SlotHold{}, err
+	}
+
+	links := make([]models.SlotHoldSlot, len(run))
+	for i, held := range run {
+		links[i] = models.SlotHoldSlot{HoldID: hold.ID, SlotID: held.ID}
+	}
+	if err := tx.Create(&links).Error; err != nil {
+		return models.SlotHold{}, err
+	}
+	return hold, nil
+}
+
+// consumeSlotHold hands an active, unexpired hold over to a booking and
+// returns the slots it had reserved. The capacity stays taken.
+func consumeSlotHold(tx *gorm.DB, booking *models.Booking, token string, now time.Time) ([]models.Slot, error) {
+	var hold models.SlotHold
+	if err := tx.Where("token_hash = ? AND business_id = ?", auth.HashOpaqueToken(token), booking.BusinessID).First(&hold).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrBadRequest
+		}
+		return nil, err
+	}
+	if hold.ServiceID != booking.ServiceID || hold.SlotID != booking.SlotID {
+		return nil, ErrBadRequest
+	}
+
+	result := tx.Model(&models.SlotHold{}).
+		Where("id = ? AND status = ? AND expires_at > ?", hold.ID, models.SlotHoldStatusActive, now).
+		Update("status", models.SlotHoldStatusConsumed)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrConflict
+	}
+	if err := markWaitlistOfferClaimed(tx, hold.ID); err != nil {
+		return nil, err
+	}
+
+	var slots []models.Slot
+	if err := tx.Where("id IN (?)", tx.Model(&models.SlotHoldSlot{}).Select("slot_id").Where("hold_id = ?", hold.ID)).
+		Order("start_time ASC").
+		Find(&slots).Error; err != nil {
+		return nil, err
+	}
+	return slots, nil
+}
+
+func releaseSlotHold(tx *gorm.DB, hold *models.SlotHold) error {
+	result := tx.Model(&models.SlotHold{}).
+		Where("id = ? AND status = ?", hold.ID, models.SlotHoldStatusActive).
+		Update("status", models.SlotHoldStatusReleased)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConflict
+	}
+
+	var slotIDs []uuid.UUID
+	if err := tx.Model(&models.SlotHoldSlot{}).Where("hold_id = ?", hold.ID).Pluck("slot_id", &slotIDs).Error; err != nil {
+		return err
+	}
+	if len(slotIDs) == 0 {
+		return nil
+	}
+	return releaseSlots(tx, hold.BusinessID, slotIDs)
+}
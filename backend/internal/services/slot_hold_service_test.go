@@ -0,0 +1,96 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"blytz.cloud/backend/internal/models"
+)
+
+func TestSlotHoldServiceHoldIsConsumedByBooking(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, slot := seedBookingTestRecords(t, db)
+	holdService := NewSlotHoldService(db)
+	bookingService := NewBookingService(db)
+
+	_, token, err := holdService.Create(business.ID, service.ID, slot.ID, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("create hold: %v", err)
+	}
+
+	competing := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slot.ID,
+		Customer:   models.CustomerDetails{Name: "Bob", Email: "bob@example.com", Phone: "555-0102"},
+	}
+	if err := bookingService.Create(competing); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected held slot to be unavailable, got %v", err)
+	}
+
+	booking := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slot.ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.CreateWithHold(booking, token); err != nil {
+		t.Fatalf("create booking with hold: %v", err)
+	}
+
+	var persisted models.Slot
+	if err := db.First(&persisted, "id = ?", slot.ID).Error; err != nil {
+		t.Fatalf("reload slot: %v", err)
+	}
+	if persisted.BookedCount != 1 {
+		t.Fatalf("expected hold capacity to transfer to the booking, got booked_count %d", persisted.BookedCount)
+	}
+
+	reuse := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slot.ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.CreateWithHold(reuse, token); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected consumed hold to be rejected, got %v", err)
+	}
+}
+
+func TestSlotHoldServiceReleaseExpiredFreesCapacity(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, slot := seedBookingTestRecords(t, db)
+	holdService := NewSlotHoldService(db)
+
+	_, token, err := holdService.Create(business.ID, service.ID, slot.ID, time.Minute)
+	if err != nil {
+		t.Fatalf("create hold: %v", err)
+	}
+
+	released, err := holdService.ReleaseExpired(time.Now().UTC().Add(2 * time.Minute))
+	if err != nil {
+		t.Fatalf("release expired holds: %v", err)
+	}
+	if released != 1 {
+		t.Fatalf("expected 1 released hold, got %d", released)
+	}
+
+	var persisted models.Slot
+	if err := db.First(&persisted, "id = ?", slot.ID).Error; err != nil {
+		t.Fatalf("reload slot: %v", err)
+	}
+	if persisted.BookedCount != 0 {
+		t.Fatalf("expected capacity to be released, got booked_count %d", persisted.BookedCount)
+	}
+
+	booking := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slot.ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := NewBookingService(db).CreateWithHold(booking, token); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected expired hold to be rejected, got %v", err)
+	}
+}
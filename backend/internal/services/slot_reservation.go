@@ -0,0 +1,153 @@
+package services
+
+import (
+	"time"
+
+	"blytz.cloud/backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// findSlotRun returns the contiguous slots starting at first that together
+// cover durationMin. It returns ErrConflict when the business has a gap
+// before the duration is covered, because the service cannot fit there.
+func findSlotRun(tx *gorm.DB, first models.Slot, durationMin int) ([]models.Slot, error) {
+	end := first.StartTime.Add(time.Duration(durationMin) * time.Minute)
+	if !first.EndTime.Before(end) {
+		return []models.Slot{first}, nil
+	}
+
+	var candidates []models.Slot
+	if err := tx.Where("business_id = ? AND start_time > ? AND start_time < ?", first.BusinessID, first.StartTime, end).
+		Order("start_time ASC").
+		Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	run := []models.Slot{first}
+	for _, slot := range candidates {
+		last := run[len(run)-1]
+		if !slot.StartTime.Equal(last.EndTime) {
+			return nil, ErrConflict
+		}
+		run = append(run, slot)
+		if !slot.EndTime.Before(end) {
+			return run, nil
+		}
+	}
+	return nil, ErrConflict
+}
+
+// fittingSlotStarts filters slots, ordered by start time, down to the ones
+// where a contiguous run of available slots covers durationMin.
+func fittingSlotStarts(slots []models.Slot, durationMin int) []models.Slot {
+	duration := time.Duration(durationMin) * time.Minute
+	fitting := make([]models.Slot, 0, len(slots))
+	for i, first := range slots {
+		end := first.StartTime.Add(duration)
+		last := first
+		for j := i + 1; last.EndTime.Before(end) && j < len(slots); j++ {
+			if !slots[j].StartTime.Equal(last.EndTime) {
+				break
+			}
+			last = slots[j]
+		}
+		if !last.EndTime.Before(end) {
+			fitting = append(fitting, first)
+		}
+	}
+	return fitting
+}
+
+// reserveSlots takes one unit of capacity from every slot only if it still
+// has room, returning ErrConflict when another booking won the race for any
+// of them.
+func reserveSlots(tx *gorm.DB, businessID uuid.UUID, slots []models.Slot) error {
+	for _, slot := range slots {
+		result := tx.Model(&models.Slot{}).
+			Where("id = ? AND business_id = ? AND booked_count < capacity", slot.ID, businessID).
+			Update("booked_count", gorm.Expr("booked_count + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrConflict
+		}
+	}
+	return nil
+}
+
+func linkBookingSlots(tx *gorm.DB, booking *models.Booking, slots []models.Slot) error {
+	links := make([]models.BookingSlot, len(slots))
+	for i, slot := range slots {
+		links[i] = models.BookingSlot{BookingID: booking.ID, SlotID: slot.ID, BusinessID: booking.BusinessID}
+	}
+	return tx.Create(&links).Error
+}
+
+// bookingSlotIDs returns every slot a booking occupies. Bookings created
+// before slot links existed only occupy their starting slot.
+func bookingSlotIDs(tx *gorm.DB, booking *models.Booking) ([]uuid.UUID, error) {
+	var slotIDs []uuid.UUID
+	if err := tx.Model(&models.BookingSlot{}).Where("booking_id = ?", booking.ID).Pluck("slot_id", &slotIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(slotIDs) == 0 {
+		slotIDs = []uuid.UUID{booking.SlotID}
+	}
+	return slotIDs, nil
+}
+
+// releaseBookingSlots returns one unit of capacity to every slot held by
+// the booking, removes its slot links and returns the released slots.
+func releaseBookingSlots(tx *gorm.DB, booking *models.Booking) ([]uuid.UUID, error) {
+	slotIDs, err := bookingSlotIDs(tx, booking)
+	if err != nil {
+		return nil, err
+	}
+	if err := releaseSlots(tx, booking.BusinessID, slotIDs); err != nil {
+		return nil, err
+	}
+	if err := tx.Where("booking_id = ?", booking.ID).Delete(&models.BookingSlot{}).Error; err != nil {
+		return nil, err
+	}
+	return slotIDs, nil
+}
+
+// slotsOutsideRun returns the slotIDs a rescheduled booking no longer
+// occupies after moving onto run.
+func slotsOutsideRun(slotIDs []uuid.UUID, run []models.Slot) []uuid.UUID {
+	kept := make(map[uuid.UUID]bool, len(run))
+	for _, slot := range run {
+		kept[slot.ID] = true
+	}
+	var freed []uuid.UUID
+	for _, slotID := range slotIDs {
+		if !kept[slotID] {
+			freed = append(freed, slotID)
+		}
+	}
+	return freed
+}
+
+func releaseSlots(tx *gorm.DB, businessID uuid.UUID, slotIDs []uuid.UUID) error {
+	return tx.Model(&models.Slot{}).
+		Where("id IN ? AND business_id = ? AND booked_count > 0", slotIDs, businessID).
+		Update("booked_count", gorm.Expr("booked_count - 1")).Error
+}
+
+// slotsNotIn returns the slots of run that are not already in taken.
+func slotsNotIn(run, taken []models.Slot) []models.Slot {
+	takenIDs := make(map[uuid.UUID]struct{}, len(taken))
+	for _, slot := range taken {
+		takenIDs[slot.ID] = struct{}{}
+	}
+	var remaining []models.Slot
+	for _, slot := range run {
+		if _, ok := takenIDs[slot.ID]; !ok {
+			remaining = append(remaining, slot)
+		}
+	}
+	return remaining
+}
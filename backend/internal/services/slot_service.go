@@ -19,12 +19,22 @@ func NewSlotService(db *gorm.DB) *SlotService {
 
 func (s *SlotService) GetAvailableByBusiness(businessID uuid.UUID) ([]models.Slot, error) {
 	var slots []models.Slot
-	if err := s.DB.Where("business_id = ? AND is_booked = ?", businessID, false).Order("start_time").Find(&slots).Error; err != nil {
+	if err := s.DB.Where("business_id = ? AND booked_count < capacity", businessID).Order("start_time").Find(&slots).Error; err != nil {
 		return nil, err
 	}
 	return slots, nil
 }
 
+// GetAvailableForDuration returns the available slots where a service of
+// durationMin fits into a contiguous run of available slots.
+func (s *SlotService) GetAvailableForDuration(businessID uuid.UUID, durationMin int) ([]models.Slot, error) {
+	slots, err := s.GetAvailableByBusiness(businessID)
+	if err != nil {
+		return nil, err
+	}
+	return fittingSlotStarts(slots, durationMin), nil
+}
+
 func (s *SlotService) GetByBusiness(businessID uuid.UUID) ([]models.Slot, error) {
 	var slots []models.Slot
 	if err := s.DB.Where("business_id = ?", businessID).Order("start_time").Find(&slots).Error; err != nil {
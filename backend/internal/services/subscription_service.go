@@ -0,0 +1,138 @@
+package services
+
+import (
+	"time"
+
+	"blytz.cloud/backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TrialPeriod is how long a new workshop can use the product before it needs
+// a paid plan.
+const TrialPeriod = 14 * 24 * time.Hour
+
+var trialGracePeriod = 3 * 24 * time.Hour
+
+// SetTrialGracePeriod sets how long a workshop whose trial has ended keeps
+// taking public bookings, giving the owner time to choose a plan.
+func SetTrialGracePeriod(duration time.Duration) {
+	if duration >= 0 {
+		trialGracePeriod = duration
+	}
+}
+
+type SubscriptionService struct {
+	*BaseService
+}
+
+func NewSubscriptionService(db *gorm.DB) *SubscriptionService {
+	return &SubscriptionService{
+		BaseService: NewBaseService(db),
+	}
+}
+
+// SubscriptionActive reports whether a workshop on sub may make changes and
+// take public bookings at now. A workshop without a subscription is lapsed.
+func SubscriptionActive(sub *models.Subscription, now time.Time) bool {
+	if sub == nil {
+		return false
+	}
+	switch sub.Status {
+	case models.SubscriptionStatusTrialing, models.SubscriptionStatusActive:
+		return now.Before(sub.CurrentPeriodEnd)
+	}
+	return false
+}
+
+func (s *SubscriptionService) GetByBusiness(businessID uuid.UUID) (*models.Subscription, error) {
+	var sub models.Subscription
+	if err := s.DB.Where("business_id = ?", businessID).First(&sub).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// SubscriptionTakesPublicBookings reports whether customers can book with a
+// workshop on sub at now. Besides active workshops, this covers a trial that
+// ended less than the grace period ago.
+func SubscriptionTakesPublicBookings(sub *models.Subscription, now time.Time) bool {
+	if SubscriptionActive(sub, now) {
+		return true
+	}
+	if sub == nil {
+		return false
+	}
+	switch sub.Status {
+	case models.SubscriptionStatusTrialing, models.SubscriptionStatusRestricted:
+		return now.Before(sub.CurrentPeriodEnd.Add(trialGracePeriod))
+	}
+	return false
+}
+
+// TrialDaysRemaining returns the whole or partial days left in a trial, or
+// nil when sub is not trialing.
+func TrialDaysRemaining(sub *models.Subscription, now time.Time) *int {
+	if sub == nil || sub.Status != models.SubscriptionStatusTrialing {
+		return nil
+	}
+	days := 0
+	if left := sub.CurrentPeriodEnd.Sub(now); left > 0 {
+		days = int((left + 24*time.Hour - 1) / (24 * time.Hour))
+	}
+	return &days
+}
+
+// GetByBusinesses returns the subscriptions of businessIDs keyed by business.
+// Businesses without one are left out.
+func (s *SubscriptionService) GetByBusinesses(businessIDs []uuid.UUID) (map[uuid.UUID]models.Subscription, error) {
+	byBusiness := make(map[uuid.UUID]models.Subscription, len(businessIDs))
+	if len(businessIDs) == 0 {
+		return byBusiness, nil
+	}
+	var subs []models.Subscription
+	if err := s.DB.Where("business_id IN ?", businessIDs).Find(&subs).Error; err != nil {
+		return nil, err
+	}
+	for _, sub := range subs {
+		byBusiness[sub.BusinessID] = sub
+	}
+	return byBusiness, nil
+}
+
+// TakesPublicBookings reports whether customers can book with the business at
+// now. A business without a subscription does not take bookings.
+func (s *SubscriptionService) TakesPublicBookings(businessID uuid.UUID, now time.Time) (bool, error) {
+	sub, err := s.GetByBusiness(businessID)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return SubscriptionTakesPublicBookings(sub, now), nil
+}
+
+// ExpireTrials restricts every trial whose period ended by now and returns how
+// many were restricted. The workshop keeps its data and can read it, but
+// cannot make changes until it has a paid plan.
+func (s *SubscriptionService) ExpireTrials(now time.Time) (int64, error) {
+	result := s.DB.Model(&models.Subscription{}).
+		Where("status = ? AND current_period_end <= ?", models.SubscriptionStatusTrialing, now).
+		Updates(map[string]interface{}{"status": models.SubscriptionStatusRestricted, "updated_at": now})
+	return result.RowsAffected, result.Error
+}
+
+// startTrial gives a new workshop a trial of the starter plan.
+func startTrial(tx *gorm.DB, businessID uuid.UUID, now time.Time) error {
+	return tx.Create(&models.Subscription{
+		BusinessID:       businessID,
+		Plan:             models.SubscriptionPlanStarter,
+		Status:           models.SubscriptionStatusTrialing,
+		CurrentPeriodEnd: now.Add(TrialPeriod),
+	}).Error
+}
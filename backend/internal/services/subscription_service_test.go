@@ -0,0 +1,56 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"blytz.cloud/backend/internal/models"
+)
+
+func TestSubscriptionServiceExpiresTrialsAfterGracePeriod(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, _, _ := seedBookingTestRecords(t, db)
+	subscriptionService := NewSubscriptionService(db)
+	now := time.Now().UTC()
+	if err := startTrial(db, business.ID, now); err != nil {
+		t.Fatalf("start trial: %v", err)
+	}
+
+	sub, err := subscriptionService.GetByBusiness(business.ID)
+	if err != nil {
+		t.Fatalf("load subscription: %v", err)
+	}
+	if days := TrialDaysRemaining(sub, now.Add(time.Hour)); days == nil || *days != 14 {
+		t.Fatalf("expected 14 trial days remaining, got %v", days)
+	}
+
+	if expired, err := subscriptionService.ExpireTrials(now.Add(TrialPeriod - time.Minute)); err != nil || expired != 0 {
+		t.Fatalf("expected no trial to expire early, got %d (%v)", expired, err)
+	}
+	ended := now.Add(TrialPeriod + time.Minute)
+	expired, err := subscriptionService.ExpireTrials(ended)
+	if err != nil {
+		t.Fatalf("expire trials: %v", err)
+	}
+	if expired != 1 {
+		t.Fatalf("expected 1 trial to expire, got %d", expired)
+	}
+
+	sub, err = subscriptionService.GetByBusiness(business.ID)
+	if err != nil {
+		t.Fatalf("reload subscription: %v", err)
+	}
+	if sub.Status != models.SubscriptionStatusRestricted || SubscriptionActive(sub, ended) {
+		t.Fatalf("expected an inactive RESTRICTED subscription, got %s", sub.Status)
+	}
+	if TrialDaysRemaining(sub, ended) != nil {
+		t.Fatalf("expected no trial days once restricted")
+	}
+
+	if ok, err := subscriptionService.TakesPublicBookings(business.ID, ended); err != nil || !ok {
+		t.Fatalf("expected public booking during the grace period, got %v (%v)", ok, err)
+	}
+	if ok, err := subscriptionService.TakesPublicBookings(business.ID, ended.Add(trialGracePeriod)); err != nil || ok {
+		t.Fatalf("expected public booking to stop after the grace period, got %v (%v)", ok, err)
+	}
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"strings"
+
+	"blytz.cloud/backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// basisPointsPerUnit is 100%, expressed in basis points.
+const basisPointsPerUnit = 10000
+
+type TaxRateService struct {
+	*BaseService
+}
+
+func NewTaxRateService(db *gorm.DB) *TaxRateService {
+	return &TaxRateService{
+		BaseService: NewBaseService(db),
+	}
+}
+
+func (s *TaxRateService) GetByBusiness(businessID uuid.UUID) ([]models.TaxRate, error) {
+	var rates []models.TaxRate
+	if err := s.DB.Where("business_id = ?", businessID).Order("name ASC").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+func (s *TaxRateService) Create(rate *models.TaxRate) error {
+	if err := validateTaxRate(rate); err != nil {
+		return err
+	}
+	return s.DB.Create(rate).Error
+}
+
+// Update changes a tax rate for future bookings. Existing bookings keep the
+// tax lines they were priced with.
+func (s *TaxRateService) Update(businessID, id uuid.UUID, rate *models.TaxRate) (*models.TaxRate, error) {
+	if err := validateTaxRate(rate); err != nil {
+		return nil, err
+	}
+
+	var existing models.TaxRate
+	if err := s.DB.Where("id = ? AND business_id = ?", id, businessID).First(&existing).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	existing.Name = rate.Name
+	existing.RateBasisPoints = rate.RateBasisPoints
+	existing.Inclusive = rate.Inclusive
+	if err := s.DB.Save(&existing).Error; err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+func (s *TaxRateService) Delete(businessID, id uuid.UUID) error {
+	result := s.DB.Where("id = ? AND business_id = ?", id, businessID).Delete(&models.TaxRate{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func validateTaxRate(rate *models.TaxRate) error {
+	rate.Name = strings.TrimSpace(rate.Name)
+	if rate.Name == "" || len(rate.Name) > 100 {
+		return ErrBadRequest
+	}
+	if rate.RateBasisPoints <= 0 || rate.RateBasisPoints > basisPointsPerUnit {
+		return ErrBadRequest
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"blytz.cloud/backend/internal/auth"
+	"blytz.cloud/backend/internal/models"
+	"blytz.cloud/backend/internal/notify"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const maxWaitlistRangeDays = 60
+
+var waitlistOfferDuration = 30 * time.Minute
+
+// SetWaitlistOfferDuration sets how long a waitlisted customer has to claim
+// an offered slot before it moves on to the next entry.
+func SetWaitlistOfferDuration(duration time.Duration) {
+	if duration > 0 {
+		waitlistOfferDuration = duration
+	}
+}
+
+type WaitlistService struct {
+	*BaseService
+}
+
+func NewWaitlistService(db *gorm.DB) *WaitlistService {
+	return &WaitlistService{
+		BaseService: NewBaseService(db),
+	}
+}
+
+func (s *WaitlistService) Create(entry *models.WaitlistEntry, now time.Time) error {
+	if !entry.PreferredFrom.Before(entry.PreferredTo) || !entry.PreferredTo.After(now) {
+		return ErrBadRequest
+	}
+	if entry.PreferredTo.Sub(entry.PreferredFrom) > maxWaitlistRangeDays*24*time.Hour {
+		return ErrBadRequest
+	}
+
+	var count int64
+	if err := s.DB.Model(&models.Service{}).Where("id = ? AND business_id = ? AND archived_at IS NULL", entry.ServiceID, entry.BusinessID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrBadRequest
+	}
+
+	entry.Status = models.WaitlistStatusWaiting
+	return s.DB.Create(entry).Error
+}
+
+func (s *WaitlistService) GetByBusiness(businessID uuid.UUID) ([]models.WaitlistEntry, error) {
+	var entries []models.WaitlistEntry
+	if err := s.DB.Where("business_id = ?", businessID).Order("created_at ASC").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// OfferSlot offers a freed slot to the oldest waiting entry whose preferred
+// range contains it and whose service fits there. It returns the entry that
+// received the offer, or nil when nobody could take it.
+func (s *WaitlistService) OfferSlot(businessID, slotID uuid.UUID, now time.Time) (*models.WaitlistEntry, error) {
+	var slot models.Slot
+	if err := s.DB.Where("id = ? AND business_id = ?", slotID, businessID).First(&slot).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !slot.StartTime.After(now) {
+		return nil, nil
+	}
+
+	var candidates []models.WaitlistEntry
+	if err := s.DB.Where("business_id = ? AND status = ? AND preferred_from <= ? AND preferred_to > ?",
+		businessID, models.WaitlistStatusWaiting, slot.StartTime, slot.StartTime).
+		Order("created_at ASC").
+		Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range candidates {
+		entry := &candidates[i]
+		token, err := auth.NewOpaqueToken()
+		if err != nil {
+			return nil, err
+		}
+		expiresAt := now.Add(waitlistOfferDuration)
+
+		err = s.DB.Transaction(func(tx *gorm.DB) error {
+			hold, err := createSlotHold(tx, businessID, entry.ServiceID, slot.ID, token, expiresAt)
+			if err != nil {
+				return err
+			}
+			result := tx.Model(&models.WaitlistEntry{}).
+				Where("id = ? AND status = ?", entry.ID, models.WaitlistStatusWaiting).
+				Updates(map[string]interface{}{
+					"status":           models.WaitlistStatusOffered,
+					"offer_hold_id":    hold.ID,
+					"offer_expires_at": expiresAt,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrConflict
+			}
+			entry.Status = models.WaitlistStatusOffered
+			entry.OfferHoldID = &hold.ID
+			entry.OfferExpiresAt = &expiresAt
+			return nil
+		})
+		if err == ErrConflict || err == ErrBadRequest {
+			// The service does not fit here, or the entry changed meanwhile.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		sendWaitlistOffer(entry, slot, token)
+		return entry, nil
+	}
+	return nil, nil
+}
+
+// ExpireOffers closes offers that were not claimed in time and passes each
+// freed slot on to the next matching entry.
+func (s *WaitlistService) ExpireOffers(now time.Time) (int, error) {
+	var entries []models.WaitlistEntry
+	if err := s.DB.Where("status = ? AND offer_expires_at <= ?", models.WaitlistStatusOffered, now).Find(&entries).Error; err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, entry := range entries {
+		var hold models.SlotHold
+		err := s.DB.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&models.WaitlistEntry{}).
+				Where("id = ? AND status = ?", entry.ID, models.WaitlistStatusOffered).
+				Update("status", models.WaitlistStatusExpired)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrConflict
+			}
+			if entry.OfferHoldID == nil {
+				return nil
+			}
+			if err := tx.Where("id = ?", *entry.OfferHoldID).First(&hold).Error; err != nil {
+				return err
+			}
+			// The slot hold sweeper may already have released it.
+			if err := releaseSlotHold(tx, &hold); err != nil && err != ErrConflict {
+				return err
+			}
+			return nil
+		})
+		if err == ErrConflict {
+			// Claimed by the customer since it was loaded.
+			continue
+		}
+		if err != nil {
+			return expired, err
+		}
+		expired++
+
+		if hold.ID != uuid.Nil {
+			if _, err := s.OfferSlot(hold.BusinessID, hold.SlotID, now); err != nil {
+				return expired, err
+			}
+		}
+	}
+	return expired, nil
+}
+
+// markWaitlistOfferClaimed records that the customer booked the slot offered
+// through hold.
+func markWaitlistOfferClaimed(tx *gorm.DB, holdID uuid.UUID) error {
+	return tx.Model(&models.WaitlistEntry{}).
+		Where("offer_hold_id = ? AND status = ?", holdID, models.WaitlistStatusOffered).
+		Update("status", models.WaitlistStatusBooked).Error
+}
+
+func sendWaitlistOffer(entry *models.WaitlistEntry, slot models.Slot, token string) {
+	msg := notify.Message{
+		To:      entry.Customer.Email,
+		Subject: "A slot has opened up",
+		Body: fmt.Sprintf("A slot at %s is available. Book it before %s with business_id=%s service_id=%s slot_id=%s hold_token=%s",
+			slot.StartTime.Format(time.RFC3339), entry.OfferExpiresAt.Format(time.RFC3339),
+			entry.BusinessID, entry.ServiceID, slot.ID, token),
+		Secrets: []string{token},
+	}
+	if err := notify.Send(msg); err != nil {
+		log.Printf("Failed to send waitlist offer %s: %v", entry.ID, err)
+	}
+}
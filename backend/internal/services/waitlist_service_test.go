@@ -0,0 +1,182 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"blytz.cloud/backend/internal/models"
+	"blytz.cloud/backend/internal/notify"
+
+	"gorm.io/gorm"
+)
+
+type recordingNotifier struct {
+	messages []notify.Message
+}
+
+func (n *recordingNotifier) Send(msg notify.Message) error {
+	n.messages = append(n.messages, msg)
+	return nil
+}
+
+func (n *recordingNotifier) lastHoldToken(t *testing.T, to string) string {
+	t.Helper()
+	for i := len(n.messages) - 1; i >= 0; i-- {
+		if n.messages[i].To != to {
+			continue
+		}
+		_, token, found := strings.Cut(n.messages[i].Body, "hold_token=")
+		if !found {
+			t.Fatalf("offer to %s has no hold token", to)
+		}
+		return token
+	}
+	t.Fatalf("no offer sent to %s", to)
+	return ""
+}
+
+func TestWaitlistOfferMovesOnWhenUnclaimed(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, _ := seedBookingTestRecords(t, db)
+	bookingService := NewBookingService(db)
+	waitlistService := NewWaitlistService(db)
+
+	notifier := &recordingNotifier{}
+	notify.SetNotifier(notifier)
+	t.Cleanup(func() { notify.SetNotifier(notify.LogNotifier{}) })
+
+	now := time.Now().UTC()
+	slots := seedConsecutiveSlots(t, db, business, now.Add(48*time.Hour).Truncate(time.Hour), 2, time.Hour)
+
+	booking := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slots[0].ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.Create(booking); err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+
+	var entries []*models.WaitlistEntry
+	for _, email := range []string{"bob@example.com", "carol@example.com"} {
+		entry := &models.WaitlistEntry{
+			BusinessID:    business.ID,
+			ServiceID:     service.ID,
+			PreferredFrom: now.Truncate(24 * time.Hour),
+			PreferredTo:   now.Truncate(24*time.Hour).AddDate(0, 0, 7),
+			Customer:      models.CustomerDetails{Name: "Waiting", Email: email, Phone: "555-0000"},
+		}
+		if err := waitlistService.Create(entry, now); err != nil {
+			t.Fatalf("create waitlist entry: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if _, err := bookingService.Cancel(business.ID, booking.ID); err != nil {
+		t.Fatalf("cancel booking: %v", err)
+	}
+	assertWaitlistStatus(t, db, entries[0], models.WaitlistStatusOffered)
+	assertWaitlistStatus(t, db, entries[1], models.WaitlistStatusWaiting)
+	notifier.lastHoldToken(t, "bob@example.com")
+
+	expired, err := waitlistService.ExpireOffers(now.Add(waitlistOfferDuration + time.Minute))
+	if err != nil {
+		t.Fatalf("expire offers: %v", err)
+	}
+	if expired != 1 {
+		t.Fatalf("expected 1 expired offer, got %d", expired)
+	}
+	assertWaitlistStatus(t, db, entries[0], models.WaitlistStatusExpired)
+	assertWaitlistStatus(t, db, entries[1], models.WaitlistStatusOffered)
+
+	claim := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slots[0].ID,
+		Customer:   models.CustomerDetails{Name: "Carol", Email: "carol@example.com", Phone: "555-0000"},
+	}
+	if err := bookingService.CreateWithHold(claim, notifier.lastHoldToken(t, "carol@example.com")); err != nil {
+		t.Fatalf("claim offer: %v", err)
+	}
+	assertWaitlistStatus(t, db, entries[1], models.WaitlistStatusBooked)
+}
+
+func TestWaitlistOffersEverySlotAMultiSlotBookingFrees(t *testing.T) {
+	db := setupBookingTestDB(t)
+	business, service, _ := seedBookingTestRecords(t, db)
+	bookingService := NewBookingService(db)
+	waitlistService := NewWaitlistService(db)
+
+	notifier := &recordingNotifier{}
+	notify.SetNotifier(notifier)
+	t.Cleanup(func() { notify.SetNotifier(notify.LogNotifier{}) })
+
+	express := models.Service{
+		BusinessID:      business.ID,
+		Name:            "Express Wash",
+		DurationMin:     60,
+		TotalPriceMinor: 5000,
+		CurrencyCode:    "USD",
+	}
+	if err := db.Create(&express).Error; err != nil {
+		t.Fatalf("create service: %v", err)
+	}
+
+	now := time.Now().UTC()
+	slots := seedConsecutiveSlots(t, db, business, now.Add(48*time.Hour).Truncate(time.Hour), 2, time.Hour)
+	booking := &models.Booking{
+		BusinessID: business.ID,
+		ServiceID:  service.ID,
+		SlotID:     slots[0].ID,
+		Customer:   models.CustomerDetails{Name: "Alice", Email: "alice@example.com", Phone: "555-0101"},
+	}
+	if err := bookingService.Create(booking); err != nil {
+		t.Fatalf("create booking: %v", err)
+	}
+
+	var entries []*models.WaitlistEntry
+	for _, email := range []string{"bob@example.com", "carol@example.com"} {
+		entry := &models.WaitlistEntry{
+			BusinessID:    business.ID,
+			ServiceID:     express.ID,
+			PreferredFrom: now.Truncate(24 * time.Hour),
+			PreferredTo:   now.Truncate(24*time.Hour).AddDate(0, 0, 7),
+			Customer:      models.CustomerDetails{Name: "Waiting", Email: email, Phone: "555-0000"},
+		}
+		if err := waitlistService.Create(entry, now); err != nil {
+			t.Fatalf("create waitlist entry: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if _, err := bookingService.Cancel(business.ID, booking.ID); err != nil {
+		t.Fatalf("cancel booking: %v", err)
+	}
+
+	offered := map[string]bool{}
+	for _, entry := range entries {
+		assertWaitlistStatus(t, db, entry, models.WaitlistStatusOffered)
+		var hold models.SlotHold
+		if err := db.Joins("JOIN waitlist_entries ON waitlist_entries.offer_hold_id = slot_holds.id").
+			Where("waitlist_entries.id = ?", entry.ID).First(&hold).Error; err != nil {
+			t.Fatalf("load offer hold: %v", err)
+		}
+		offered[hold.SlotID.String()] = true
+	}
+	if !offered[slots[0].ID.String()] || !offered[slots[1].ID.String()] {
+		t.Fatal("expected both slots the booking held to be offered")
+	}
+}
+
+func assertWaitlistStatus(t *testing.T, db *gorm.DB, entry *models.WaitlistEntry, expected models.WaitlistStatus) {
+	t.Helper()
+	var reloaded models.WaitlistEntry
+	if err := db.First(&reloaded, "id = ?", entry.ID).Error; err != nil {
+		t.Fatalf("reload waitlist entry: %v", err)
+	}
+	if reloaded.Status != expected {
+		t.Fatalf("expected waitlist entry for %s to be %s, got %s", entry.Customer.Email, expected, reloaded.Status)
+	}
+}